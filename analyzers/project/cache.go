@@ -0,0 +1,73 @@
+package project
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// FileCache caches the parsed project files (projects and the imported .props and .targets files) by path,
+// so the files imported by many projects of a solution are parsed once. It is safe for concurrent use.
+// A cached file is parsed again if its modification time or size changes.
+// The returned projects are shared between the callers, they must not be modified.
+// A cache has to be used with a single file system, the entries are keyed by path.
+type FileCache struct {
+	mutex   sync.Mutex
+	entries map[string]*fileCacheEntry
+}
+
+type fileCacheEntry struct {
+	ready   chan struct{} // closed when the file is parsed
+	modTime time.Time
+	size    int64
+
+	project Project
+	err     error
+}
+
+// NewFileCache ...
+func NewFileCache() *FileCache {
+	return &FileCache{entries: map[string]*fileCacheEntry{}}
+}
+
+// ParseProject parses the project file on the given path, or returns its cached parsed content.
+// A nil cache parses the file every time.
+func (cache *FileCache) ParseProject(pth string) (Project, error) {
+	return cache.parseProject(pth, utility.OSFileSystem)
+}
+
+// ParseProjectWithFileSystem parses the project file on the given path of the given file system, see ParseProject.
+func (cache *FileCache) ParseProjectWithFileSystem(fileSystem utility.FileSystem, pth string) (Project, error) {
+	return cache.parseProject(pth, fileSystem)
+}
+
+func (cache *FileCache) parseProject(pth string, fileSystem utility.FileSystem) (Project, error) {
+	if cache == nil {
+		return parseProject(pth, fileSystem)
+	}
+
+	info, err := fileSystem.Stat(pth)
+	if err != nil {
+		// parseProject reports the error
+		return parseProject(pth, fileSystem)
+	}
+
+	cache.mutex.Lock()
+	entry, ok := cache.entries[pth]
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		cache.mutex.Unlock()
+
+		<-entry.ready
+		return entry.project, entry.err
+	}
+
+	entry = &fileCacheEntry{ready: make(chan struct{}), modTime: info.ModTime(), size: info.Size()}
+	cache.entries[pth] = entry
+	cache.mutex.Unlock()
+
+	entry.project, entry.err = parseProject(pth, fileSystem)
+	close(entry.ready)
+
+	return entry.project, entry.err
+}
@@ -0,0 +1,317 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/utility"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCache(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__cache-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	pth := filepath.Join(tmpDir, "Directory.Build.props")
+	require.NoError(t, fileutil.WriteStringToFile(pth, `<Project><PropertyGroup><Version>1.0</Version></PropertyGroup></Project>`))
+
+	cache := NewFileCache()
+
+	t.Log("it returns the cached project for unchanged files")
+	{
+		first, err := cache.ParseProject(pth)
+		require.NoError(t, err)
+		second, err := cache.ParseProject(pth)
+		require.NoError(t, err)
+		require.Equal(t, first, second)
+		require.Equal(t, 1, len(cache.entries))
+	}
+
+	t.Log("it parses the file again if it changes")
+	{
+		require.NoError(t, fileutil.WriteStringToFile(pth, `<Project><PropertyGroup><Version>2.0</Version><Authors>me</Authors></PropertyGroup></Project>`))
+		modTime := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(pth, modTime, modTime))
+
+		project, err := cache.ParseProject(pth)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(project.PropertyGroups))
+		require.Contains(t, project.PropertyGroups[0].InnerXML, "<Version>2.0</Version>")
+	}
+
+	t.Log("a nil cache parses the file")
+	{
+		var nilCache *FileCache
+		project, err := nilCache.ParseProject(pth)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(project.PropertyGroups))
+	}
+
+	t.Log("it returns the parse errors")
+	{
+		_, err := cache.ParseProject(filepath.Join(tmpDir, "missing.props"))
+		require.Error(t, err)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__cache-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	pth := filepath.Join(tmpDir, "src", "App", "App.csproj")
+	require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+	require.NoError(t, fileutil.WriteStringToFile(pth, packageReferenceTestProjectContent))
+
+	cache, err := NewDiskCache(filepath.Join(tmpDir, "cache"))
+	require.NoError(t, err)
+
+	globalProperties := map[string]string{"Configuration": "Release"}
+	model, err := analyzeProject(pth, globalProperties, utility.OSFileSystem, nil)
+	require.NoError(t, err)
+
+	t.Log("it misses projects not stored yet")
+	{
+		_, ok := cache.Load(pth, globalProperties)
+		require.False(t, ok)
+	}
+
+	t.Log("it loads the stored project")
+	{
+		require.NoError(t, cache.Store(pth, globalProperties, model))
+
+		cached, ok := cache.Load(pth, globalProperties)
+		require.True(t, ok)
+		require.Equal(t, model.ID, cached.ID)
+		require.Equal(t, model.Packages, cached.Packages)
+
+		_, ok = cache.Load(pth, map[string]string{"Configuration": "Debug"})
+		require.False(t, ok)
+	}
+
+	t.Log("it invalidates the stored project if a new implicitly imported file appears")
+	{
+		propsPth := filepath.Join(tmpDir, "src", "Directory.Build.props")
+		require.NoError(t, fileutil.WriteStringToFile(propsPth, packageReferenceDirectoryBuildPropsTestContent))
+
+		_, ok := cache.Load(pth, globalProperties)
+		require.False(t, ok)
+
+		model, err := analyzeProject(pth, globalProperties, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+		require.NoError(t, cache.Store(pth, globalProperties, model))
+
+		_, ok = cache.Load(pth, globalProperties)
+		require.True(t, ok)
+	}
+
+	t.Log("it invalidates the stored project if the project file changes")
+	{
+		require.NoError(t, fileutil.WriteStringToFile(pth, androidTestProjectContent))
+
+		_, ok := cache.Load(pth, globalProperties)
+		require.False(t, ok)
+	}
+
+	t.Log("it invalidates the stored project if a referenced environment variable changes")
+	{
+		const envKey = "XAMARIN_DISK_CACHE_TEST_VERSION"
+		defer func() {
+			require.NoError(t, os.Unsetenv(envKey))
+		}()
+
+		content := `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0-android</TargetFramework>
+    <ApplicationDisplayVersion>$(` + envKey + `)</ApplicationDisplayVersion>
+  </PropertyGroup>
+</Project>`
+		require.NoError(t, fileutil.WriteStringToFile(pth, content))
+		require.NoError(t, os.Setenv(envKey, "1.0"))
+
+		model, err := analyzeProject(pth, globalProperties, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+		require.Equal(t, "1.0", model.Configs["Release|AnyCPU"].Properties["ApplicationDisplayVersion"])
+		require.NoError(t, cache.Store(pth, globalProperties, model))
+
+		_, ok := cache.Load(pth, globalProperties)
+		require.True(t, ok)
+
+		// the not referenced environment variables are not part of the analysis
+		require.NoError(t, os.Setenv("XAMARIN_DISK_CACHE_TEST_OTHER", "1"))
+		defer func() {
+			require.NoError(t, os.Unsetenv("XAMARIN_DISK_CACHE_TEST_OTHER"))
+		}()
+		_, ok = cache.Load(pth, globalProperties)
+		require.True(t, ok)
+
+		require.NoError(t, os.Setenv(envKey, "2.0"))
+		_, ok = cache.Load(pth, globalProperties)
+		require.False(t, ok)
+	}
+
+	t.Log("it invalidates the stored project if a new file matches a wildcard import")
+	{
+		content := `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0-android</TargetFramework>
+  </PropertyGroup>
+  <Import Project="..\build\*.props" />
+</Project>`
+		require.NoError(t, fileutil.WriteStringToFile(pth, content))
+		buildDir := filepath.Join(tmpDir, "src", "build")
+		require.NoError(t, os.MkdirAll(buildDir, 0755))
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(buildDir, "a.props"), `<Project />`))
+
+		model, err := analyzeProject(pth, globalProperties, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{filepath.Join(buildDir, "*.props")}, model.ImportPatterns)
+		require.NoError(t, cache.Store(pth, globalProperties, model))
+
+		_, ok := cache.Load(pth, globalProperties)
+		require.True(t, ok)
+
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(buildDir, "b.props"), `<Project />`))
+		_, ok = cache.Load(pth, globalProperties)
+		require.False(t, ok)
+	}
+}
+
+func TestDiskCacheProbedPaths(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__cache-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	pth := filepath.Join(tmpDir, "App", "App.csproj")
+	require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+	require.NoError(t, fileutil.WriteStringToFile(pth, `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0-android</TargetFramework>
+    <OutputType>Exe</OutputType>
+    <ApplicationId>com.a.$(Flavor)</ApplicationId>
+  </PropertyGroup>
+  <Import Project="..\local.props" Condition="Exists('..\local.props')" />
+  <PropertyGroup Condition="Exists('custom')">
+    <OutputPath>custom/out</OutputPath>
+  </PropertyGroup>
+</Project>`))
+
+	cache, err := NewDiskCache(filepath.Join(tmpDir, "cache"))
+	require.NoError(t, err)
+
+	analyze := func() (Model, []string) {
+		recorder := &utility.PathRecorder{}
+		model, err := analyzeProject(pth, nil, utility.OSFileSystem.WithRecorder(recorder), nil)
+		require.NoError(t, err)
+		return model, recorder.Paths()
+	}
+
+	t.Log("it records the probed paths, even the not existing ones")
+	{
+		model, inputs := analyze()
+		require.Contains(t, inputs, filepath.Join(tmpDir, "local.props"))
+		require.Contains(t, inputs, filepath.Join(tmpDir, "App", "custom"))
+		require.NoError(t, cache.Store(pth, nil, model, inputs...))
+
+		_, ok := cache.Load(pth, nil)
+		require.True(t, ok)
+	}
+
+	t.Log("it invalidates the stored project if a conditionally imported file appears")
+	{
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "local.props"), `<Project><PropertyGroup><Flavor>prod</Flavor></PropertyGroup></Project>`))
+
+		_, ok := cache.Load(pth, nil)
+		require.False(t, ok)
+
+		model, inputs := analyze()
+		require.Equal(t, "com.a.prod", model.ApplicationID)
+		require.NoError(t, cache.Store(pth, nil, model, inputs...))
+	}
+
+	t.Log("it invalidates the stored project if a path probed by an Exists() condition appears")
+	{
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "App", "custom"), 0755))
+
+		_, ok := cache.Load(pth, nil)
+		require.False(t, ok)
+
+		model, _ := analyze()
+		require.Equal(t, filepath.Join(tmpDir, "App", "custom", "out"), model.Configs["Release|AnyCPU"].OutputDir)
+	}
+}
+
+func TestDiskCacheStartupDirectory(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__cache-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+
+	pth := filepath.Join(tmpDir, "App.csproj")
+	require.NoError(t, fileutil.WriteStringToFile(pth, `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>netstandard2.0</TargetFramework>
+    <OutputPath>$(MSBuildStartupDirectory)/out</OutputPath>
+  </PropertyGroup>
+</Project>`))
+
+	t.Log("it invalidates the stored project referring to the working directory if the working directory changes")
+	{
+		cache, err := NewDiskCache(filepath.Join(tmpDir, "cache"))
+		require.NoError(t, err)
+
+		model, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+		require.NoError(t, cache.Store(pth, nil, model))
+
+		_, ok := cache.Load(pth, nil)
+		require.True(t, ok)
+
+		require.NoError(t, os.Chdir(tmpDir))
+		_, ok = cache.Load(pth, nil)
+		require.False(t, ok)
+	}
+}
+
+func TestDiskCacheDefaultFileSystem(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__cache-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	pth := filepath.Join(tmpDir, "App.csproj")
+	require.NoError(t, fileutil.WriteStringToFile(pth, sdkStyleLibraryTestProjectContent))
+
+	t.Log("it uses the OS file system by default")
+	{
+		cache, err := NewDiskCache(filepath.Join(tmpDir, "cache"))
+		require.NoError(t, err)
+
+		model, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+		require.NoError(t, cache.Store(pth, nil, model))
+
+		cached, ok := cache.Load(pth, nil)
+		require.True(t, ok)
+		require.Equal(t, model.AssemblyName, cached.AssemblyName)
+	}
+}
@@ -0,0 +1,745 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// Condition is a parsed MSBuild condition expression, like:
+// '$(Configuration)|$(Platform)' == 'Release|iPhone' And !Exists('$(SolutionDir)local.props')
+type Condition struct {
+	Expression string
+
+	root conditionNode
+}
+
+// ParseCondition parses the given MSBuild condition expression.
+// An empty expression is a valid condition, which is always true.
+func ParseCondition(expression string) (Condition, error) {
+	condition := Condition{Expression: expression}
+	if strings.TrimSpace(expression) == "" {
+		return condition, nil
+	}
+
+	tokens, err := tokenizeCondition(expression)
+	if err != nil {
+		return Condition{}, fmt.Errorf("invalid condition (%s): %s", expression, err)
+	}
+
+	parser := conditionParser{tokens: tokens}
+	root, err := parser.parseOr()
+	if err != nil {
+		return Condition{}, fmt.Errorf("invalid condition (%s): %s", expression, err)
+	}
+	if !parser.done() {
+		return Condition{}, fmt.Errorf("invalid condition (%s): unexpected token: %s", expression, parser.peek().value)
+	}
+
+	condition.root = root
+	return condition, nil
+}
+
+// EvaluateCondition parses and evaluates the given MSBuild condition expression with the given properties.
+func EvaluateCondition(expression string, properties map[string]string) (bool, error) {
+	return evaluateCondition(expression, properties, utility.OSFileSystem)
+}
+
+func evaluateCondition(expression string, properties map[string]string, fileSystem utility.FileSystem) (bool, error) {
+	condition, err := ParseCondition(expression)
+	if err != nil {
+		return false, err
+	}
+	return condition.evaluate(properties, fileSystem)
+}
+
+// Evaluate evaluates the condition with the given properties.
+// Property names are case insensitive, undefined properties are expanded to empty string.
+// Relative paths of Exists() calls are resolved against the MSBuildProjectDirectory property.
+func (condition Condition) Evaluate(properties map[string]string) (bool, error) {
+	return condition.evaluate(properties, utility.OSFileSystem)
+}
+
+func (condition Condition) evaluate(properties map[string]string, fileSystem utility.FileSystem) (bool, error) {
+	if condition.root == nil {
+		return true, nil
+	}
+
+	value, err := condition.root.boolValue(properties, fileSystem)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate condition (%s): %s", condition.Expression, err)
+	}
+	return value, nil
+}
+
+// PropertyBindings returns the values, which the condition compares the properties with.
+// For example the condition: '$(Configuration)|$(Platform)' == 'Release|iPhone'
+// binds Configuration to Release and Platform to iPhone.
+func (condition Condition) PropertyBindings() map[string][]string {
+	bindings := map[string][]string{}
+	if condition.root != nil {
+		condition.root.collectBindings(bindings)
+	}
+	return bindings
+}
+
+// BindingFor returns the first value the condition compares the given property with.
+func (condition Condition) BindingFor(property string) (string, bool) {
+	for name, values := range condition.PropertyBindings() {
+		if strings.EqualFold(name, property) && len(values) > 0 {
+			return values[0], true
+		}
+	}
+	return "", false
+}
+
+//
+// Tokenizer
+
+type conditionTokenKind int
+
+const (
+	tokenString   conditionTokenKind = iota // quoted string
+	tokenWord                               // unquoted string, property reference, number or function name
+	tokenOperator                           // ==, !=, <, >, <=, >=, !
+	tokenAnd
+	tokenOr
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type conditionToken struct {
+	kind  conditionTokenKind
+	value string
+}
+
+func tokenizeCondition(expression string) ([]conditionToken, error) {
+	var tokens []conditionToken
+
+	for i := 0; i < len(expression); {
+		c := expression[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, conditionToken{kind: tokenLeftParen, value: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, conditionToken{kind: tokenRightParen, value: ")"})
+			i++
+		case c == ',':
+			tokens = append(tokens, conditionToken{kind: tokenComma, value: ","})
+			i++
+		case c == '=' || c == '!' || c == '<' || c == '>':
+			if i+1 < len(expression) && expression[i+1] == '=' {
+				tokens = append(tokens, conditionToken{kind: tokenOperator, value: expression[i : i+2]})
+				i += 2
+			} else if c == '=' {
+				return nil, fmt.Errorf("unexpected character (=) at position %d", i)
+			} else {
+				tokens = append(tokens, conditionToken{kind: tokenOperator, value: string(c)})
+				i++
+			}
+		case c == '\'':
+			end, err := scanQuoted(expression, i+1)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, conditionToken{kind: tokenString, value: expression[i+1 : end]})
+			i = end + 1
+		default:
+			end, err := scanWord(expression, i)
+			if err != nil {
+				return nil, err
+			}
+			word := expression[i:end]
+			switch strings.ToLower(word) {
+			case "and":
+				tokens = append(tokens, conditionToken{kind: tokenAnd, value: word})
+			case "or":
+				tokens = append(tokens, conditionToken{kind: tokenOr, value: word})
+			default:
+				tokens = append(tokens, conditionToken{kind: tokenWord, value: word})
+			}
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+// scanQuoted returns the index of the closing quote,
+// quotes inside property functions like '$([System.String]::Copy('a'))' do not terminate the string.
+func scanQuoted(expression string, start int) (int, error) {
+	for i := start; i < len(expression); i++ {
+		switch expression[i] {
+		case '\'':
+			return i, nil
+		case '$', '@', '%':
+			if i+1 < len(expression) && expression[i+1] == '(' {
+				end, err := scanParens(expression, i+1)
+				if err != nil {
+					return 0, err
+				}
+				i = end
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated string starting at position %d", start-1)
+}
+
+// scanParens returns the index of the parenthesis closing the one at start.
+func scanParens(expression string, start int) (int, error) {
+	depth := 0
+	inQuote := false
+	for i := start; i < len(expression); i++ {
+		switch expression[i] {
+		case '\'':
+			inQuote = !inQuote
+		case '(':
+			if !inQuote {
+				depth++
+			}
+		case ')':
+			if !inQuote {
+				depth--
+				if depth == 0 {
+					return i, nil
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated parenthesis at position %d", start)
+}
+
+func scanWord(expression string, start int) (int, error) {
+	i := start
+	for i < len(expression) {
+		c := expression[i]
+		if (c == '$' || c == '@' || c == '%') && i+1 < len(expression) && expression[i+1] == '(' {
+			end, err := scanParens(expression, i+1)
+			if err != nil {
+				return 0, err
+			}
+			i = end + 1
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '(' || c == ')' || c == ',' ||
+			c == '=' || c == '!' || c == '<' || c == '>' || c == '\'' {
+			break
+		}
+		i++
+	}
+	if i == start {
+		return 0, fmt.Errorf("unexpected character (%c) at position %d", expression[start], start)
+	}
+	return i, nil
+}
+
+//
+// Parser
+
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+}
+
+func (parser *conditionParser) done() bool {
+	return parser.pos >= len(parser.tokens)
+}
+
+func (parser *conditionParser) peek() conditionToken {
+	if parser.done() {
+		return conditionToken{}
+	}
+	return parser.tokens[parser.pos]
+}
+
+func (parser *conditionParser) next() (conditionToken, error) {
+	if parser.done() {
+		return conditionToken{}, fmt.Errorf("unexpected end of expression")
+	}
+	token := parser.tokens[parser.pos]
+	parser.pos++
+	return token, nil
+}
+
+func (parser *conditionParser) expect(kind conditionTokenKind, value string) error {
+	token, err := parser.next()
+	if err != nil {
+		return err
+	}
+	if token.kind != kind {
+		return fmt.Errorf("expected %s, found: %s", value, token.value)
+	}
+	return nil
+}
+
+// or := and ('Or' and)*
+func (parser *conditionParser) parseOr() (conditionNode, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for !parser.done() && parser.peek().kind == tokenOr {
+		parser.pos++
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+// and := not ('And' not)*
+func (parser *conditionParser) parseAnd() (conditionNode, error) {
+	left, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for !parser.done() && parser.peek().kind == tokenAnd {
+		parser.pos++
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+// not := '!' not | comparison
+func (parser *conditionParser) parseNot() (conditionNode, error) {
+	if token := parser.peek(); token.kind == tokenOperator && token.value == "!" {
+		parser.pos++
+		operand, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return parser.parseComparison()
+}
+
+// comparison := operand (('==' | '!=' | '<' | '>' | '<=' | '>=') operand)?
+func (parser *conditionParser) parseComparison() (conditionNode, error) {
+	left, err := parser.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if token := parser.peek(); token.kind == tokenOperator && token.value != "!" {
+		parser.pos++
+		right, err := parser.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return compareNode{operator: token.value, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+// operand := '(' or ')' | string | word | word '(' arguments ')'
+func (parser *conditionParser) parseOperand() (conditionNode, error) {
+	token, err := parser.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token.kind {
+	case tokenLeftParen:
+		node, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := parser.expect(tokenRightParen, ")"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case tokenString:
+		return stringNode{value: token.value}, nil
+	case tokenWord:
+		if parser.peek().kind == tokenLeftParen {
+			return parser.parseFunction(token.value)
+		}
+		return stringNode{value: token.value}, nil
+	default:
+		return nil, fmt.Errorf("unexpected token: %s", token.value)
+	}
+}
+
+func (parser *conditionParser) parseFunction(name string) (conditionNode, error) {
+	// consume '('
+	parser.pos++
+
+	function := functionNode{name: name}
+	if parser.peek().kind == tokenRightParen {
+		parser.pos++
+		return function, nil
+	}
+
+	for {
+		argument, err := parser.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		function.arguments = append(function.arguments, argument)
+
+		token, err := parser.next()
+		if err != nil {
+			return nil, err
+		}
+		if token.kind == tokenRightParen {
+			return function, nil
+		}
+		if token.kind != tokenComma {
+			return nil, fmt.Errorf("expected , or ), found: %s", token.value)
+		}
+	}
+}
+
+//
+// Evaluation
+
+type conditionNode interface {
+	boolValue(properties map[string]string, fileSystem utility.FileSystem) (bool, error)
+	stringValue(properties map[string]string, fileSystem utility.FileSystem) (string, error)
+	collectBindings(bindings map[string][]string)
+}
+
+type andNode struct {
+	left, right conditionNode
+}
+
+func (node andNode) boolValue(properties map[string]string, fileSystem utility.FileSystem) (bool, error) {
+	left, err := node.left.boolValue(properties, fileSystem)
+	if err != nil || !left {
+		return false, err
+	}
+	return node.right.boolValue(properties, fileSystem)
+}
+
+func (node andNode) stringValue(properties map[string]string, fileSystem utility.FileSystem) (string, error) {
+	return boolString(node.boolValue(properties, fileSystem))
+}
+
+func (node andNode) collectBindings(bindings map[string][]string) {
+	node.left.collectBindings(bindings)
+	node.right.collectBindings(bindings)
+}
+
+type orNode struct {
+	left, right conditionNode
+}
+
+func (node orNode) boolValue(properties map[string]string, fileSystem utility.FileSystem) (bool, error) {
+	left, err := node.left.boolValue(properties, fileSystem)
+	if err != nil || left {
+		return left, err
+	}
+	return node.right.boolValue(properties, fileSystem)
+}
+
+func (node orNode) stringValue(properties map[string]string, fileSystem utility.FileSystem) (string, error) {
+	return boolString(node.boolValue(properties, fileSystem))
+}
+
+func (node orNode) collectBindings(bindings map[string][]string) {
+	node.left.collectBindings(bindings)
+	node.right.collectBindings(bindings)
+}
+
+type notNode struct {
+	operand conditionNode
+}
+
+func (node notNode) boolValue(properties map[string]string, fileSystem utility.FileSystem) (bool, error) {
+	value, err := node.operand.boolValue(properties, fileSystem)
+	return !value, err
+}
+
+func (node notNode) stringValue(properties map[string]string, fileSystem utility.FileSystem) (string, error) {
+	return boolString(node.boolValue(properties, fileSystem))
+}
+
+func (node notNode) collectBindings(bindings map[string][]string) {
+	node.operand.collectBindings(bindings)
+}
+
+type compareNode struct {
+	operator    string
+	left, right conditionNode
+}
+
+func (node compareNode) boolValue(properties map[string]string, fileSystem utility.FileSystem) (bool, error) {
+	left, err := node.left.stringValue(properties, fileSystem)
+	if err != nil {
+		return false, err
+	}
+	right, err := node.right.stringValue(properties, fileSystem)
+	if err != nil {
+		return false, err
+	}
+
+	switch node.operator {
+	case "==":
+		return compareEqual(left, right), nil
+	case "!=":
+		return !compareEqual(left, right), nil
+	}
+
+	cmp, err := compareOrdered(left, right)
+	if err != nil {
+		return false, err
+	}
+
+	switch node.operator {
+	case "<":
+		return cmp < 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	default:
+		return false, fmt.Errorf("unknown operator: %s", node.operator)
+	}
+}
+
+func (node compareNode) stringValue(properties map[string]string, fileSystem utility.FileSystem) (string, error) {
+	return boolString(node.boolValue(properties, fileSystem))
+}
+
+func (node compareNode) collectBindings(bindings map[string][]string) {
+	// only equality defines a value, '$(Configuration)' != 'Debug' does not tell what the configuration is
+	if node.operator != "==" {
+		return
+	}
+
+	left, leftOk := node.left.(stringNode)
+	right, rightOk := node.right.(stringNode)
+	if !leftOk || !rightOk {
+		return
+	}
+
+	// one side should reference properties, the other one should be a literal
+	if !containsPropertyReference(left.value) {
+		left, right = right, left
+	}
+	if !containsPropertyReference(left.value) || containsPropertyReference(right.value) {
+		return
+	}
+
+	// '$(Configuration)|$(Platform)' == 'Release|iPhone'
+	templates := strings.Split(left.value, "|")
+	values := strings.Split(right.value, "|")
+	if len(templates) != len(values) {
+		return
+	}
+
+	for i, template := range templates {
+		name, ok := propertyReferenceName(strings.TrimSpace(template))
+		if !ok {
+			continue
+		}
+		value := strings.TrimSpace(values[i])
+		if value == "" || sliceContainsFold(bindings[name], value) {
+			continue
+		}
+		bindings[name] = append(bindings[name], value)
+	}
+}
+
+type stringNode struct {
+	value string
+}
+
+func (node stringNode) boolValue(properties map[string]string, fileSystem utility.FileSystem) (bool, error) {
+	value, err := node.stringValue(properties, fileSystem)
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "on", "yes", "!false", "!off", "!no":
+		return true, nil
+	case "false", "off", "no", "!true", "!on", "!yes":
+		return false, nil
+	default:
+		return false, fmt.Errorf("expected boolean value, found: %s", value)
+	}
+}
+
+func (node stringNode) stringValue(properties map[string]string, fileSystem utility.FileSystem) (string, error) {
+	return expandProperties(node.value, properties, fileSystem), nil
+}
+
+func (node stringNode) collectBindings(bindings map[string][]string) {}
+
+type functionNode struct {
+	name      string
+	arguments []conditionNode
+}
+
+func (node functionNode) boolValue(properties map[string]string, fileSystem utility.FileSystem) (bool, error) {
+	if len(node.arguments) != 1 {
+		return false, fmt.Errorf("function %s expects 1 argument, got: %d", node.name, len(node.arguments))
+	}
+
+	argument, err := node.arguments[0].stringValue(properties, fileSystem)
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(node.name) {
+	case "exists":
+		return conditionPathExists(argument, properties, fileSystem)
+	case "hastrailingslash":
+		return strings.HasSuffix(argument, "/") || strings.HasSuffix(argument, `\`), nil
+	default:
+		return false, fmt.Errorf("unknown function: %s", node.name)
+	}
+}
+
+func (node functionNode) stringValue(properties map[string]string, fileSystem utility.FileSystem) (string, error) {
+	return boolString(node.boolValue(properties, fileSystem))
+}
+
+func (node functionNode) collectBindings(bindings map[string][]string) {}
+
+func conditionPathExists(pth string, properties map[string]string, fileSystem utility.FileSystem) (bool, error) {
+	pth = strings.TrimSpace(pth)
+	if pth == "" {
+		return false, nil
+	}
+
+	pth = utility.FixWindowsPath(pth)
+	if !filepath.IsAbs(pth) {
+		projectDir := lookupProperty(properties, "MSBuildProjectDirectory")
+		if projectDir == "" {
+			return false, nil
+		}
+		pth = filepath.Join(projectDir, pth)
+	}
+
+	return fileSystem.IsPathExists(pth)
+}
+
+func compareEqual(left, right string) bool {
+	if leftNumber, ok := parseConditionNumber(left); ok {
+		if rightNumber, ok := parseConditionNumber(right); ok {
+			return leftNumber == rightNumber
+		}
+	}
+	return strings.EqualFold(left, right)
+}
+
+func compareOrdered(left, right string) (int, error) {
+	if leftNumber, ok := parseConditionNumber(left); ok {
+		if rightNumber, ok := parseConditionNumber(right); ok {
+			switch {
+			case leftNumber < rightNumber:
+				return -1, nil
+			case leftNumber > rightNumber:
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		}
+	}
+
+	if leftVersion, ok := parseConditionVersion(left); ok {
+		if rightVersion, ok := parseConditionVersion(right); ok {
+			return compareVersions(leftVersion, rightVersion), nil
+		}
+	}
+
+	return 0, fmt.Errorf("can not compare non numeric values: %s, %s", left, right)
+}
+
+func parseConditionNumber(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(strings.ToLower(value), "0x") {
+		number, err := strconv.ParseInt(value[2:], 16, 64)
+		return float64(number), err == nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	return number, err == nil
+}
+
+func parseConditionVersion(value string) ([]int, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	parts := strings.Split(value, ".")
+	if len(parts) < 2 {
+		return nil, false
+	}
+
+	var version []int
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		version = append(version, number)
+	}
+	return version, true
+}
+
+func compareVersions(left, right []int) int {
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var l, r int
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		if l < r {
+			return -1
+		}
+		if l > r {
+			return 1
+		}
+	}
+	return 0
+}
+
+func boolString(value bool, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatBool(value), nil
+}
+
+func containsPropertyReference(value string) bool {
+	return strings.Contains(value, "$(")
+}
+
+// propertyReferenceName returns Name for a value like: $(Name)
+func propertyReferenceName(value string) (string, bool) {
+	if !strings.HasPrefix(value, "$(") || !strings.HasSuffix(value, ")") {
+		return "", false
+	}
+	name := strings.TrimSpace(value[2 : len(value)-1])
+	if name == "" || strings.ContainsAny(name, "()[]:.$' ") {
+		return "", false
+	}
+	return name, true
+}
+
+func sliceContainsFold(slice []string, value string) bool {
+	for _, item := range slice {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	properties := map[string]string{
+		"Configuration":   "Release",
+		"Platform":        "iPhone",
+		"TargetFramework": "net8.0-ios",
+		"WarningLevel":    "4",
+		"LangVersion":     "7.3",
+		"XcodeVersion":    "14.9.1",
+	}
+
+	t.Log("it evaluates comparisons")
+	{
+		for condition, expected := range map[string]bool{
+			"":                                  true,
+			" '$(Configuration)' == 'Release' ": true,
+			"'$(Configuration)'=='Debug'":       false,
+			"'$(configuration)' == 'RELEASE'":   true,
+			"'$(Configuration)' != 'Debug'":     true,
+			"'$(Configuration)|$(Platform)' == 'Release|iPhone'":            true,
+			"'$(Configuration)|$(Platform)'  ==  'Release|iPhoneSimulator'": false,
+			"'$(Undefined)' == ''":                 true,
+			"$(Configuration) == Release":          true,
+			"$(WarningLevel) > 3":                  true,
+			"'$(WarningLevel)' <= '3'":             false,
+			"'$(LangVersion)' >= '7.10'":           true,
+			"'$(XcodeVersion)' >= '14.10.0'":       false,
+			"'$(TargetFramework)' == 'net8.0-ios'": true,
+		} {
+			value, err := EvaluateCondition(condition, properties)
+			require.NoError(t, err, condition)
+			require.Equal(t, expected, value, condition)
+		}
+	}
+
+	t.Log("it evaluates logical operators")
+	{
+		for condition, expected := range map[string]bool{
+			"'$(Configuration)' == 'Release' And '$(Platform)' == 'iPhone'":                               true,
+			"'$(Configuration)' == 'Release' and '$(Platform)' == 'AnyCPU'":                               false,
+			"'$(Configuration)' == 'Debug' Or '$(Platform)' == 'iPhone'":                                  true,
+			"'$(Configuration)' == 'Debug' OR '$(Platform)' == 'AnyCPU'":                                  false,
+			"!('$(Configuration)' == 'Debug')":                                                            true,
+			"('$(Configuration)' == 'Debug' Or '$(Configuration)' == 'Release') And '$(Platform)' != ''":  true,
+			"'$(Configuration)' == 'Debug' Or '$(Configuration)' == 'Release' And '$(Platform)' == 'x86'": false,
+			"true":   true,
+			"!false": true,
+			"'$(Configuration)' == 'Release' And !Exists('does/not/exist.props')": true,
+			"HasTrailingSlash('$(Configuration)')":                                false,
+			"HasTrailingSlash('bin\\')":                                           true,
+		} {
+			value, err := EvaluateCondition(condition, properties)
+			require.NoError(t, err, condition)
+			require.Equal(t, expected, value, condition)
+		}
+	}
+
+	t.Log("it resolves Exists relative to the project directory")
+	{
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("__condition-test__")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, os.RemoveAll(tmpDir))
+		}()
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "local.props"), "<Project />"))
+
+		properties := map[string]string{"MSBuildProjectDirectory": tmpDir}
+
+		value, err := EvaluateCondition("Exists('local.props')", properties)
+		require.NoError(t, err)
+		require.Equal(t, true, value)
+
+		value, err = EvaluateCondition("Exists('$(MSBuildProjectDirectory)\\local.props')", properties)
+		require.NoError(t, err)
+		require.Equal(t, true, value)
+
+		value, err = EvaluateCondition("Exists('other.props')", properties)
+		require.NoError(t, err)
+		require.Equal(t, false, value)
+	}
+
+	t.Log("it fails for invalid conditions")
+	{
+		for _, condition := range []string{
+			"'$(Configuration)' == 'Release",
+			"'$(Configuration)' = 'Release'",
+			"'$(Configuration)' == ",
+			"('$(Configuration)' == 'Release'",
+			"'$(Configuration)' == 'Release' 'Debug'",
+		} {
+			_, err := ParseCondition(condition)
+			require.Error(t, err, condition)
+		}
+
+		_, err := EvaluateCondition("'$(Configuration)'", properties)
+		require.Error(t, err)
+
+		_, err = EvaluateCondition("'$(Configuration)' > 'Debug'", properties)
+		require.Error(t, err)
+	}
+}
+
+func TestConditionPropertyBindings(t *testing.T) {
+	t.Log("it binds Configuration and Platform")
+	{
+		condition, err := ParseCondition(" '$(Configuration)|$(Platform)' == 'Release|iPhone' ")
+		require.NoError(t, err)
+		require.Equal(t, map[string][]string{"Configuration": {"Release"}, "Platform": {"iPhone"}}, condition.PropertyBindings())
+	}
+
+	t.Log("it binds properties of compound conditions")
+	{
+		condition, err := ParseCondition("'$(Configuration)' == 'Debug' Or ('$(Configuration)' == 'Ad-Hoc' And '$(TargetFramework)|$(Platform)' == 'net8.0-ios|AnyCPU')")
+		require.NoError(t, err)
+		require.Equal(t, map[string][]string{
+			"Configuration":   {"Debug", "Ad-Hoc"},
+			"TargetFramework": {"net8.0-ios"},
+			"Platform":        {"AnyCPU"},
+		}, condition.PropertyBindings())
+
+		value, ok := condition.BindingFor("configuration")
+		require.Equal(t, true, ok)
+		require.Equal(t, "Debug", value)
+	}
+
+	t.Log("it does not bind non literal comparisons")
+	{
+		condition, err := ParseCondition("'$(Configuration)' == '$(DefaultConfiguration)' And Exists('$(Platform).props')")
+		require.NoError(t, err)
+		require.Equal(t, 0, len(condition.PropertyBindings()))
+	}
+}
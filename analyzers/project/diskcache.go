@@ -0,0 +1,221 @@
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// diskCacheVersion is part of the cache keys, it has to be increased if the Model or the analysis changes.
+const diskCacheVersion = 5
+
+// DiskCache stores the analyzed projects on disk, keyed by the project's path and the global properties.
+// A stored project is used only if the content of every file it was analyzed from is unchanged: the project file,
+// its imports, the implicitly imported files (like Directory.Build.props, even the ones not existing at the time of the analysis)
+// and packages.config, and the paths probed by the analysis (like the conditional imports and the Exists() conditions,
+// recorded by a utility.PathRecorder) still exist or do not exist. The files matching its wildcard imports have to be unchanged,
+// the environment variables referenced by the input files (and MSBuildStartupDirectory, the working directory) have to be the same.
+type DiskCache struct {
+	Dir string
+
+	fileSystem utility.FileSystem // The file system of the analyzed projects
+}
+
+type diskCacheEntry struct {
+	Inputs      map[string]string // File path - content hash map, empty hash for the not existing files
+	Globs       map[string]string // Wildcard import pattern - hash of the matching file paths
+	Environment map[string]string // Environment variable - value map, empty value for the not set variables
+	// The working directory (MSBuildStartupDirectory) of the analysis, empty if the input files do not refer to it
+	StartupDirectory string
+	Model            Model
+}
+
+// NewDiskCache creates the cache directory if it does not exist, the cache is used for the projects of the OS file system.
+func NewDiskCache(dir string) (DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return DiskCache{}, fmt.Errorf("failed to create cache directory (%s), error: %s", dir, err)
+	}
+	return DiskCache{Dir: dir, fileSystem: utility.OSFileSystem}, nil
+}
+
+// WithFileSystem returns a copy of the cache for the projects of the given file system,
+// the content hashes of the stored projects' input files are calculated on that file system.
+func (cache DiskCache) WithFileSystem(fileSystem utility.FileSystem) DiskCache {
+	cache.fileSystem = fileSystem
+	return cache
+}
+
+// Load returns the stored analysis of the given project, if none of its input files changed since it was stored.
+func (cache DiskCache) Load(pth string, globalProperties map[string]string) (Model, bool) {
+	content, err := os.ReadFile(cache.entryPth(pth, globalProperties))
+	if err != nil {
+		return Model{}, false
+	}
+
+	var entry diskCacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		debugLog(fmt.Errorf("invalid cache entry: %s", err), pth)
+		return Model{}, false
+	}
+
+	for inputPth, hash := range entry.Inputs {
+		if inputHash(cache.fileSystem, inputPth) != hash {
+			return Model{}, false
+		}
+	}
+	for pattern, hash := range entry.Globs {
+		if globHash(cache.fileSystem, pattern) != hash {
+			return Model{}, false
+		}
+	}
+	for name, value := range entry.Environment {
+		if environmentVariable(name) != value {
+			return Model{}, false
+		}
+	}
+	if entry.StartupDirectory != "" {
+		if wd, err := os.Getwd(); err != nil || wd != entry.StartupDirectory {
+			return Model{}, false
+		}
+	}
+	return entry.Model, true
+}
+
+// Store stores the given analysis of the project with the content hashes of its input files,
+// the files matching its wildcard imports and the environment variables referenced by its input files.
+// The given input paths (like the paths recorded while analyzing the project, see utility.PathRecorder) are added to the project's known inputs.
+func (cache DiskCache) Store(pth string, globalProperties map[string]string, model Model, inputPths ...string) error {
+	entry := diskCacheEntry{Inputs: map[string]string{}, Globs: map[string]string{}, Environment: map[string]string{}, Model: model}
+	for _, name := range implicitEnvironmentProperties {
+		entry.Environment[name] = environmentVariable(name)
+	}
+	for _, inputPth := range append(analysisInputs(model), inputPths...) {
+		if _, ok := entry.Inputs[inputPth]; ok {
+			continue
+		}
+
+		entry.Inputs[inputPth] = inputHash(cache.fileSystem, inputPth)
+		content, err := cache.fileSystem.ReadFile(inputPth)
+		if err != nil {
+			continue
+		}
+
+		for _, name := range referencedNames(string(content)) {
+			if strings.EqualFold(name, "MSBuildStartupDirectory") {
+				if wd, err := os.Getwd(); err == nil {
+					entry.StartupDirectory = wd
+				}
+				continue
+			}
+			entry.Environment[name] = environmentVariable(name)
+		}
+	}
+	for _, pattern := range model.ImportPatterns {
+		entry.Globs[pattern] = globHash(cache.fileSystem, pattern)
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// the entry is renamed into place, so concurrent analyses never read partially written entries
+	tmpFile, err := os.CreateTemp(cache.Dir, "entry")
+	if err != nil {
+		return err
+	}
+	if _, err := tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), cache.entryPth(pth, globalProperties))
+}
+
+func (cache DiskCache) entryPth(pth string, globalProperties map[string]string) string {
+	keys := []string{}
+	for key := range globalProperties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n%s\n", diskCacheVersion, pth)
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%s\n", key, globalProperties[key])
+	}
+	return filepath.Join(cache.Dir, hex.EncodeToString(hash.Sum(nil))+".json")
+}
+
+// analysisInputs returns the paths of the files the given project's analysis depends on.
+func analysisInputs(model Model) []string {
+	inputs := []string{model.Pth}
+	for _, imported := range model.Imports {
+		inputs = append(inputs, imported.Pth)
+	}
+
+	projectDir := filepath.Dir(model.Pth)
+	projectName := strings.TrimSuffix(filepath.Base(model.Pth), filepath.Ext(model.Pth))
+	inputs = append(inputs, filepath.Join(projectDir, "packages."+projectName+".config"), filepath.Join(projectDir, "packages.config"))
+
+	// the implicitly imported files are looked up in the project's directory and above
+	for dir := projectDir; ; dir = filepath.Dir(dir) {
+		for _, fileName := range []string{"Directory.Build.props", "Directory.Build.targets", "Directory.Packages.props"} {
+			inputs = append(inputs, filepath.Join(dir, fileName))
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	return inputs
+}
+
+// inputHash returns the hex encoded SHA-256 hash of the file's content, "dir" for directories
+// or an empty string if the path does not exist.
+func inputHash(fileSystem utility.FileSystem, pth string) string {
+	info, err := fileSystem.Stat(pth)
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		return "dir"
+	}
+
+	content, err := fileSystem.ReadFile(pth)
+	if err != nil {
+		return ""
+	}
+	return hashContent(content)
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// globHash returns the hex encoded SHA-256 hash of the paths matching the pattern.
+func globHash(fileSystem utility.FileSystem, pattern string) string {
+	matches, err := fileSystem.Glob(pattern)
+	if err != nil {
+		return ""
+	}
+	sort.Strings(matches)
+
+	hash := sha256.New()
+	for _, match := range matches {
+		fmt.Fprintf(hash, "%s\n", match)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
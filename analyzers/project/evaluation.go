@@ -0,0 +1,470 @@
+package project
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// Property is a property definition of a property group.
+type Property struct {
+	Name      string
+	Value     string
+	Condition string
+}
+
+// GetProperties gets the property definitions of the given property group, in document order.
+func GetProperties(propertyGroup PropertyGroup) ([]Property, error) {
+	var properties []Property
+
+	decoder := xml.NewDecoder(strings.NewReader(propertyGroup.InnerXML))
+	depth := 0
+	var current *Property
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse property group: %s", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				current = &Property{Name: element.Name.Local}
+				for _, attr := range element.Attr {
+					if attr.Name.Local == "Condition" {
+						current.Condition = attr.Value
+					}
+				}
+			}
+		case xml.CharData:
+			if depth == 1 && current != nil {
+				current.Value += string(element)
+			}
+		case xml.EndElement:
+			if depth == 1 && current != nil {
+				current.Value = strings.TrimSpace(current.Value)
+				properties = append(properties, *current)
+				current = nil
+			}
+			depth--
+		}
+	}
+
+	return properties, nil
+}
+
+// Evaluator evaluates the properties of a project file.
+type Evaluator struct {
+	Pth     string
+	Project Project
+
+	fileSystem utility.FileSystem
+	cache      *FileCache // Parses the imported files, nil parses them every time
+}
+
+// Evaluation is the result of a project evaluation.
+type Evaluation struct {
+	Properties     map[string]string
+	Imports        []ImportModel // The imported files in evaluation order
+	ImportPatterns []string      // The wildcard patterns of the imports, the files they match are in Imports
+}
+
+// implicitEnvironmentProperties are the properties read by the analysis without a reference in the project files,
+// they are defined from the environment variables up front.
+var implicitEnvironmentProperties = []string{
+	"ImportDirectoryBuildProps", "DirectoryBuildPropsPath",
+	"ImportDirectoryBuildTargets", "DirectoryBuildTargetsPath",
+	"ManagePackageVersionsCentrally", "DirectoryPackagesPropsPath",
+}
+
+// propertyReferenceRegexp matches the property references ($(Name)) and the environment variable lookups
+// ($([System.Environment]::GetEnvironmentVariable('Name'))) of the project files.
+var propertyReferenceRegexp = regexp.MustCompile(`\$\(\s*([A-Za-z_][A-Za-z0-9_-]*)|GetEnvironmentVariable\(\s*['"]?([A-Za-z_][A-Za-z0-9_-]*)`)
+
+// NewEvaluator parses the project at the given path and creates an Evaluator for it.
+func NewEvaluator(pth string) (Evaluator, error) {
+	return newEvaluator(pth, utility.OSFileSystem, nil)
+}
+
+func newEvaluator(pth string, fileSystem utility.FileSystem, cache *FileCache) (Evaluator, error) {
+	absPth, err := fileSystem.AbsPath(pth)
+	if err != nil {
+		return Evaluator{}, fmt.Errorf("failed to expand path (%s), error: %s", pth, err)
+	}
+
+	project, err := cache.parseProject(absPth, fileSystem)
+	if err != nil {
+		return Evaluator{}, err
+	}
+
+	return Evaluator{Pth: absPth, Project: project, fileSystem: fileSystem, cache: cache}, nil
+}
+
+// Evaluate resolves the project's properties for the given Configuration|Platform.
+func (evaluator Evaluator) Evaluate(configuration, platform string, globalProperties map[string]string) (map[string]string, error) {
+	evaluation, err := evaluator.EvaluateProject(configuration, platform, globalProperties)
+	if err != nil {
+		return nil, err
+	}
+	return evaluation.Properties, nil
+}
+
+// EvaluateProject resolves the project's properties and imports for the given Configuration|Platform.
+// Property groups, properties and imports are applied in document order, their conditions are evaluated with the properties defined so far.
+// Directory.Build.props is imported before, Directory.Build.targets after the project's content, like MSBuild does.
+// Reserved properties (like MSBuildProjectDirectory) and the given global properties are defined up front,
+// global properties (including Configuration and Platform) can not be overridden by the project.
+// The environment variables are defined as properties when a file referencing them is evaluated,
+// so the evaluation depends only on the referenced environment variables.
+func (evaluator Evaluator) EvaluateProject(configuration, platform string, globalProperties map[string]string) (Evaluation, error) {
+	state := evaluationState{
+		properties: map[string]string{},
+		global:     map[string]string{},
+		visited:    map[string]bool{evaluator.Pth: true},
+		fileSystem: evaluator.fileSystem,
+		cache:      evaluator.cache,
+	}
+
+	for key, value := range reservedProperties(evaluator.Pth) {
+		setProperty(state.properties, key, value)
+	}
+
+	for key, value := range globalProperties {
+		state.global[key] = value
+	}
+	if configuration != "" {
+		state.global["Configuration"] = configuration
+	}
+	if platform != "" {
+		state.global["Platform"] = platform
+	}
+	for key, value := range state.global {
+		setProperty(state.properties, key, value)
+	}
+	state.defineEnvironment(implicitEnvironmentProperties...)
+
+	if err := state.importImplicit(evaluator.Pth, "Directory.Build.props", "ImportDirectoryBuildProps", "DirectoryBuildPropsPath"); err != nil {
+		return Evaluation{}, err
+	}
+	if err := state.evaluateFile(evaluator.Pth, evaluator.Project); err != nil {
+		return Evaluation{}, err
+	}
+	if err := state.importImplicit(evaluator.Pth, "Directory.Build.targets", "ImportDirectoryBuildTargets", "DirectoryBuildTargetsPath"); err != nil {
+		return Evaluation{}, err
+	}
+
+	return Evaluation{Properties: state.properties, Imports: state.imports, ImportPatterns: state.importPatterns}, nil
+}
+
+type evaluationState struct {
+	properties     map[string]string
+	global         map[string]string
+	imports        []ImportModel
+	importPatterns []string
+	visited        map[string]bool
+	fileSystem     utility.FileSystem
+	cache          *FileCache
+}
+
+// defineEnvironment defines the given properties from the environment variables, unless they are already defined,
+// like MSBuild defines the environment variables before the evaluation.
+func (state *evaluationState) defineEnvironment(names ...string) {
+	for _, name := range names {
+		if _, ok := lookupPropertyOK(state.properties, name); ok {
+			continue
+		}
+		if value := environmentVariable(name); value != "" {
+			setProperty(state.properties, name, value)
+		}
+	}
+}
+
+// environmentVariable returns the value of the given environment variable, the name is case insensitive like the property names.
+func environmentVariable(name string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	for _, env := range os.Environ() {
+		if idx := strings.Index(env, "="); idx > 0 && strings.EqualFold(env[:idx], name) {
+			return env[idx+1:]
+		}
+	}
+	return ""
+}
+
+// referencedProperties returns the names of the properties and environment variables referenced by the given project file's elements.
+func referencedProperties(project Project) []string {
+	names := []string{}
+	for _, element := range project.Elements {
+		names = append(names, referencedNames(element.Condition+element.Project+element.InnerXML)...)
+	}
+	return names
+}
+
+// referencedNames returns the names of the properties and environment variables referenced by the given text.
+func referencedNames(text string) []string {
+	names := []string{}
+	for _, matches := range propertyReferenceRegexp.FindAllStringSubmatch(text, -1) {
+		name := matches[1]
+		if name == "" {
+			name = matches[2]
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// evaluateFile applies the property groups and imports of the given project file,
+// the MSBuildThisFile properties point to the given file while its content is evaluated.
+func (state *evaluationState) evaluateFile(pth string, project Project) error {
+	outerThisFileProperties := map[string]string{}
+	for key, value := range thisFileProperties(pth) {
+		outerThisFileProperties[key] = lookupProperty(state.properties, key)
+		setProperty(state.properties, key, value)
+	}
+	defer func() {
+		for key, value := range outerThisFileProperties {
+			setProperty(state.properties, key, value)
+		}
+	}()
+
+	state.defineEnvironment(referencedProperties(project)...)
+
+	for _, element := range project.Elements {
+		switch element.XMLName.Local {
+		case "PropertyGroup":
+			if err := state.evaluatePropertyGroup(PropertyGroup{Condition: element.Condition, InnerXML: element.InnerXML}); err != nil {
+				return err
+			}
+		case "Import":
+			if err := state.evaluateImport(pth, element); err != nil {
+				return err
+			}
+		case "ImportGroup":
+			if !state.conditionApplies(element.Condition) {
+				continue
+			}
+
+			imports, err := GetImportGroupImports(element)
+			if err != nil {
+				return err
+			}
+			for _, importElement := range imports {
+				if err := state.evaluateImport(pth, importElement); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (state *evaluationState) evaluatePropertyGroup(propertyGroup PropertyGroup) error {
+	if !state.conditionApplies(propertyGroup.Condition) {
+		return nil
+	}
+
+	groupProperties, err := GetProperties(propertyGroup)
+	if err != nil {
+		return err
+	}
+
+	for _, property := range groupProperties {
+		// global properties can not be overridden
+		if _, ok := lookupPropertyOK(state.global, property.Name); ok {
+			continue
+		}
+
+		if !state.conditionApplies(property.Condition) {
+			continue
+		}
+
+		setProperty(state.properties, property.Name, expandProperties(property.Value, state.properties, state.fileSystem))
+	}
+	return nil
+}
+
+func (state *evaluationState) evaluateImport(importingPth string, element ProjectElement) error {
+	// SDK imports (Sdk.props, Sdk.targets) are not part of the repository
+	if element.Sdk != "" {
+		return nil
+	}
+
+	if !state.conditionApplies(element.Condition) {
+		return nil
+	}
+
+	importPth := expandProperties(element.Project, state.properties, state.fileSystem)
+	// the files matching the wildcard imports might change, not just their content
+	if strings.ContainsAny(importPth, "*?") {
+		state.importPatterns = append(state.importPatterns, resolvePropertyFunctionPath(filepath.Dir(importingPth), strings.TrimSpace(importPth)))
+	}
+
+	for _, pth := range resolveImportPaths(importPth, filepath.Dir(importingPth), state.fileSystem) {
+		if err := state.importFile(importingPth, pth, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importImplicit imports the first file with the given name found in the project's directory or above,
+// unless the import is disabled by the given property, the path can be overridden by the given path property.
+func (state *evaluationState) importImplicit(projectPth, fileName, importProperty, pathProperty string) error {
+	if strings.EqualFold(lookupProperty(state.properties, importProperty), "false") {
+		return nil
+	}
+
+	pth := lookupProperty(state.properties, pathProperty)
+	if pth == "" {
+		dir, err := findFileAbove(filepath.Dir(projectPth), fileName, state.fileSystem)
+		if err != nil {
+			return err
+		}
+		if dir == "" {
+			return nil
+		}
+		pth = filepath.Join(dir, fileName)
+	} else {
+		pth = resolvePropertyFunctionPath(filepath.Dir(projectPth), pth)
+		if exist, err := state.fileSystem.IsPathExists(pth); err != nil {
+			return err
+		} else if !exist {
+			return nil
+		}
+	}
+
+	return state.importFile(projectPth, pth, true)
+}
+
+func (state *evaluationState) importFile(importingPth, pth string, implicit bool) error {
+	// MSBuild skips the already imported files with a warning
+	if state.visited[pth] {
+		debugLog(fmt.Errorf("skipping duplicate import (%s)", pth), importingPth)
+		return nil
+	}
+	state.visited[pth] = true
+
+	project, err := state.cache.parseProject(pth, state.fileSystem)
+	if err != nil {
+		return err
+	}
+
+	state.imports = append(state.imports, ImportModel{Pth: pth, ImportedBy: importingPth, Implicit: implicit})
+	return state.evaluateFile(pth, project)
+}
+
+func (state *evaluationState) conditionApplies(condition string) bool {
+	return conditionApplies(condition, state.properties, state.fileSystem)
+}
+
+// ResolveImportPaths resolves the (already expanded) Project attribute of an Import element:
+// relative paths are relative to the importing file's directory, wildcards are expanded
+// and the not existing files are skipped.
+func ResolveImportPaths(importPth, importingDir string) []string {
+	return resolveImportPaths(importPth, importingDir, utility.OSFileSystem)
+}
+
+func resolveImportPaths(importPth, importingDir string, fileSystem utility.FileSystem) []string {
+	importPth = strings.TrimSpace(importPth)
+	if importPth == "" {
+		return nil
+	}
+
+	pth := resolvePropertyFunctionPath(importingDir, importPth)
+
+	if strings.ContainsAny(pth, "*?") {
+		matches, err := fileSystem.Glob(pth)
+		if err != nil {
+			debugParseLog(err)
+			return nil
+		}
+		sort.Strings(matches)
+		return matches
+	}
+
+	pth, warning := fileSystem.ResolvePath(pth)
+	if exist, err := fileSystem.IsPathExists(pth); err != nil || !exist {
+		return nil
+	}
+	if warning != "" {
+		log.Warnf("Import (%s): %s", importPth, warning)
+	}
+	return []string{pth}
+}
+
+// reservedProperties returns the MSBuild reserved properties of the given project file.
+func reservedProperties(projectPth string) map[string]string {
+	projectDir := filepath.Dir(projectPth)
+	fileName := filepath.Base(projectPth)
+	ext := filepath.Ext(projectPth)
+
+	properties := map[string]string{
+		"MSBuildProjectFullPath":        projectPth,
+		"MSBuildProjectDirectory":       projectDir,
+		"MSBuildProjectDirectoryNoRoot": strings.TrimPrefix(projectDir, string(filepath.Separator)),
+		"MSBuildProjectFile":            fileName,
+		"MSBuildProjectExtension":       ext,
+		"MSBuildProjectName":            strings.TrimSuffix(fileName, ext),
+		"OS":                            "Unix",
+	}
+	for key, value := range thisFileProperties(projectPth) {
+		properties[key] = value
+	}
+	if runtime.GOOS == "windows" {
+		properties["OS"] = "Windows_NT"
+	}
+	if wd, err := os.Getwd(); err == nil {
+		properties["MSBuildStartupDirectory"] = wd
+	}
+	return properties
+}
+
+// thisFileProperties returns the MSBuildThisFile reserved properties of the given (project or imported) file.
+func thisFileProperties(pth string) map[string]string {
+	dir := filepath.Dir(pth)
+	fileName := filepath.Base(pth)
+	ext := filepath.Ext(pth)
+
+	return map[string]string{
+		"MSBuildThisFileFullPath":        pth,
+		"MSBuildThisFileDirectory":       ensureTrailingSlash(dir),
+		"MSBuildThisFileDirectoryNoRoot": ensureTrailingSlash(strings.TrimPrefix(dir, string(filepath.Separator))),
+		"MSBuildThisFile":                fileName,
+		"MSBuildThisFileExtension":       ext,
+		"MSBuildThisFileName":            strings.TrimSuffix(fileName, ext),
+	}
+}
+
+// setProperty sets the given property, keeping the casing of an already defined property name.
+func setProperty(properties map[string]string, name, value string) {
+	if _, ok := properties[name]; !ok {
+		for key := range properties {
+			if strings.EqualFold(key, name) {
+				name = key
+				break
+			}
+		}
+	}
+	properties[name] = value
+}
+
+func ensureTrailingSlash(pth string) string {
+	if pth == "" {
+		return pth
+	}
+	return strings.TrimSuffix(pth, "/") + "/"
+}
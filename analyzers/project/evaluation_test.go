@@ -0,0 +1,247 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/utility"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProperties(t *testing.T) {
+	t.Log("it returns the properties in document order")
+	{
+		project, err := ParseProjectContent(propertyEvaluationTestProjectContent)
+		require.NoError(t, err)
+
+		properties, err := GetProperties(project.PropertyGroups[0])
+		require.NoError(t, err)
+		require.Equal(t, 10, len(properties))
+		require.Equal(t, Property{Name: "Configuration", Value: "Debug", Condition: " '$(Configuration)' == '' "}, properties[0])
+		require.Equal(t, Property{Name: "AssemblyName", Value: "$(AppName).Droid"}, properties[6])
+		require.Equal(t, "BaseOutputDir", properties[9].Name)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__evaluation-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	pth := tmpProjectWithContentInDir(t, propertyEvaluationTestProjectContent, tmpDir)
+
+	evaluator, err := NewEvaluator(pth)
+	require.NoError(t, err)
+
+	t.Log("it evaluates the properties in document order")
+	{
+		properties, err := evaluator.Evaluate("", "", nil)
+		require.NoError(t, err)
+		require.Equal(t, "Debug", properties["Configuration"])
+		require.Equal(t, "AnyCPU", properties["Platform"])
+		require.Equal(t, "CreditCardValidator.Droid", properties["AssemblyName"])
+		require.Equal(t, "bin", properties["BaseOutputDir"])
+		require.Equal(t, `bin\debug`, properties["OutputPath"])
+	}
+
+	t.Log("it defines the reserved properties")
+	{
+		properties, err := evaluator.Evaluate("Debug", "AnyCPU", nil)
+		require.NoError(t, err)
+		require.Equal(t, tmpDir, properties["MSBuildProjectDirectory"])
+		require.Equal(t, "project", properties["MSBuildProjectName"])
+		require.Equal(t, ".csproj", properties["MSBuildProjectExtension"])
+		require.Equal(t, tmpDir+"/", properties["MSBuildThisFileDirectory"])
+	}
+
+	t.Log("global properties can not be overridden")
+	{
+		properties, err := evaluator.Evaluate("Release", "AnyCPU", map[string]string{"AppName": "Global", "SolutionDir": "/solution/"})
+		require.NoError(t, err)
+		require.Equal(t, "Release", properties["Configuration"])
+		require.Equal(t, "Global", properties["AppName"])
+		require.Equal(t, "Global.Droid", properties["AssemblyName"])
+		require.Equal(t, `/solution/artifacts\project\Release`, properties["OutputPath"])
+		require.Equal(t, formatBool(runtime.GOOS == "darwin"), properties["IsMac"])
+	}
+
+	t.Log("it defines the referenced environment variables")
+	{
+		require.NoError(t, os.Setenv("SolutionDir", "/env/"))
+		require.NoError(t, os.Setenv("XAMARIN_EVALUATION_TEST_UNREFERENCED", "1"))
+		defer func() {
+			require.NoError(t, os.Unsetenv("SolutionDir"))
+			require.NoError(t, os.Unsetenv("XAMARIN_EVALUATION_TEST_UNREFERENCED"))
+		}()
+
+		properties, err := evaluator.Evaluate("Release", "AnyCPU", nil)
+		require.NoError(t, err)
+		require.Equal(t, `/env/artifacts\project\Release`, properties["OutputPath"])
+		_, ok := properties["XAMARIN_EVALUATION_TEST_UNREFERENCED"]
+		require.False(t, ok)
+	}
+}
+
+func TestExpandProperties(t *testing.T) {
+	properties := map[string]string{
+		"Configuration":           "Release",
+		"TargetFramework":         "net8.0-ios17.0",
+		"Name":                    "  My.App  ",
+		"MSBuildProjectDirectory": "/project",
+	}
+
+	t.Log("it expands property references")
+	{
+		for value, expected := range map[string]string{
+			"":                              "",
+			"bin":                           "bin",
+			`bin\$(Configuration)`:          `bin\Release`,
+			"$(configuration)":              "Release",
+			"$(Undefined)":                  "",
+			"$( Configuration )":            "Release",
+			"@(Compile)":                    "",
+			"%(Identity)":                   "",
+			"$(Configuration":               "$(Configuration",
+			"$(Configuration)-$(Undefined)": "Release-",
+		} {
+			require.Equal(t, expected, ExpandProperties(value, properties), value)
+		}
+	}
+
+	t.Log("it expands property functions")
+	{
+		for value, expected := range map[string]string{
+			"$(Configuration.ToLower())":                                                "release",
+			"$(Name.Trim())":                                                            "My.App",
+			"$(Name.Trim().Replace('.', '_'))":                                          "My_App",
+			"$(Configuration.StartsWith('Rel'))":                                        "True",
+			"$(Configuration.Substring(0, 3))":                                          "Rel",
+			"$(Configuration.Length)":                                                   "7",
+			"$([MSBuild]::GetTargetPlatformIdentifier('$(TargetFramework)'))":           "ios",
+			"$([MSBuild]::GetTargetPlatformVersion($(TargetFramework)))":                "17.0",
+			"$([MSBuild]::GetTargetFrameworkIdentifier('$(TargetFramework)'))":          ".NETCoreApp",
+			"$([MSBuild]::ValueOrDefault('$(Undefined)', 'default'))":                   "default",
+			"$([MSBuild]::Add(1, 2))":                                                   "3",
+			"$([MSBuild]::VersionGreaterThanOrEquals('17.0', '16.4'))":                  "True",
+			"$([MSBuild]::EnsureTrailingSlash('bin'))":                                  "bin/",
+			"$([MSBuild]::NormalizeDirectory('$(MSBuildProjectDirectory)', 'bin'))":     "/project/bin/",
+			"$([System.IO.Path]::Combine('$(MSBuildProjectDirectory)', 'obj'))":         "/project/obj",
+			"$([System.IO.Path]::GetFileNameWithoutExtension('App.csproj'))":            "App",
+			"$([System.String]::IsNullOrEmpty('$(Undefined)'))":                         "True",
+			"$([MSBuild]::IsOSPlatform('windows'))":                                     formatBool(runtime.GOOS == "windows"),
+			"$([MSBuild]::Unsupported())":                                               "",
+			"$(TargetFramework.Split('-'))":                                             "",
+			"bin/$([System.IO.Path]::GetFileName('$(MSBuildProjectDirectory)'))/output": "bin/project/output",
+		} {
+			require.Equal(t, expected, ExpandProperties(value, properties), value)
+		}
+	}
+
+	t.Log("it finds files above the project directory")
+	{
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("__evaluation-test__")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, os.RemoveAll(tmpDir))
+		}()
+		projectDir := filepath.Join(tmpDir, "src", "App")
+		require.NoError(t, os.MkdirAll(projectDir, 0755))
+		tmpProjectWithContentInDir(t, "<Project />", tmpDir)
+
+		properties := map[string]string{"MSBuildProjectDirectory": projectDir}
+		require.Equal(t, tmpDir, ExpandProperties("$([MSBuild]::GetDirectoryNameOfFileAbove($(MSBuildProjectDirectory), project.csproj))", properties))
+		require.Equal(t, filepath.Join(tmpDir, "project.csproj"), ExpandProperties("$([MSBuild]::GetPathOfFileAbove('project.csproj'))", properties))
+		require.Equal(t, "", ExpandProperties("$([MSBuild]::GetPathOfFileAbove('missing.props'))", properties))
+	}
+}
+
+func TestAnalyzeProjectWithPropertyEvaluation(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__evaluation-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	pth := tmpProjectWithContentInDir(t, propertyEvaluationTestProjectContent, tmpDir)
+
+	t.Log("it resolves the output dirs and the assembly name")
+	{
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+		require.Equal(t, "CreditCardValidator.Droid", project.AssemblyName)
+		require.Equal(t, filepath.Join(tmpDir, "bin", "debug"), project.Configs["Debug|AnyCPU"].OutputDir)
+		require.Equal(t, filepath.Join(tmpDir, "bin", "Release"), project.Configs["Release|AnyCPU"].OutputDir)
+		require.Equal(t, "Release", project.Configs["Release|AnyCPU"].Properties["Configuration"])
+	}
+
+	t.Log("it uses the given global properties")
+	{
+		project, err := NewWithProperties(pth, map[string]string{"SolutionDir": "/solution/"})
+		require.NoError(t, err)
+		require.Equal(t, "/solution/artifacts/project/debug", project.Configs["Debug|AnyCPU"].OutputDir)
+	}
+}
+
+func tmpImportingProject(t *testing.T) (string, string) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__evaluation-test__")
+	require.NoError(t, err)
+
+	for pth, content := range map[string]string{
+		"Directory.Build.props":   directoryBuildPropsTestContent,
+		"Directory.Build.targets": directoryBuildTargetsTestContent,
+		"build/signing.props":     signingPropsTestContent,
+		"src/Droid/release.props": releasePropsTestContent,
+		"src/Droid/Droid.csproj":  importingAndroidTestProjectContent,
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(tmpDir, pth)), 0755))
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, pth), content))
+	}
+	return tmpDir, filepath.Join(tmpDir, "src", "Droid", "Droid.csproj")
+}
+
+func TestEvaluateImports(t *testing.T) {
+	tmpDir, pth := tmpImportingProject(t)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	evaluator, err := NewEvaluator(pth)
+	require.NoError(t, err)
+
+	t.Log("it imports Directory.Build.props and Directory.Build.targets")
+	{
+		evaluation, err := evaluator.EvaluateProject("Debug", "AnyCPU", nil)
+		require.NoError(t, err)
+		require.Equal(t, tmpDir+`/artifacts\Droid\Debug`, evaluation.Properties["OutputPath"])
+		require.Equal(t, "aab", evaluation.Properties["AndroidPackageFormat"])
+		require.Equal(t, "true", evaluation.Properties["ImportedTargets"])
+		require.Equal(t, "", evaluation.Properties["AndroidKeyStore"])
+		require.Equal(t, []ImportModel{
+			{Pth: filepath.Join(tmpDir, "Directory.Build.props"), ImportedBy: pth, Implicit: true},
+			{Pth: filepath.Join(tmpDir, "build", "signing.props"), ImportedBy: filepath.Join(tmpDir, "Directory.Build.props")},
+			{Pth: filepath.Join(tmpDir, "Directory.Build.targets"), ImportedBy: pth, Implicit: true},
+		}, evaluation.Imports)
+	}
+
+	t.Log("it honours the import conditions")
+	{
+		evaluation, err := evaluator.EvaluateProject("Release", "AnyCPU", nil)
+		require.NoError(t, err)
+		require.Equal(t, "apk", evaluation.Properties["AndroidPackageFormat"])
+		require.Equal(t, "True", evaluation.Properties["AndroidKeyStore"])
+		require.Equal(t, 4, len(evaluation.Imports))
+		require.Equal(t, filepath.Join(tmpDir, "src", "Droid", "release.props"), evaluation.Imports[2].Pth)
+	}
+
+	t.Log("the implicit imports can be disabled")
+	{
+		evaluation, err := evaluator.EvaluateProject("Debug", "AnyCPU", map[string]string{"ImportDirectoryBuildProps": "false", "ImportDirectoryBuildTargets": "false"})
+		require.NoError(t, err)
+		require.Equal(t, `\Debug`, evaluation.Properties["OutputPath"])
+		require.Equal(t, 0, len(evaluation.Imports))
+	}
+}
@@ -0,0 +1,315 @@
+package project
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// PackageSource ...
+type PackageSource string
+
+const (
+	// PackageSourcePackageReference ...
+	PackageSourcePackageReference PackageSource = "PackageReference"
+	// PackageSourcePackagesConfig ...
+	PackageSourcePackagesConfig PackageSource = "packages.config"
+)
+
+// PackageDependency ...
+type PackageDependency struct {
+	ID      string
+	Version string
+	Source  PackageSource
+
+	CentrallyManaged bool // The version is defined by Directory.Packages.props
+	Private          bool // PrivateAssets="all" or developmentDependency="true", the package does not flow to the dependent projects
+}
+
+// PackagesConfig the packages.config file.
+type PackagesConfig struct {
+	XMLName  xml.Name `xml:"packages"`
+	Packages []struct {
+		ID                    string `xml:"id,attr"`
+		Version               string `xml:"version,attr"`
+		TargetFramework       string `xml:"targetFramework,attr"`
+		DevelopmentDependency string `xml:"developmentDependency,attr"`
+	} `xml:"package"`
+}
+
+// ParsePackagesConfigContent parses the given string content to PackagesConfig struct.
+func ParsePackagesConfigContent(content string) (PackagesConfig, error) {
+	var packagesConfig PackagesConfig
+	if err := xml.Unmarshal([]byte(content), &packagesConfig); err != nil {
+		return PackagesConfig{}, fmt.Errorf("failed to unmarshall packages.config content. Error: %v", err)
+	}
+	return packagesConfig, nil
+}
+
+// ParsePackagesConfig parses the packages.config on the given path.
+func ParsePackagesConfig(pth string) (PackagesConfig, error) {
+	return parsePackagesConfig(pth, utility.OSFileSystem)
+}
+
+func parsePackagesConfig(pth string, fileSystem utility.FileSystem) (PackagesConfig, error) {
+	content, err := fileSystem.ReadStringFromFile(pth)
+	if err != nil {
+		return PackagesConfig{}, fmt.Errorf("failed to read packages.config at (%s), error: %s", pth, err)
+	}
+	return ParsePackagesConfigContent(content)
+}
+
+// GetPackagesConfigPath gets the path of the project's packages.config,
+// the project specific packages.<ProjectName>.config takes precedence, like NuGet does.
+func GetPackagesConfigPath(projectPth string) (string, error) {
+	return getPackagesConfigPath(projectPth, utility.OSFileSystem)
+}
+
+func getPackagesConfigPath(projectPth string, fileSystem utility.FileSystem) (string, error) {
+	projectDir := filepath.Dir(projectPth)
+	projectName := strings.TrimSuffix(filepath.Base(projectPth), filepath.Ext(projectPth))
+
+	for _, fileName := range []string{"packages." + projectName + ".config", "packages.config"} {
+		pth := filepath.Join(projectDir, fileName)
+		if exist, err := fileSystem.IsPathExists(pth); err != nil {
+			return "", err
+		} else if exist {
+			return pth, nil
+		}
+	}
+	return "", nil
+}
+
+// GetPackagesConfigDependencies gets the package dependencies from the given packages.config.
+func GetPackagesConfigDependencies(packagesConfig PackagesConfig) []PackageDependency {
+	var packages []PackageDependency
+	for _, pkg := range packagesConfig.Packages {
+		if pkg.ID == "" {
+			continue
+		}
+		packages = append(packages, PackageDependency{
+			ID:      pkg.ID,
+			Version: pkg.Version,
+			Source:  PackageSourcePackagesConfig,
+			Private: boolParse(pkg.DevelopmentDependency),
+		})
+	}
+	return packages
+}
+
+// GetPackageReferences gets the package dependencies of the PackageReference items of the given projects (the project and its imports).
+// Item and item group conditions are evaluated with the given properties, Update items modify the previously included packages.
+// centralVersions (lower case package ID - version map) provides the version of the references without version.
+func GetPackageReferences(projects []Project, properties map[string]string, centralVersions map[string]string) []PackageDependency {
+	return getPackageReferences(projects, properties, centralVersions, utility.OSFileSystem)
+}
+
+func getPackageReferences(projects []Project, properties map[string]string, centralVersions map[string]string, fileSystem utility.FileSystem) []PackageDependency {
+	var packages []PackageDependency
+	indexByID := map[string]int{}
+
+	var updates []PackageReference
+	for _, project := range projects {
+		for _, itemGroup := range project.ItemGroups {
+			if !conditionApplies(itemGroup.Condition, properties, fileSystem) {
+				continue
+			}
+
+			for _, reference := range itemGroup.PackageReferences {
+				if !conditionApplies(reference.Condition, properties, fileSystem) {
+					continue
+				}
+
+				if reference.Include == "" {
+					if reference.Update != "" {
+						updates = append(updates, reference)
+					}
+					continue
+				}
+
+				for _, id := range strings.Split(expandProperties(reference.Include, properties, fileSystem), ";") {
+					id = strings.TrimSpace(id)
+					if id == "" {
+						continue
+					}
+
+					pkg := PackageDependency{ID: id, Source: PackageSourcePackageReference}
+					applyPackageReference(&pkg, reference, properties, centralVersions, fileSystem)
+
+					if idx, ok := indexByID[strings.ToLower(id)]; ok {
+						packages[idx] = pkg
+					} else {
+						indexByID[strings.ToLower(id)] = len(packages)
+						packages = append(packages, pkg)
+					}
+				}
+			}
+		}
+	}
+
+	for _, reference := range updates {
+		for _, id := range strings.Split(expandProperties(reference.Update, properties, fileSystem), ";") {
+			if idx, ok := indexByID[strings.ToLower(strings.TrimSpace(id))]; ok {
+				applyPackageReference(&packages[idx], reference, properties, nil, fileSystem)
+			}
+		}
+	}
+
+	return packages
+}
+
+func applyPackageReference(pkg *PackageDependency, reference PackageReference, properties map[string]string, centralVersions map[string]string, fileSystem utility.FileSystem) {
+	if version := firstNonEmpty(reference.VersionOverrideAttr, reference.VersionOverride, reference.VersionAttr, reference.Version); version != "" {
+		pkg.Version = expandProperties(version, properties, fileSystem)
+		pkg.CentrallyManaged = false
+	} else if version, ok := centralVersions[strings.ToLower(pkg.ID)]; ok {
+		pkg.Version = version
+		pkg.CentrallyManaged = true
+	}
+
+	if privateAssets := firstNonEmpty(reference.PrivateAssetsAttr, reference.PrivateAssets); privateAssets != "" {
+		pkg.Private = strings.EqualFold(strings.TrimSpace(privateAssets), "all")
+	}
+}
+
+// GetCentralPackageVersions gets the package versions (lower case package ID - version map) from the
+// Directory.Packages.props found in the project's directory or above, if central package management is enabled.
+func GetCentralPackageVersions(projectPth string, properties map[string]string) (map[string]string, error) {
+	return getCentralPackageVersions(projectPth, properties, utility.OSFileSystem, nil)
+}
+
+func getCentralPackageVersions(projectPth string, properties map[string]string, fileSystem utility.FileSystem, cache *FileCache) (map[string]string, error) {
+	if strings.EqualFold(lookupProperty(properties, "ManagePackageVersionsCentrally"), "false") {
+		return nil, nil
+	}
+
+	pth := lookupProperty(properties, "DirectoryPackagesPropsPath")
+	if pth == "" {
+		dir, err := findFileAbove(filepath.Dir(projectPth), "Directory.Packages.props", fileSystem)
+		if err != nil || dir == "" {
+			return nil, err
+		}
+		pth = filepath.Join(dir, "Directory.Packages.props")
+	} else {
+		pth = resolvePropertyFunctionPath(filepath.Dir(projectPth), pth)
+	}
+
+	project, err := cache.parseProject(pth, fileSystem)
+	if err != nil {
+		return nil, err
+	}
+
+	// Directory.Packages.props usually enables central package management for itself
+	state := evaluationState{
+		properties: map[string]string{},
+		global:     map[string]string{},
+		visited:    map[string]bool{pth: true},
+		fileSystem: fileSystem,
+		cache:      cache,
+	}
+	for key, value := range properties {
+		state.properties[key] = value
+	}
+	if err := state.evaluateFile(pth, project); err != nil {
+		return nil, err
+	}
+
+	if !boolParse(lookupProperty(state.properties, "ManagePackageVersionsCentrally")) {
+		return nil, nil
+	}
+
+	versions := map[string]string{}
+	for _, itemGroup := range project.ItemGroups {
+		if !state.conditionApplies(itemGroup.Condition) {
+			continue
+		}
+
+		for _, packageVersion := range itemGroup.PackageVersions {
+			if !state.conditionApplies(packageVersion.Condition) {
+				continue
+			}
+
+			id := firstNonEmpty(packageVersion.Include, packageVersion.Update)
+			if id == "" {
+				continue
+			}
+			versions[strings.ToLower(id)] = expandProperties(firstNonEmpty(packageVersion.VersionAttr, packageVersion.Version), state.properties, fileSystem)
+		}
+	}
+	return versions, nil
+}
+
+// resolvePackages merges the project's PackageReference items (including the ones defined by its imports)
+// and its packages.config entries, PackageReference items take precedence.
+func resolvePackages(projectModel Model, properties map[string]string, fileSystem utility.FileSystem, cache *FileCache) ([]PackageDependency, error) {
+	projects := []Project{}
+	for _, imported := range projectModel.Imports {
+		project, err := cache.parseProject(imported.Pth, fileSystem)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+
+	project, err := cache.parseProject(projectModel.Pth, fileSystem)
+	if err != nil {
+		return nil, err
+	}
+	projects = append(projects, project)
+
+	centralVersions, err := getCentralPackageVersions(projectModel.Pth, properties, fileSystem, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	packages := getPackageReferences(projects, properties, centralVersions, fileSystem)
+
+	packagesConfigPth, err := getPackagesConfigPath(projectModel.Pth, fileSystem)
+	if err != nil {
+		return nil, err
+	}
+	if packagesConfigPth != "" {
+		packagesConfig, err := parsePackagesConfig(packagesConfigPth, fileSystem)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pkg := range GetPackagesConfigDependencies(packagesConfig) {
+			if !packagesContain(packages, pkg.ID) {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+
+	return packages, nil
+}
+
+func packagesContain(packages []PackageDependency, id string) bool {
+	for _, pkg := range packages {
+		if strings.EqualFold(pkg.ID, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func conditionApplies(condition string, properties map[string]string, fileSystem utility.FileSystem) bool {
+	applies, err := evaluateCondition(condition, properties, fileSystem)
+	if err != nil {
+		debugParseLog(err)
+		return false
+	}
+	return applies
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
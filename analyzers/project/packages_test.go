@@ -0,0 +1,98 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/utility"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPackagesConfigDependencies(t *testing.T) {
+	t.Log("it parses packages.config")
+	{
+		packagesConfig, err := ParsePackagesConfigContent(packagesConfigTestContent)
+		require.NoError(t, err)
+		require.Equal(t, []PackageDependency{
+			{ID: "Newtonsoft.Json", Version: "12.0.1", Source: PackageSourcePackagesConfig},
+			{ID: "Xamarin.Forms", Version: "5.0.0.2612", Source: PackageSourcePackagesConfig},
+			{ID: "NuGet.Build.Tasks.Pack", Version: "6.0.0", Source: PackageSourcePackagesConfig, Private: true},
+		}, GetPackagesConfigDependencies(packagesConfig))
+	}
+}
+
+func TestGetPackageReferences(t *testing.T) {
+	project, err := ParseProjectContent(packageReferenceTestProjectContent)
+	require.NoError(t, err)
+
+	t.Log("it reads the version from attributes, child elements and updates")
+	{
+		packages := GetPackageReferences([]Project{project}, map[string]string{"Configuration": "Release"}, nil)
+		require.Equal(t, []PackageDependency{
+			{ID: "Newtonsoft.Json", Source: PackageSourcePackageReference},
+			{ID: "xamarin.essentials", Source: PackageSourcePackageReference},
+			{ID: "NUnit", Version: "3.14.0", Source: PackageSourcePackageReference},
+			{ID: "Serilog", Version: "3.1.1", Source: PackageSourcePackageReference},
+		}, packages)
+	}
+
+	t.Log("it evaluates the item group conditions and uses the central package versions")
+	{
+		centralVersions := map[string]string{"newtonsoft.json": "13.0.3", "xamarin.essentials": "1.8.0", "serilog": "3.0.0"}
+		packages := GetPackageReferences([]Project{project}, map[string]string{"Configuration": "Debug"}, centralVersions)
+		require.Equal(t, 5, len(packages))
+		require.Equal(t, PackageDependency{ID: "Newtonsoft.Json", Version: "13.0.3", Source: PackageSourcePackageReference, CentrallyManaged: true}, packages[0])
+		require.Equal(t, "1.8.0", packages[1].Version)
+		require.Equal(t, PackageDependency{ID: "Serilog", Version: "3.1.1", Source: PackageSourcePackageReference}, packages[3])
+		require.Equal(t, "Debug.Only", packages[4].ID)
+	}
+}
+
+func TestAnalyzeProjectPackages(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__packages-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	for pth, content := range map[string]string{
+		"Directory.Packages.props":          directoryPackagesPropsTestContent,
+		"Directory.Build.props":             packageReferenceDirectoryBuildPropsTestContent,
+		"src/App/App.csproj":                packageReferenceTestProjectContent,
+		"src/App/packages.config":           packagesConfigTestContent,
+		"src/Legacy/packages.config":        packagesConfigTestContent,
+		"src/Legacy/Legacy.csproj":          androidTestProjectContent,
+		"src/Legacy/packages.Legacy.config": `<packages><package id="Only.Legacy" version="1.0.0" /></packages>`,
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(tmpDir, pth)), 0755))
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, pth), content))
+	}
+
+	t.Log("it merges PackageReference items, central versions and packages.config")
+	{
+		project, err := analyzeProject(filepath.Join(tmpDir, "src", "App", "App.csproj"), nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+		require.Equal(t, []PackageDependency{
+			{ID: "StyleCop.Analyzers", Version: "1.1.118", Source: PackageSourcePackageReference, Private: true},
+			{ID: "Newtonsoft.Json", Version: "13.0.3", Source: PackageSourcePackageReference, CentrallyManaged: true},
+			{ID: "xamarin.essentials", Version: "1.8.0", Source: PackageSourcePackageReference, CentrallyManaged: true},
+			{ID: "NUnit", Version: "3.14.0", Source: PackageSourcePackageReference},
+			{ID: "Serilog", Version: "3.1.1", Source: PackageSourcePackageReference},
+			{ID: "Debug.Only", Version: "1.0.0", Source: PackageSourcePackageReference},
+			{ID: "Xamarin.Forms", Version: "5.0.0.2612", Source: PackageSourcePackagesConfig},
+			{ID: "NuGet.Build.Tasks.Pack", Version: "6.0.0", Source: PackageSourcePackagesConfig, Private: true},
+		}, project.Packages)
+	}
+
+	t.Log("it prefers the project specific packages.config")
+	{
+		project, err := analyzeProject(filepath.Join(tmpDir, "src", "Legacy", "Legacy.csproj"), nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(project.Packages))
+		require.Equal(t, "StyleCop.Analyzers", project.Packages[0].ID)
+		require.Equal(t, PackageDependency{ID: "Only.Legacy", Version: "1.0.0", Source: PackageSourcePackagesConfig}, project.Packages[1])
+	}
+}
@@ -2,11 +2,12 @@ package project
 
 import (
 	"fmt"
+	"io/fs"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/bitrise-io/go-utils/log"
-	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/go-xamarin/constants"
 	"github.com/bitrise-io/go-xamarin/utility"
 )
@@ -20,7 +21,23 @@ type ConfigurationPlatformModel struct {
 	MtouchArchs []string
 	BuildIpa    bool
 
-	SignAndroid bool
+	SignAndroid          bool
+	AndroidPackageFormat string
+
+	// Properties holds the evaluated MSBuild properties of the Configuration|Platform
+	Properties map[string]string
+}
+
+// Property returns the evaluated value of the given property, property names are case insensitive.
+func (config ConfigurationPlatformModel) Property(name string) string {
+	return lookupProperty(config.Properties, name)
+}
+
+// ImportModel ...
+type ImportModel struct {
+	Pth        string
+	ImportedBy string // The importing file's path
+	Implicit   bool   // Directory.Build.props or Directory.Build.targets
 }
 
 // Model ...
@@ -31,6 +48,12 @@ type Model struct {
 	// Solution Configuration|Platform - Project Configuration|Platform map
 	// !!! only set by solution analyze
 	ConfigMap map[string]string
+	// Solution Configuration|Platform - project config mapping with the solution's build and deploy flags
+	// !!! only set by solution analyze
+	ConfigMappings map[string]ConfigMapping
+	// The path of the solution folder containing the project (like Apps/Android), empty for the projects in the solution root
+	// !!! only set by solution analyze
+	SolutionFolder string
 
 	ID            string
 	SDK           constants.SDK
@@ -39,51 +62,203 @@ type Model struct {
 	AssemblyName  string
 
 	ReferredProjectIDs []string
+	// The absolute paths of the projects referenced by the ProjectReference items,
+	// the SDK-style project references are resolved to the solution's projects by path
+	ReferredProjectPaths []string
+	// The IDs of the projects the project explicitly depends on (ProjectSection(ProjectDependencies) in the solution)
+	// !!! only set by solution analyze
+	DependencyProjectIDs []string
 
 	ManifestPth        string
 	AndroidApplication bool
 
+	// SDK-style (.NET 6+, MAUI) project properties
+	Sdk              string // The Project's Sdk attribute, empty for legacy Xamarin projects
+	TargetFrameworks []string
+	ApplicationID    string
+
+	// The files imported by the project (including Directory.Build.props and Directory.Build.targets) in evaluation order,
+	// each import refers to its importing file
+	Imports []ImportModel
+	// The wildcard patterns of the imports, the files matching them might change
+	ImportPatterns []string
+
+	// NuGet dependencies from PackageReference items and packages.config
+	Packages []PackageDependency
+
 	Configs map[string]ConfigurationPlatformModel // Project Configuration|Platform - ConfigurationPlatformModel map
+	// The Configuration|Platforms of each platform target framework of a multi-platform project (like a MAUI app targeting
+	// net8.0-ios and net8.0-android) evaluated with the target framework, see PlatformProjects
+	TargetFrameworkConfigs map[string]map[string]ConfigurationPlatformModel
+}
+
+// ConfigMapping is the mapping of a solution Configuration|Platform to the project's Configuration|Platform.
+type ConfigMapping struct {
+	Config string // The project Configuration|Platform (ActiveCfg)
+	Build  bool   // The project is built in the solution config (Build.0)
+	Deploy bool   // The project is deployed in the solution config (Deploy.0)
 }
 
 // New ...
 func New(pth string) (Model, error) {
-	return analyzeProject(pth)
+	return analyzeProject(pth, nil, utility.OSFileSystem, nil)
+}
+
+// NewFS analyzes the project at the given path of the fs.FS, see NewWithFileSystem.
+func NewFS(fsys fs.FS, pth string, globalProperties map[string]string) (Model, error) {
+	return analyzeProject(pth, globalProperties, utility.NewFSFileSystem(fsys), nil)
+}
+
+// NewWithProperties analyzes the project, the given global properties (like SolutionDir) are used to evaluate the project's properties.
+func NewWithProperties(pth string, globalProperties map[string]string) (Model, error) {
+	return analyzeProject(pth, globalProperties, utility.OSFileSystem, nil)
+}
+
+// NewWithCache analyzes the project like NewWithProperties, the project files are parsed through the given cache,
+// which can be shared between the projects of a solution.
+func NewWithCache(pth string, globalProperties map[string]string, cache *FileCache) (Model, error) {
+	return analyzeProject(pth, globalProperties, utility.OSFileSystem, cache)
+}
+
+// NewWithFileSystem analyzes the project like NewWithCache, reading the project and its imports from the given file system.
+// The paths of a mounted fs.FS are relative to its root or absolute paths under its mount directory,
+// the paths of the returned model (like Pth, OutputDir or ManifestPth) are under the mount directory.
+func NewWithFileSystem(fileSystem utility.FileSystem, pth string, globalProperties map[string]string, cache *FileCache) (Model, error) {
+	return analyzeProject(pth, globalProperties, fileSystem, cache)
+}
+
+// BuildEnabled returns true if the project is mapped to and marked for build in the given solution Configuration|Platform.
+// Projects without build flags (not analyzed as part of a solution) are built in every mapped solution config.
+func (project Model) BuildEnabled(solutionConfig string) bool {
+	if _, ok := project.ConfigMap[solutionConfig]; !ok {
+		return false
+	}
+
+	mapping, ok := project.ConfigMappings[solutionConfig]
+	return !ok || mapping.Build
+}
+
+// IsSDKStyle returns true if the project uses the SDK-style project format.
+func (project Model) IsSDKStyle() bool {
+	return project.Sdk != ""
+}
+
+// TargetFrameworkForSDK returns the project's first target framework for the given SDK,
+// or the first target framework if none of them targets the given SDK.
+func (project Model) TargetFrameworkForSDK(sdk constants.SDK) string {
+	for _, targetFramework := range project.TargetFrameworks {
+		if targetFrameworkSDK, err := constants.ParseTargetFramework(targetFramework); err == nil && targetFrameworkSDK == sdk {
+			return targetFramework
+		}
+	}
+	if len(project.TargetFrameworks) > 0 {
+		return project.TargetFrameworks[0]
+	}
+	return ""
+}
+
+// PlatformTargetFrameworks returns the project's platform specific target frameworks (like net8.0-ios and net8.0-android).
+func (project Model) PlatformTargetFrameworks() []string {
+	targetFrameworks := []string{}
+	for _, targetFramework := range project.TargetFrameworks {
+		if _, err := constants.ParseTargetFramework(targetFramework); err == nil {
+			targetFrameworks = append(targetFrameworks, targetFramework)
+		}
+	}
+	return targetFrameworks
+}
+
+// targetsSDK returns true if the project's SDK or one of its platform target frameworks targets the given SDK.
+func (project Model) targetsSDK(sdk constants.SDK) bool {
+	if project.SDK == sdk {
+		return true
+	}
+	for _, targetFramework := range project.PlatformTargetFrameworks() {
+		if targetFrameworkSDK, _ := constants.ParseTargetFramework(targetFramework); targetFrameworkSDK == sdk {
+			return true
+		}
+	}
+	return false
+}
+
+// PlatformProjects returns the project for each of its platform target frameworks: a multi-platform project
+// (like a MAUI app targeting net8.0-ios and net8.0-android) is returned once per target framework
+// with the target framework's SDK and Configuration|Platforms, the other projects are returned as they are.
+func (project Model) PlatformProjects() []Model {
+	if len(project.TargetFrameworkConfigs) == 0 {
+		return []Model{project}
+	}
+
+	projects := []Model{}
+	for _, targetFramework := range project.PlatformTargetFrameworks() {
+		configs, ok := project.TargetFrameworkConfigs[targetFramework]
+		if !ok {
+			continue
+		}
+		sdk, err := constants.ParseTargetFramework(targetFramework)
+		if err != nil {
+			continue
+		}
+
+		platformProject := project
+		platformProject.SDK = sdk
+		platformProject.TargetFrameworks = []string{targetFramework}
+		platformProject.Configs = configs
+		platformProject.TargetFrameworkConfigs = nil
+		projects = append(projects, platformProject)
+	}
+	return projects
 }
 
 func debugLog(err error, pth string) {
 	log.Debugf("%v for project at %s", err, pth)
 }
 
-func analyzeTargetDefinition(projectModel Model, pth string) (Model, error) {
+func analyzeTargetDefinition(projectModel Model, pth string, visited map[string]bool, fileSystem utility.FileSystem, cache *FileCache) (Model, error) {
 	projectDir := filepath.Dir(pth)
 	var err error
 
-	parsedProject, err := ParseProject(pth)
+	parsedProject, err := cache.parseProject(pth, fileSystem)
 	if err != nil {
 		return Model{}, err
 	}
 
-	for _, importedProject := range GetImportedProjects(parsedProject) {
-		if !strings.Contains(importedProject, "$(MSBuild") {
-			targetDefinitionPth := filepath.Join(projectDir, importedProject)
+	// imports are resolved with the properties known without evaluating the project
+	properties := reservedProperties(projectModel.Pth)
+	for key, value := range thisFileProperties(pth) {
+		properties[key] = value
+	}
 
-			if exist, err := pathutil.IsPathExists(targetDefinitionPth); err != nil {
-				return Model{}, err
-			} else if exist {
-				projectFromTargetDefinition, err := analyzeTargetDefinition(projectModel, targetDefinitionPth)
-				if err != nil {
-					return Model{}, err
-				}
+	for _, importItem := range parsedProject.Imports {
+		if importItem.Sdk != "" {
+			continue
+		}
 
-				// Set properties became from solution analyze
-				projectFromTargetDefinition.Name = projectModel.Name
-				projectFromTargetDefinition.Pth = projectModel.Pth
-				projectFromTargetDefinition.ConfigMap = projectModel.ConfigMap
-				// ---
+		if applies, err := evaluateCondition(importItem.Condition, properties, fileSystem); err != nil {
+			debugLog(err, pth)
+			continue
+		} else if !applies {
+			continue
+		}
+
+		for _, targetDefinitionPth := range resolveImportPaths(expandProperties(importItem.Project, properties, fileSystem), projectDir, fileSystem) {
+			if visited[targetDefinitionPth] {
+				continue
+			}
+			visited[targetDefinitionPth] = true
 
-				projectModel = projectFromTargetDefinition
+			projectFromTargetDefinition, err := analyzeTargetDefinition(projectModel, targetDefinitionPth, visited, fileSystem, cache)
+			if err != nil {
+				return Model{}, err
 			}
+
+			// Set properties became from solution analyze
+			projectFromTargetDefinition.Name = projectModel.Name
+			projectFromTargetDefinition.Pth = projectModel.Pth
+			projectFromTargetDefinition.ConfigMap = projectModel.ConfigMap
+			// ---
+
+			projectModel = projectFromTargetDefinition
 		}
 	}
 
@@ -112,34 +287,278 @@ func analyzeTargetDefinition(projectModel Model, pth string) (Model, error) {
 		debugLog(err, pth)
 	}
 
-	if projectModel.SDK == constants.SDKAndroid {
-		projectModel.ManifestPth, err = GetResolvedAndroidManifestPath(parsedProject, projectDir)
+	if sdk, err := GetSdk(parsedProject); err == nil {
+		projectModel.Sdk = sdk
+	}
+
+	if projectModel.IsSDKStyle() {
+		if targetFrameworks, err := GetTargetFrameworks(parsedProject); err != nil {
+			debugLog(err, pth)
+		} else {
+			projectModel.TargetFrameworks = targetFrameworks
+		}
+
+		if projectModel.SDK == constants.SDKUnknown {
+			projectModel.SDK, err = GetResolvedTargetFrameworkSDK(projectModel.TargetFrameworks)
+			if err != nil {
+				debugLog(err, pth)
+			}
+		}
+
+		if applicationID, err := GetApplicationID(parsedProject); err != nil {
+			debugLog(err, pth)
+		} else {
+			projectModel.ApplicationID = applicationID
+		}
+	}
+
+	// the Android project properties are needed by the Android target framework of the multi-platform projects too
+	if projectModel.targetsSDK(constants.SDKAndroid) {
+		if projectModel.IsSDKStyle() {
+			projectModel.ManifestPth, err = getResolvedSDKStyleAndroidManifestPath(parsedProject, projectDir, fileSystem)
+		} else {
+			projectModel.ManifestPth, err = GetResolvedAndroidManifestPath(parsedProject, projectDir)
+		}
 		if err != nil {
 			debugLog(err, pth)
+		} else if manifestPth, warning := fileSystem.ResolvePath(projectModel.ManifestPth); warning != "" {
+			log.Warnf("Android manifest of project (%s): %s", pth, warning)
+			projectModel.ManifestPth = manifestPth
 		}
 
 		projectModel.AndroidApplication, err = GetIsAndroidApplication(parsedProject)
 		if err != nil {
 			debugLog(err, pth)
+
+			// SDK-style Android application projects are identified by their output type
+			if projectModel.IsSDKStyle() {
+				projectModel.AndroidApplication = projectModel.OutputType == "exe"
+			}
 		}
 	}
 
 	projectModel.ReferredProjectIDs = GetReferencedProjectIds(parsedProject)
+	for _, referencePth := range GetReferencedProjectPaths(parsedProject, projectDir) {
+		referencePth, warning := fileSystem.ResolvePath(referencePth)
+		if warning != "" {
+			log.Warnf("Project (%s) reference: %s", pth, warning)
+		}
+		projectModel.ReferredProjectPaths = append(projectModel.ReferredProjectPaths, referencePth)
+	}
+
+	globalProperties := map[string]string{}
+	if projectModel.IsSDKStyle() {
+		if targetFramework := projectModel.TargetFrameworkForSDK(projectModel.SDK); targetFramework != "" {
+			globalProperties["TargetFramework"] = targetFramework
+		}
+	}
 
-	configPlatforms, err := GetPropertyGroupsConfiguration(parsedProject, projectDir, projectModel.SDK)
+	configPlatforms, err := getPropertyGroupsConfiguration(parsedProject, projectDir, projectModel.SDK, globalProperties, fileSystem)
 	if err != nil {
 		debugLog(err, pth)
 	}
 
-	for _, configPlatform := range configPlatforms {
-		projectModel.Configs[utility.ToConfig(configPlatform.Configuration, configPlatform.Platform)] = configPlatform
+	if projectModel.IsSDKStyle() {
+		targetFramework := projectModel.TargetFrameworkForSDK(projectModel.SDK)
+		for _, configPlatform := range GetImplicitConfigurations(projectDir, targetFramework) {
+			config := utility.ToConfig(configPlatform.Configuration, configPlatform.Platform)
+			if _, ok := projectModel.Configs[config]; !ok {
+				projectModel.Configs[config] = configPlatform
+			}
+		}
+
+		for _, configPlatform := range configPlatforms {
+			if configPlatform.OutputDir == "" {
+				configPlatform.OutputDir = GetImplicitOutputDir(projectDir, configPlatform.Configuration, targetFramework)
+			}
+			projectModel.Configs[utility.ToConfig(configPlatform.Configuration, configPlatform.Platform)] = configPlatform
+		}
+	} else {
+		for _, configPlatform := range configPlatforms {
+			projectModel.Configs[utility.ToConfig(configPlatform.Configuration, configPlatform.Platform)] = configPlatform
+		}
 	}
 
 	return projectModel, nil
 }
 
-func analyzeProject(pth string) (Model, error) {
-	absPth, err := pathutil.AbsPath(pth)
+// defaultProperties returns the evaluated properties of the project's first Configuration|Platform.
+func (project Model) defaultProperties() map[string]string {
+	configs := []string{}
+	for config := range project.Configs {
+		configs = append(configs, config)
+	}
+	if len(configs) == 0 {
+		return nil
+	}
+	sort.Strings(configs)
+	return project.Configs[configs[0]].Properties
+}
+
+// evaluateConfigs evaluates the project's properties for each of its Configuration|Platform
+// and resolves the project level properties containing property references.
+func evaluateConfigs(projectModel Model, globalProperties map[string]string, fileSystem utility.FileSystem, cache *FileCache) Model {
+	evaluator, err := newEvaluator(projectModel.Pth, fileSystem, cache)
+	if err != nil {
+		debugLog(err, projectModel.Pth)
+		return projectModel
+	}
+	projectDir := filepath.Dir(projectModel.Pth)
+
+	configs := []string{}
+	for config := range projectModel.Configs {
+		configs = append(configs, config)
+	}
+	sort.Strings(configs)
+
+	if len(configs) == 0 {
+		if evaluation, err := evaluator.EvaluateProject("", "", globalProperties); err != nil {
+			debugLog(err, projectModel.Pth)
+		} else {
+			projectModel.Imports = evaluation.Imports
+			projectModel.ImportPatterns = evaluation.ImportPatterns
+		}
+	}
+
+	for _, config := range configs {
+		configPlatform := projectModel.Configs[config]
+
+		configGlobalProperties := map[string]string{}
+		for key, value := range globalProperties {
+			configGlobalProperties[key] = value
+		}
+		if projectModel.IsSDKStyle() {
+			if _, ok := lookupPropertyOK(configGlobalProperties, "TargetFramework"); !ok {
+				if targetFramework := projectModel.TargetFrameworkForSDK(projectModel.SDK); targetFramework != "" {
+					configGlobalProperties["TargetFramework"] = targetFramework
+				}
+			}
+		}
+
+		evaluation, err := evaluator.EvaluateProject(configPlatform.Configuration, configPlatform.Platform, configGlobalProperties)
+		if err != nil {
+			debugLog(err, projectModel.Pth)
+			continue
+		}
+		if projectModel.Imports == nil {
+			projectModel.Imports = evaluation.Imports
+		}
+		projectModel.ImportPatterns = appendUniqueFold(projectModel.ImportPatterns, evaluation.ImportPatterns...)
+
+		applyEvaluatedConfiguration(&configPlatform, evaluation.Properties, projectDir, projectModel.SDK)
+
+		projectModel.Configs[config] = configPlatform
+	}
+
+	// project level properties are resolved with the first Configuration|Platform's properties
+	if properties := projectModel.defaultProperties(); properties != nil {
+		if strings.Contains(projectModel.AssemblyName, "$(") {
+			projectModel.AssemblyName = expandProperties(projectModel.AssemblyName, properties, fileSystem)
+		}
+		if strings.Contains(projectModel.ApplicationID, "$(") {
+			projectModel.ApplicationID = expandProperties(projectModel.ApplicationID, properties, fileSystem)
+		}
+	}
+
+	return projectModel
+}
+
+// evaluateTargetFrameworkConfigs evaluates the Configuration|Platforms of the multi-platform project's each platform target framework,
+// the project's Configs are the ones of its SDK's target framework.
+func evaluateTargetFrameworkConfigs(projectModel Model, globalProperties map[string]string, fileSystem utility.FileSystem, cache *FileCache) Model {
+	targetFrameworks := projectModel.PlatformTargetFrameworks()
+	if !projectModel.IsSDKStyle() || len(targetFrameworks) < 2 {
+		return projectModel
+	}
+	// the project is built for the given target framework only
+	if _, ok := lookupPropertyOK(globalProperties, "TargetFramework"); ok {
+		return projectModel
+	}
+
+	evaluator, err := newEvaluator(projectModel.Pth, fileSystem, cache)
+	if err != nil {
+		debugLog(err, projectModel.Pth)
+		return projectModel
+	}
+	projectDir := filepath.Dir(projectModel.Pth)
+
+	projectModel.TargetFrameworkConfigs = map[string]map[string]ConfigurationPlatformModel{}
+	for _, targetFramework := range targetFrameworks {
+		if targetFramework == projectModel.TargetFrameworkForSDK(projectModel.SDK) {
+			projectModel.TargetFrameworkConfigs[targetFramework] = projectModel.Configs
+			continue
+		}
+
+		sdk, err := constants.ParseTargetFramework(targetFramework)
+		if err != nil {
+			continue
+		}
+
+		configGlobalProperties := map[string]string{}
+		for key, value := range globalProperties {
+			configGlobalProperties[key] = value
+		}
+		configGlobalProperties["TargetFramework"] = targetFramework
+
+		configs := map[string]ConfigurationPlatformModel{}
+		for config, projectConfig := range projectModel.Configs {
+			configPlatform := ConfigurationPlatformModel{
+				Configuration: projectConfig.Configuration,
+				Platform:      projectConfig.Platform,
+				OutputDir:     GetImplicitOutputDir(projectDir, projectConfig.Configuration, targetFramework),
+			}
+
+			evaluation, err := evaluator.EvaluateProject(configPlatform.Configuration, configPlatform.Platform, configGlobalProperties)
+			if err != nil {
+				debugLog(err, projectModel.Pth)
+				continue
+			}
+			projectModel.ImportPatterns = appendUniqueFold(projectModel.ImportPatterns, evaluation.ImportPatterns...)
+
+			applyEvaluatedConfiguration(&configPlatform, evaluation.Properties, projectDir, sdk)
+
+			configs[config] = configPlatform
+		}
+		projectModel.TargetFrameworkConfigs[targetFramework] = configs
+	}
+
+	return projectModel
+}
+
+// applyEvaluatedConfiguration sets the Configuration|Platform's fields from the evaluated properties,
+// which include the properties defined by the imported files.
+func applyEvaluatedConfiguration(configModel *ConfigurationPlatformModel, properties map[string]string, projectDir string, sdk constants.SDK) {
+	configModel.Properties = properties
+
+	if outputPath := lookupProperty(properties, "OutputPath"); outputPath != "" {
+		outputDir := utility.FixWindowsPath(outputPath)
+		if !filepath.IsAbs(outputDir) {
+			outputDir = filepath.Join(projectDir, outputDir)
+		}
+		configModel.OutputDir = filepath.Clean(outputDir)
+	}
+
+	if sdk == constants.SDKIOS || sdk == constants.SDKMacOS || sdk == constants.SDKTvOS {
+		if mtouchArch, ok := lookupPropertyOK(properties, "MtouchArch"); ok {
+			configModel.MtouchArchs = utility.SplitAndStripList(mtouchArch, ",")
+		}
+
+		if buildIpa, ok := lookupPropertyOK(properties, "BuildIpa"); ok {
+			configModel.BuildIpa = boolParse(buildIpa)
+		}
+	}
+
+	if sdk == constants.SDKAndroid {
+		if androidKeyStore, ok := lookupPropertyOK(properties, "AndroidKeyStore"); ok {
+			configModel.SignAndroid = boolParse(androidKeyStore)
+		}
+
+		configModel.AndroidPackageFormat = strings.ToLower(lookupProperty(properties, "AndroidPackageFormat"))
+	}
+}
+
+func analyzeProject(pth string, globalProperties map[string]string, fileSystem utility.FileSystem, cache *FileCache) (Model, error) {
+	absPth, err := fileSystem.AbsPath(pth)
 	if err != nil {
 		return Model{}, fmt.Errorf("failed to expand path (%s), error: %s", pth, err)
 	}
@@ -156,5 +575,40 @@ func analyzeProject(pth string) (Model, error) {
 		SDK:           constants.SDKUnknown,
 		TestFramework: constants.TestFrameworkUnknown,
 	}
-	return analyzeTargetDefinition(project, absPth)
+	project, err = analyzeTargetDefinition(project, absPth, map[string]bool{absPth: true}, fileSystem, cache)
+	if err != nil {
+		return Model{}, err
+	}
+	project = evaluateConfigs(project, globalProperties, fileSystem, cache)
+	project = evaluateTargetFrameworkConfigs(project, globalProperties, fileSystem, cache)
+
+	properties := project.defaultProperties()
+	if properties == nil {
+		properties = reservedProperties(project.Pth)
+	}
+
+	// MSBuild defaults the assembly name to the project file's name (MSBuildProjectName)
+	if project.AssemblyName == "" {
+		project.AssemblyName = lookupProperty(properties, "AssemblyName")
+	}
+	if project.AssemblyName == "" {
+		project.AssemblyName = fileName
+	}
+
+	if project.Packages, err = resolvePackages(project, properties, fileSystem, cache); err != nil {
+		debugLog(err, project.Pth)
+	}
+
+	// package references might be defined by the imported files (like Directory.Build.props)
+	if project.TestFramework == constants.TestFrameworkUnknown && len(project.Packages) > 0 {
+		var packageIDs []string
+		for _, pkg := range project.Packages {
+			packageIDs = append(packageIDs, pkg.ID)
+		}
+		if testFramework, err := GetResolvedTestFramework(packageIDs); err == nil {
+			project.TestFramework = testFramework
+		}
+	}
+
+	return project, nil
 }
@@ -4,10 +4,8 @@ import (
 	"encoding/xml"
 	"fmt"
 	"path/filepath"
-	"regexp"
 	"strings"
 
-	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/log"
 	"github.com/bitrise-io/go-xamarin/constants"
 	"github.com/bitrise-io/go-xamarin/utility"
@@ -20,9 +18,26 @@ type Project struct {
 	DefaultTargets string          `xml:"DefaultTargets,attr"`
 	ToolsVersion   string          `xml:"ToolsVersion,attr"`
 	Xmlns          string          `xml:"xmlns,attr"`
+	Sdk            string          `xml:"Sdk,attr"`
+	Sdks           []Sdk           `xml:"Sdk"`
 	PropertyGroups []PropertyGroup `xml:"PropertyGroup"`
 	ItemGroups     []ItemGroup     `xml:"ItemGroup"`
 	Imports        []Import        `xml:"Import"`
+
+	Elements []ProjectElement `xml:"-"` // The top level elements in document order
+}
+
+// ProjectElement is a top level element of the csproj file.
+type ProjectElement struct {
+	XMLName   xml.Name
+	Condition string `xml:"Condition,attr"`
+	Project   string `xml:"Project,attr"`
+	Sdk       string `xml:"Sdk,attr"`
+	InnerXML  string `xml:",innerxml"`
+}
+
+type projectElements struct {
+	Elements []ProjectElement `xml:",any"`
 }
 
 // Import the import values from the csproj file.
@@ -31,12 +46,26 @@ type Import struct {
 	Project   string `xml:"Project,attr"`
 	Label     string `xml:"Label,attr"`
 	Condition string `xml:"Condition,attr"`
+	Sdk       string `xml:"Sdk,attr"`
+}
+
+// Sdk the sdk element from the csproj file, an alternative of the Project's Sdk attribute.
+type Sdk struct {
+	Name    string `xml:"Name,attr"`
+	Version string `xml:"Version,attr"`
+}
+
+// ConditionalProperty a property from the csproj file, which might be set only under a given condition.
+type ConditionalProperty struct {
+	Text      string `xml:",chardata"`
+	Condition string `xml:"Condition,attr"`
 }
 
 // PropertyGroup the property group from the csproj file.
 type PropertyGroup struct {
 	XMLName       xml.Name `xml:"PropertyGroup"`
 	Text          string   `xml:",chardata"`
+	InnerXML      string   `xml:",innerxml"`
 	Condition     string   `xml:"Condition,attr"`
 	Configuration []struct {
 		Text      string `xml:",chardata"`
@@ -46,39 +75,43 @@ type PropertyGroup struct {
 		Text      string `xml:",chardata"`
 		Condition string `xml:"Condition,attr"`
 	} `xml:"Platform"`
-	ProjectGUID               []string `xml:"ProjectGuid"`
-	ProjectTypeGuids          []string `xml:"ProjectTypeGuids"`
-	OutputType                []string `xml:"OutputType"`
-	RootNamespace             []string `xml:"RootNamespace"`
-	AssemblyName              []string `xml:"AssemblyName"`
-	TargetFrameworkVersion    []string `xml:"TargetFrameworkVersion"`
-	AndroidApplication        []string `xml:"AndroidApplication"`
-	AndroidManifest           []string `xml:"AndroidManifest"`
-	AndroidResgenFile         []string `xml:"AndroidResgenFile"`
-	AndroidResgenClass        []string `xml:"AndroidResgenClass"`
-	MonoAndroidResourcePrefix []string `xml:"MonoAndroidResourcePrefix"`
-	MonoAndroidAssetsPrefix   []string `xml:"MonoAndroidAssetsPrefix"`
-	DebugSymbols              []string `xml:"DebugSymbols"`
-	DebugType                 []string `xml:"DebugType"`
-	Optimize                  []string `xml:"Optimize"`
-	OutputPath                []string `xml:"OutputPath"`
-	DefineConstants           []string `xml:"DefineConstants"`
-	ErrorReport               []string `xml:"ErrorReport"`
-	WarningLevel              []string `xml:"WarningLevel"`
-	AndroidLinkMode           []string `xml:"AndroidLinkMode"`
-	AndroidManagedSymbols     []string `xml:"AndroidManagedSymbols"`
-	AndroidUseSharedRuntime   []string `xml:"AndroidUseSharedRuntime"`
-	MandroidI18n              []string `xml:"MandroidI18n"`
-	MtouchArch                []string `xml:"MtouchArch"`
-	AndroidSupportedAbis      []string `xml:"AndroidSupportedAbis"`
-	BuildIpa                  []string `xml:"BuildIpa"`
-	AndroidKeyStore           []string `xml:"AndroidKeyStore"`
+	ProjectGUID               []string              `xml:"ProjectGuid"`
+	ProjectTypeGuids          []string              `xml:"ProjectTypeGuids"`
+	OutputType                []string              `xml:"OutputType"`
+	RootNamespace             []string              `xml:"RootNamespace"`
+	AssemblyName              []string              `xml:"AssemblyName"`
+	TargetFrameworkVersion    []string              `xml:"TargetFrameworkVersion"`
+	TargetFramework           []ConditionalProperty `xml:"TargetFramework"`
+	TargetFrameworks          []ConditionalProperty `xml:"TargetFrameworks"`
+	ApplicationID             []string              `xml:"ApplicationId"`
+	AndroidApplication        []string              `xml:"AndroidApplication"`
+	AndroidManifest           []string              `xml:"AndroidManifest"`
+	AndroidResgenFile         []string              `xml:"AndroidResgenFile"`
+	AndroidResgenClass        []string              `xml:"AndroidResgenClass"`
+	MonoAndroidResourcePrefix []string              `xml:"MonoAndroidResourcePrefix"`
+	MonoAndroidAssetsPrefix   []string              `xml:"MonoAndroidAssetsPrefix"`
+	DebugSymbols              []string              `xml:"DebugSymbols"`
+	DebugType                 []string              `xml:"DebugType"`
+	Optimize                  []string              `xml:"Optimize"`
+	OutputPath                []string              `xml:"OutputPath"`
+	DefineConstants           []string              `xml:"DefineConstants"`
+	ErrorReport               []string              `xml:"ErrorReport"`
+	WarningLevel              []string              `xml:"WarningLevel"`
+	AndroidLinkMode           []string              `xml:"AndroidLinkMode"`
+	AndroidManagedSymbols     []string              `xml:"AndroidManagedSymbols"`
+	AndroidUseSharedRuntime   []string              `xml:"AndroidUseSharedRuntime"`
+	MandroidI18n              []string              `xml:"MandroidI18n"`
+	MtouchArch                []string              `xml:"MtouchArch"`
+	AndroidSupportedAbis      []string              `xml:"AndroidSupportedAbis"`
+	BuildIpa                  []string              `xml:"BuildIpa"`
+	AndroidKeyStore           []string              `xml:"AndroidKeyStore"`
 }
 
 // ItemGroup the item group from the csproj file.
 type ItemGroup struct {
 	XMLName   xml.Name `xml:"ItemGroup"`
 	Text      string   `xml:",chardata"`
+	Condition string   `xml:"Condition,attr"`
 	Reference []struct {
 		Text    string `xml:",chardata"`
 		Include string `xml:"Include,attr"`
@@ -96,6 +129,22 @@ type ItemGroup struct {
 		Text    string `xml:",chardata"`
 		Include string `xml:"Include,attr"`
 	} `xml:"AndroidResource"`
+	PackageReferences []PackageReference `xml:"PackageReference"`
+	PackageVersions   []PackageReference `xml:"PackageVersion"` // Central package management (Directory.Packages.props)
+}
+
+// PackageReference the package reference (or central package version) from the csproj file,
+// the version might be set either as attribute or as child element.
+type PackageReference struct {
+	Include             string `xml:"Include,attr"`
+	Update              string `xml:"Update,attr"`
+	Condition           string `xml:"Condition,attr"`
+	VersionAttr         string `xml:"Version,attr"`
+	Version             string `xml:"Version"`
+	VersionOverrideAttr string `xml:"VersionOverride,attr"`
+	VersionOverride     string `xml:"VersionOverride"`
+	PrivateAssetsAttr   string `xml:"PrivateAssets,attr"`
+	PrivateAssets       string `xml:"PrivateAssets"`
 }
 
 // ProjReference the project reference from the csproj file.
@@ -117,12 +166,39 @@ func ParseProjectContent(content string) (Project, error) {
 	if err := xml.Unmarshal([]byte(content), &project); err != nil {
 		return Project{}, fmt.Errorf("failed to unmarshall conent. Error: %v", err)
 	}
+
+	var elements projectElements
+	if err := xml.Unmarshal([]byte(content), &elements); err != nil {
+		return Project{}, fmt.Errorf("failed to unmarshall conent. Error: %v", err)
+	}
+	project.Elements = elements.Elements
+
 	return project, nil
 }
 
+// GetImportGroupImports gets the Import elements of the given ImportGroup element.
+func GetImportGroupImports(importGroup ProjectElement) ([]ProjectElement, error) {
+	var elements projectElements
+	if err := xml.Unmarshal([]byte("<ImportGroup>"+importGroup.InnerXML+"</ImportGroup>"), &elements); err != nil {
+		return nil, fmt.Errorf("failed to parse import group: %s", err)
+	}
+
+	var imports []ProjectElement
+	for _, element := range elements.Elements {
+		if element.XMLName.Local == "Import" {
+			imports = append(imports, element)
+		}
+	}
+	return imports, nil
+}
+
 // ParseProject parses the given project on path.
 func ParseProject(path string) (Project, error) {
-	projectDefinitionFileContent, err := fileutil.ReadStringFromFile(path)
+	return parseProject(path, utility.OSFileSystem)
+}
+
+func parseProject(path string, fileSystem utility.FileSystem) (Project, error) {
+	projectDefinitionFileContent, err := fileSystem.ReadStringFromFile(path)
 	if err != nil {
 		return Project{}, fmt.Errorf("failed to parse project at (%s), error: %s", path, err)
 	}
@@ -163,6 +239,75 @@ func GetAssemblyName(project Project) (string, error) {
 	return "", fmt.Errorf(getterErrorMsg, "assembly name")
 }
 
+// GetSdk gets the MSBuild project SDK of an SDK-style project.
+func GetSdk(project Project) (string, error) {
+	if project.Sdk != "" {
+		return project.Sdk, nil
+	}
+	for _, sdk := range project.Sdks {
+		if sdk.Name != "" {
+			return sdk.Name, nil
+		}
+	}
+	return "", fmt.Errorf(getterErrorMsg, "sdk")
+}
+
+// GetTargetFrameworks gets the target frameworks from the given project.
+// TargetFrameworks takes precedence over TargetFramework, like in MSBuild.
+func GetTargetFrameworks(project Project) ([]string, error) {
+	targetFrameworksLine := ""
+	for _, propertyGroup := range project.PropertyGroups {
+		if value, ok := lastUnconditionalProperty(propertyGroup.TargetFrameworks); ok {
+			targetFrameworksLine = value
+			break
+		}
+	}
+
+	if targetFrameworksLine == "" {
+		for _, propertyGroup := range project.PropertyGroups {
+			if value, ok := lastUnconditionalProperty(propertyGroup.TargetFramework); ok {
+				targetFrameworksLine = value
+				break
+			}
+		}
+	}
+
+	var targetFrameworks []string
+	for _, targetFramework := range utility.SplitAndStripList(targetFrameworksLine, ";") {
+		// property references can not be resolved at this point
+		if targetFramework == "" || strings.Contains(targetFramework, "$(") {
+			continue
+		}
+		targetFrameworks = append(targetFrameworks, targetFramework)
+	}
+
+	if len(targetFrameworks) == 0 {
+		return nil, fmt.Errorf(getterErrorMsg, "target frameworks")
+	}
+	return targetFrameworks, nil
+}
+
+// GetResolvedTargetFrameworkSDK gets the SDK of the first platform specific target framework.
+func GetResolvedTargetFrameworkSDK(targetFrameworks []string) (constants.SDK, error) {
+	for _, targetFramework := range targetFrameworks {
+		if sdk, err := constants.ParseTargetFramework(targetFramework); err == nil {
+			return sdk, nil
+		}
+	}
+	return constants.SDKUnknown, fmt.Errorf(getterErrorMsg, "platform specific target framework")
+}
+
+// GetApplicationID gets the application id from the given project.
+func GetApplicationID(project Project) (string, error) {
+	for _, propertyGroup := range project.PropertyGroups {
+		length := len(propertyGroup.ApplicationID)
+		if length > 0 {
+			return propertyGroup.ApplicationID[length-1], nil
+		}
+	}
+	return "", fmt.Errorf(getterErrorMsg, "application id")
+}
+
 // GetAndroidManifestPath gets the path for the Android manifest from the given project.
 func GetAndroidManifestPath(project Project) (string, error) {
 	for _, propertyGroup := range project.PropertyGroups {
@@ -184,6 +329,28 @@ func GetResolvedAndroidManifestPath(project Project, projectDir string) (string,
 	return filepath.Join(projectDir, relativePth), nil
 }
 
+// GetResolvedSDKStyleAndroidManifestPath gets the resolved path for the Android manifest of an SDK-style project,
+// falling back to the default .NET for Android and MAUI manifest locations.
+func GetResolvedSDKStyleAndroidManifestPath(project Project, projectDir string) (string, error) {
+	return getResolvedSDKStyleAndroidManifestPath(project, projectDir, utility.OSFileSystem)
+}
+
+func getResolvedSDKStyleAndroidManifestPath(project Project, projectDir string, fileSystem utility.FileSystem) (string, error) {
+	if pth, err := GetResolvedAndroidManifestPath(project, projectDir); err == nil {
+		return pth, nil
+	}
+
+	for _, relativePth := range []string{"AndroidManifest.xml", "Platforms/Android/AndroidManifest.xml"} {
+		pth := filepath.Join(projectDir, relativePth)
+		if exist, err := fileSystem.IsPathExists(pth); err != nil {
+			return "", err
+		} else if exist {
+			return pth, nil
+		}
+	}
+	return "", fmt.Errorf(getterErrorMsg, "Android manifest path")
+}
+
 // GetIsAndroidApplication gets the bool value if the project is an Android project.
 func GetIsAndroidApplication(project Project) (bool, error) {
 	for _, propertyGroup := range project.PropertyGroups {
@@ -215,36 +382,35 @@ func GetPropertyGroupCondition(propertyGroup PropertyGroup) (string, error) {
 
 // GetResolvedConfiguration gets the resolved configuration from the given property group.
 func GetResolvedConfiguration(propertyGroup PropertyGroup) (string, error) {
-	conditionText, err := GetPropertyGroupCondition(propertyGroup)
-	if err != nil {
-		return "", err
-	}
-	if matches := regexp.MustCompile(`'\$\(Configuration\)\|\$\(Platform\)'\s*==\s*'(?P<config>.*)\|(?P<platform>.*)'`).FindStringSubmatch(conditionText); len(matches) == 3 {
-		return matches[1], nil
-	}
-
-	if matches := regexp.MustCompile(`'\$\(Configuration\)'\s*==\s*'(?P<config>.*)'`).FindStringSubmatch(conditionText); len(matches) == 2 {
-		return matches[1], nil
-	}
-
-	return conditionText, nil
+	return getResolvedConditionBinding(propertyGroup, "Configuration")
 }
 
 // GetResolvedPlatform gets the resolved platform from the given property group.
 func GetResolvedPlatform(propertyGroup PropertyGroup) (string, error) {
+	return getResolvedConditionBinding(propertyGroup, "Platform")
+}
+
+func getResolvedConditionBinding(propertyGroup PropertyGroup, property string) (string, error) {
 	conditionText, err := GetPropertyGroupCondition(propertyGroup)
 	if err != nil {
 		return "", err
 	}
-	if matches := regexp.MustCompile(`'\$\(Configuration\)\|\$\(Platform\)'\s*==\s*'(?P<config>.*)\|(?P<platform>.*)'`).FindStringSubmatch(conditionText); len(matches) == 3 {
-		return matches[2], nil
+
+	condition, err := ParseCondition(conditionText)
+	if err != nil {
+		return "", err
 	}
 
-	if matches := regexp.MustCompile(`'\$\(Platform\)'\s*==\s*'(?P<platform>.*)'`).FindStringSubmatch(conditionText); len(matches) == 2 {
-		return matches[1], nil
+	value, ok := condition.BindingFor(property)
+	if !ok {
+		return "", fmt.Errorf(getterErrorMsg, strings.ToLower(property)+" in condition: "+conditionText)
 	}
+	return value, nil
+}
 
-	return conditionText, nil
+// PropertyGroupApplies checks if the given property group's condition is true for the given properties.
+func PropertyGroupApplies(propertyGroup PropertyGroup, properties map[string]string) (bool, error) {
+	return EvaluateCondition(propertyGroup.Condition, properties)
 }
 
 // GetPlatform gets the platform from the given property group.
@@ -355,23 +521,56 @@ func GetItemGroupIncludes(itemGroup ItemGroup) []string {
 	return includes
 }
 
-// GetTestFramework gets the test framework for the given project.
+// GetItemGroupPackageReferenceIncludes gets the included package IDs from the given item group.
+func GetItemGroupPackageReferenceIncludes(itemGroup ItemGroup) []string {
+	var includes []string
+	for _, reference := range itemGroup.PackageReferences {
+		if reference.Include != "" {
+			includes = append(includes, reference.Include)
+		}
+	}
+	return includes
+}
+
+// GetTestFramework gets the test framework for the given project,
+// based on its assembly references and package references.
 func GetTestFramework(project Project) (constants.TestFramework, error) {
-	var testFramework constants.TestFramework
+	var references []string
 	for _, itemGroup := range project.ItemGroups {
-		includes := GetItemGroupIncludes(itemGroup)
-		for _, include := range includes {
-			switch include {
-			case "Xamarin.UITest":
-				return constants.TestFrameworkXamarinUITest, nil
-			case "MonoTouch.NUnitLite":
-				return constants.TestFrameworkNunitLiteTest, nil
-			case "nunit", "nunit.framework":
+		references = append(references, GetItemGroupIncludes(itemGroup)...)
+		references = append(references, GetItemGroupPackageReferenceIncludes(itemGroup)...)
+	}
+	return GetResolvedTestFramework(references)
+}
+
+// GetResolvedTestFramework gets the test framework for the given assembly or package references.
+// Xamarin.UITest and NUnitLite test projects reference NUnit as well, so they take precedence.
+func GetResolvedTestFramework(references []string) (constants.TestFramework, error) {
+	testFramework := constants.TestFrameworkUnknown
+	for _, reference := range references {
+		// Reference Include might contain the assembly's full name: nunit.framework, Version=3.6.0.0, Culture=neutral
+		name := strings.ToLower(strings.TrimSpace(strings.Split(reference, ",")[0]))
+
+		switch name {
+		case "xamarin.uitest":
+			return constants.TestFrameworkXamarinUITest, nil
+		case "monotouch.nunitlite":
+			return constants.TestFrameworkNunitLiteTest, nil
+		case "nunit", "nunit.framework":
+			if testFramework == constants.TestFrameworkUnknown {
 				testFramework = constants.TestFrameworkNunitTest
 			}
+		case "xunit", "xunit.core", "xunit.v3", "xunit.v3.core":
+			if testFramework == constants.TestFrameworkUnknown {
+				testFramework = constants.TestFrameworkXunit
+			}
+		case "mstest", "mstest.testframework", "microsoft.visualstudio.testplatform.testframework", "microsoft.visualstudio.qualitytools.unittestframework":
+			if testFramework == constants.TestFrameworkUnknown {
+				testFramework = constants.TestFrameworkMSTest
+			}
 		}
 	}
-	if testFramework == "" {
+	if testFramework == constants.TestFrameworkUnknown {
 		return constants.TestFrameworkUnknown, fmt.Errorf(getterErrorMsg, "testframework")
 	}
 	return testFramework, nil
@@ -394,11 +593,31 @@ func GetReferencedProjectIds(project Project) []string {
 	for _, projectReference := range projectReferences {
 		id := strings.ToUpper(projectReference.Project)
 		id = trimIDFixes(id)
+		// SDK-style project references do not contain the referred project's GUID
+		if id == "" {
+			continue
+		}
 		projectIds = append(projectIds, id)
 	}
 	return projectIds
 }
 
+// GetReferencedProjectPaths gets the paths of the referenced projects, the relative paths are joined to the given project directory.
+func GetReferencedProjectPaths(project Project, projectDir string) []string {
+	var pths []string
+	for _, projectReference := range GetProjectReferences(project) {
+		include := strings.TrimSpace(utility.FixWindowsPath(projectReference.Include))
+		if include == "" {
+			continue
+		}
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(projectDir, include)
+		}
+		pths = append(pths, include)
+	}
+	return pths
+}
+
 // GetImportedProjects gets the imported projects from a given project.
 func GetImportedProjects(project Project) []string {
 	var importedProjects []string
@@ -408,51 +627,163 @@ func GetImportedProjects(project Project) []string {
 	return importedProjects
 }
 
-// GetPropertyGroupsConfiguration gets the configuration for each property group
+// GetPropertyGroupsConfiguration gets the configuration for each Configuration|Platform the property groups define.
 func GetPropertyGroupsConfiguration(project Project, projectDir string, sdk constants.SDK) ([]ConfigurationPlatformModel, error) {
-	var configModels []ConfigurationPlatformModel
-	for _, propertyGroup := range project.PropertyGroups {
-		var configModel ConfigurationPlatformModel
-		var err error
+	return GetPropertyGroupsConfigurationWithProperties(project, projectDir, sdk, nil)
+}
+
+// GetPropertyGroupsConfigurationWithProperties gets the configuration for each Configuration|Platform the property groups define.
+// The Configuration|Platform candidates are collected from the property group conditions,
+// then each property group, which condition is true for the given candidate (and the given global properties), is applied in document order.
+func GetPropertyGroupsConfigurationWithProperties(project Project, projectDir string, sdk constants.SDK, globalProperties map[string]string) ([]ConfigurationPlatformModel, error) {
+	return getPropertyGroupsConfiguration(project, projectDir, sdk, globalProperties, utility.OSFileSystem)
+}
+
+func getPropertyGroupsConfiguration(project Project, projectDir string, sdk constants.SDK, globalProperties map[string]string, fileSystem utility.FileSystem) ([]ConfigurationPlatformModel, error) {
+	type conditionalPropertyGroup struct {
+		propertyGroup        PropertyGroup
+		condition            Condition
+		selectsConfiguration bool
+	}
+
+	var propertyGroups []conditionalPropertyGroup
+	var configurations, platforms []string
 
-		configModel.Configuration, err = GetResolvedConfiguration(propertyGroup)
+	for _, propertyGroup := range project.PropertyGroups {
+		condition, err := ParseCondition(propertyGroup.Condition)
 		if err != nil {
 			debugParseLog(err)
+			continue
 		}
 
-		configModel.Platform, err = GetResolvedPlatform(propertyGroup)
-		if err != nil {
-			debugParseLog(err)
+		selectsConfiguration := false
+		for name, values := range condition.PropertyBindings() {
+			switch {
+			case strings.EqualFold(name, "Configuration"):
+				configurations = appendUniqueFold(configurations, values...)
+				selectsConfiguration = true
+			case strings.EqualFold(name, "Platform"):
+				platforms = appendUniqueFold(platforms, values...)
+				selectsConfiguration = true
+			}
 		}
 
-		configModel.OutputDir, err = GetOutputDir(propertyGroup, projectDir, configModel.Configuration, configModel.Platform)
-		if err != nil {
-			debugParseLog(err)
+		// default values, like: <Configuration Condition=" '$(Configuration)' == '' ">Debug</Configuration>
+		if configuration, err := GetConfiguration(propertyGroup); err == nil {
+			configurations = appendUniqueFold(configurations, configuration)
+		}
+		if platform, err := GetPlatform(propertyGroup); err == nil {
+			platforms = appendUniqueFold(platforms, platform)
 		}
-		if sdk == constants.SDKIOS || sdk == constants.SDKMacOS || sdk == constants.SDKTvOS {
-			configModel.MtouchArchs, err = GetResolvedMtouchArch(propertyGroup)
-			if err != nil {
-				debugParseLog(err)
+
+		propertyGroups = append(propertyGroups, conditionalPropertyGroup{
+			propertyGroup:        propertyGroup,
+			condition:            condition,
+			selectsConfiguration: selectsConfiguration,
+		})
+	}
+
+	if len(platforms) == 0 {
+		platforms = []string{"AnyCPU"}
+	}
+
+	var configModels []ConfigurationPlatformModel
+	for _, configuration := range configurations {
+		for _, platform := range platforms {
+			properties := map[string]string{}
+			for key, value := range globalProperties {
+				properties[key] = value
+			}
+			properties["Configuration"] = configuration
+			properties["Platform"] = platform
+			if lookupProperty(properties, "MSBuildProjectDirectory") == "" {
+				properties["MSBuildProjectDirectory"] = projectDir
 			}
 
-			configModel.BuildIpa, err = GetBuildIpa(propertyGroup)
-			if err != nil {
-				debugParseLog(err)
+			configModel := ConfigurationPlatformModel{
+				Configuration: configuration,
+				Platform:      platform,
+			}
+			selected := false
+
+			for _, group := range propertyGroups {
+				applies, err := group.condition.evaluate(properties, fileSystem)
+				if err != nil {
+					debugParseLog(err)
+					continue
+				}
+				if !applies {
+					continue
+				}
+
+				if group.selectsConfiguration {
+					selected = true
+				}
+				applyPropertyGroupConfiguration(&configModel, group.propertyGroup, projectDir, sdk)
 			}
-		}
 
-		if sdk == constants.SDKAndroid {
-			configModel.SignAndroid, err = GetAndroidKeyStore(propertyGroup)
-			if err != nil {
-				debugParseLog(err)
+			if selected {
+				configModels = append(configModels, configModel)
 			}
 		}
-
-		configModels = append(configModels, configModel)
 	}
 	return configModels, nil
 }
 
+func applyPropertyGroupConfiguration(configModel *ConfigurationPlatformModel, propertyGroup PropertyGroup, projectDir string, sdk constants.SDK) {
+	if outputDir, err := GetOutputDir(propertyGroup, projectDir, configModel.Configuration, configModel.Platform); err == nil {
+		configModel.OutputDir = outputDir
+	}
+
+	if sdk == constants.SDKIOS || sdk == constants.SDKMacOS || sdk == constants.SDKTvOS {
+		if mtouchArchs, err := GetResolvedMtouchArch(propertyGroup); err == nil {
+			configModel.MtouchArchs = mtouchArchs
+		}
+
+		if buildIpa, err := GetBuildIpa(propertyGroup); err == nil {
+			configModel.BuildIpa = buildIpa
+		}
+	}
+
+	if sdk == constants.SDKAndroid {
+		if signAndroid, err := GetAndroidKeyStore(propertyGroup); err == nil {
+			configModel.SignAndroid = signAndroid
+		}
+	}
+}
+
+// GetImplicitConfigurations gets the Debug and Release configurations, which every SDK-style project gets implicitly.
+func GetImplicitConfigurations(projectDir, targetFramework string) []ConfigurationPlatformModel {
+	var configModels []ConfigurationPlatformModel
+	for _, configuration := range []string{"Debug", "Release"} {
+		configModels = append(configModels, ConfigurationPlatformModel{
+			Configuration: configuration,
+			Platform:      "AnyCPU",
+			OutputDir:     GetImplicitOutputDir(projectDir, configuration, targetFramework),
+		})
+	}
+	return configModels
+}
+
+// GetImplicitOutputDir gets the default output dir of an SDK-style project built for the given target framework:
+// bin/$(Configuration)/$(TargetFramework), the output dirs of a multi-targeting project's target frameworks are separated.
+func GetImplicitOutputDir(projectDir, configuration, targetFramework string) string {
+	outputDir := filepath.Join(projectDir, "bin", configuration)
+	if targetFramework != "" {
+		outputDir = filepath.Join(outputDir, targetFramework)
+	}
+	return outputDir
+}
+
+func lastUnconditionalProperty(properties []ConditionalProperty) (string, bool) {
+	for i := len(properties) - 1; i >= 0; i-- {
+		if properties[i].Condition == "" {
+			return properties[i].Text, true
+		}
+	}
+	return "", false
+}
+
 func boolParse(value string) bool {
 	return strings.EqualFold(value, "true")
 }
@@ -463,6 +794,15 @@ func trimIDFixes(id string) string {
 	return id
 }
 
+func appendUniqueFold(slice []string, values ...string) []string {
+	for _, value := range values {
+		if value != "" && !sliceContainsFold(slice, value) {
+			slice = append(slice, value)
+		}
+	}
+	return slice
+}
+
 func debugParseLog(err error) {
 	log.Debugf("%v", err)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/utility"
 	"github.com/stretchr/testify/require"
 )
 
@@ -56,7 +57,7 @@ func TestAnalyzeProject(t *testing.T) {
 			require.NoError(t, os.Remove(pth))
 		}()
 
-		project, err := analyzeProject(pth)
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
 		require.NoError(t, err)
 
 		require.Equal(t, "BA48743D-06F3-4D2D-ACFD-EE2642CE155A", project.ID)
@@ -86,7 +87,7 @@ func TestAnalyzeProject(t *testing.T) {
 
 		require.NoError(t, os.Chdir(dir))
 
-		project, err := analyzeProject(base)
+		project, err := analyzeProject(base, nil, utility.OSFileSystem, nil)
 		require.NoError(t, err)
 		require.Equal(t, pth, project.Pth)
 
@@ -111,7 +112,7 @@ func TestAnalyzeProject(t *testing.T) {
 		fileName := filepath.Base(pth)
 		fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
 
-		project, err := analyzeProject(pth)
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
 		require.NoError(t, err)
 
 		require.Equal(t, pth, project.Pth)
@@ -183,7 +184,7 @@ func TestAnalyzeProject(t *testing.T) {
 		fileName := filepath.Base(pth)
 		fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
 
-		project, err := analyzeProject(pth)
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
 		require.NoError(t, err)
 
 		require.Equal(t, pth, project.Pth)
@@ -230,7 +231,7 @@ func TestAnalyzeProject(t *testing.T) {
 		fileName := filepath.Base(pth)
 		fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
 
-		project, err := analyzeProject(pth)
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
 		require.NoError(t, err)
 
 		require.Equal(t, pth, project.Pth)
@@ -295,7 +296,7 @@ func TestAnalyzeProject(t *testing.T) {
 		fileName := filepath.Base(pth)
 		fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
 
-		project, err := analyzeProject(pth)
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
 		require.NoError(t, err)
 
 		require.Equal(t, pth, project.Pth)
@@ -342,7 +343,7 @@ func TestAnalyzeProject(t *testing.T) {
 		fileName := filepath.Base(pth)
 		fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
 
-		project, err := analyzeProject(pth)
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
 		require.NoError(t, err)
 
 		require.Equal(t, pth, project.Pth)
@@ -389,7 +390,7 @@ func TestAnalyzeProject(t *testing.T) {
 		fileName := filepath.Base(pth)
 		fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
 
-		project, err := analyzeProject(pth)
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
 		require.NoError(t, err)
 
 		require.Equal(t, pth, project.Pth)
@@ -436,6 +437,178 @@ func TestAnalyzeProject(t *testing.T) {
 	}
 }
 
+func TestAnalyzeSDKStyleProject(t *testing.T) {
+	t.Log(".NET for Android test")
+	{
+		pth := tmpProjectWithContent(t, sdkStyleAndroidTestProjectContent)
+		defer func() {
+			require.NoError(t, os.Remove(pth))
+		}()
+		dir := filepath.Dir(pth)
+
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, "Microsoft.NET.Sdk", project.Sdk)
+		require.Equal(t, true, project.IsSDKStyle())
+		require.Equal(t, []string{"net8.0-android"}, project.TargetFrameworks)
+		require.Equal(t, constants.SDKAndroid, project.SDK)
+		require.Equal(t, "exe", project.OutputType)
+		require.Equal(t, strings.TrimSuffix(filepath.Base(pth), filepath.Ext(pth)), project.AssemblyName)
+		require.Equal(t, "com.bitrise.creditcardvalidator", project.ApplicationID)
+		require.Equal(t, true, project.AndroidApplication)
+		require.Equal(t, 0, len(project.ReferredProjectIDs))
+		require.Equal(t, []string{filepath.Join(filepath.Dir(dir), "CreditCardValidator", "CreditCardValidator.csproj")}, project.ReferredProjectPaths)
+
+		// Implicit configs
+		require.Equal(t, 2, len(project.Configs))
+
+		config, ok := project.Configs["Debug|AnyCPU"]
+		require.Equal(t, true, ok)
+		require.Equal(t, "Debug", config.Configuration)
+		require.Equal(t, "AnyCPU", config.Platform)
+		require.Equal(t, filepath.Join(dir, "bin/Debug/net8.0-android"), config.OutputDir)
+		require.Equal(t, false, config.SignAndroid)
+
+		config, ok = project.Configs["Release|AnyCPU"]
+		require.Equal(t, true, ok)
+		require.Equal(t, "Release", config.Configuration)
+		require.Equal(t, "AnyCPU", config.Platform)
+		require.Equal(t, filepath.Join(dir, "bin/Release/net8.0-android"), config.OutputDir)
+		require.Equal(t, true, config.SignAndroid)
+	}
+
+	t.Log("MAUI multi-targeting test")
+	{
+		pth := tmpProjectWithContent(t, sdkStyleMauiTestProjectContent)
+		defer func() {
+			require.NoError(t, os.Remove(pth))
+		}()
+		dir := filepath.Dir(pth)
+
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, true, project.IsSDKStyle())
+		require.Equal(t, []string{"net8.0-ios", "net8.0-android", "net8.0-maccatalyst"}, project.TargetFrameworks)
+		require.Equal(t, constants.SDKIOS, project.SDK)
+		require.Equal(t, "exe", project.OutputType)
+		require.Equal(t, "com.bitrise.mauiapp", project.ApplicationID)
+		require.Equal(t, true, project.AndroidApplication)
+
+		config, ok := project.Configs["Release|AnyCPU"]
+		require.Equal(t, true, ok)
+		require.Equal(t, filepath.Join(dir, "bin/Release/net8.0-ios"), config.OutputDir)
+
+		platformProjects := project.PlatformProjects()
+		require.Equal(t, 3, len(platformProjects))
+
+		ios := platformProjects[0]
+		require.Equal(t, constants.SDKIOS, ios.SDK)
+		require.Equal(t, []string{"net8.0-ios"}, ios.TargetFrameworks)
+		require.Equal(t, project.Configs, ios.Configs)
+
+		android := platformProjects[1]
+		require.Equal(t, constants.SDKAndroid, android.SDK)
+		require.Equal(t, []string{"net8.0-android"}, android.TargetFrameworks)
+		require.Equal(t, "com.bitrise.mauiapp", android.ApplicationID)
+		config, ok = android.Configs["Release|AnyCPU"]
+		require.Equal(t, true, ok)
+		require.Equal(t, filepath.Join(dir, "bin/Release/net8.0-android"), config.OutputDir)
+		require.Equal(t, "aab", config.AndroidPackageFormat)
+		config, ok = android.Configs["Debug|AnyCPU"]
+		require.Equal(t, true, ok)
+		require.Equal(t, filepath.Join(dir, "bin/Debug/net8.0-android"), config.OutputDir)
+		require.Equal(t, "", config.AndroidPackageFormat)
+
+		macCatalyst := platformProjects[2]
+		require.Equal(t, constants.SDKMacOS, macCatalyst.SDK)
+		require.Equal(t, filepath.Join(dir, "bin/Release/net8.0-maccatalyst"), macCatalyst.Configs["Release|AnyCPU"].OutputDir)
+	}
+
+	t.Log("multi-targeting test project test")
+	{
+		pth := tmpProjectWithContent(t, `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFrameworks>net8.0;net7.0</TargetFrameworks>
+  </PropertyGroup>
+</Project>`)
+		defer func() {
+			require.NoError(t, os.Remove(pth))
+		}()
+		dir := filepath.Dir(pth)
+
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+
+		// the test assembly is looked up in the output dir of the first target framework
+		require.Equal(t, filepath.Join(dir, "bin/Release/net8.0"), project.Configs["Release|AnyCPU"].OutputDir)
+		require.Equal(t, []Model{project}, project.PlatformProjects())
+	}
+
+	t.Log("netstandard library test")
+	{
+		pth := tmpProjectWithContent(t, sdkStyleLibraryTestProjectContent)
+		defer func() {
+			require.NoError(t, os.Remove(pth))
+		}()
+
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, true, project.IsSDKStyle())
+		require.Equal(t, []string{"netstandard2.0"}, project.TargetFrameworks)
+		require.Equal(t, constants.SDKUnknown, project.SDK)
+		require.Equal(t, 2, len(project.Configs))
+	}
+}
+
+func TestAnalyzeProjectWithComplexConditions(t *testing.T) {
+	t.Log("it evaluates property group conditions")
+	{
+		pth := tmpProjectWithContent(t, complexConditionsTestProjectContent)
+		defer func() {
+			require.NoError(t, os.Remove(pth))
+		}()
+		dir := filepath.Dir(pth)
+
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+
+		for config := range project.Configs {
+			require.Equal(t, false, strings.Contains(config, "$("), config)
+			require.Equal(t, false, strings.Contains(config, "'"), config)
+		}
+
+		config, ok := project.Configs["Debug|iPhoneSimulator"]
+		require.Equal(t, true, ok)
+		require.Equal(t, filepath.Join(dir, "bin/iPhoneSimulator/Debug"), config.OutputDir)
+		require.Equal(t, []string{"x86_64"}, config.MtouchArchs)
+		require.Equal(t, false, config.BuildIpa)
+
+		config, ok = project.Configs["Release|iPhone"]
+		require.Equal(t, true, ok)
+		require.Equal(t, filepath.Join(dir, "bin/iPhone/Release"), config.OutputDir)
+		require.Equal(t, []string{"ARM64"}, config.MtouchArchs)
+		require.Equal(t, true, config.BuildIpa)
+
+		config, ok = project.Configs["Ad-Hoc|iPhone"]
+		require.Equal(t, true, ok)
+		require.Equal(t, filepath.Join(dir, "bin/Distribution/Ad-Hoc"), config.OutputDir)
+		require.Equal(t, true, config.BuildIpa)
+
+		config, ok = project.Configs["AppStore|iPhone"]
+		require.Equal(t, true, ok)
+		require.Equal(t, filepath.Join(dir, "bin/Distribution/AppStore"), config.OutputDir)
+
+		_, ok = project.Configs["Debug|iPhone"]
+		require.Equal(t, false, ok)
+
+		_, ok = project.Configs["Release|iPhoneSimulator"]
+		require.Equal(t, false, ok)
+	}
+}
+
 func androidTest(t *testing.T, contentPth string) {
 	pth := tmpProjectWithContent(t, contentPth)
 	defer func() {
@@ -445,7 +618,7 @@ func androidTest(t *testing.T, contentPth string) {
 	fileName := filepath.Base(pth)
 	fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
 
-	project, err := analyzeProject(pth)
+	project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
 	require.NoError(t, err)
 
 	require.Equal(t, pth, project.Pth)
@@ -481,3 +654,71 @@ func androidTest(t *testing.T, contentPth string) {
 	require.Equal(t, false, config.BuildIpa)
 	require.Equal(t, true, config.SignAndroid)
 }
+
+func TestAnalyzeProjectWithImports(t *testing.T) {
+	tmpDir, pth := tmpImportingProject(t)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	t.Log("it uses the properties of the imported files")
+	{
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+		require.Equal(t, constants.SDKAndroid, project.SDK)
+		require.Equal(t, 3, len(project.Imports))
+		require.Equal(t, filepath.Join(tmpDir, "Directory.Build.props"), project.Imports[0].Pth)
+
+		debugConfig := project.Configs["Debug|AnyCPU"]
+		require.Equal(t, filepath.Join(tmpDir, "artifacts", "Droid", "Debug"), debugConfig.OutputDir)
+		require.Equal(t, false, debugConfig.SignAndroid)
+		require.Equal(t, "aab", debugConfig.AndroidPackageFormat)
+
+		releaseConfig := project.Configs["Release|AnyCPU"]
+		require.Equal(t, filepath.Join(tmpDir, "artifacts", "Droid", "Release"), releaseConfig.OutputDir)
+		require.Equal(t, true, releaseConfig.SignAndroid)
+		require.Equal(t, "apk", releaseConfig.AndroidPackageFormat)
+	}
+}
+
+func TestGetResolvedTestFramework(t *testing.T) {
+	t.Log("it detects the test framework from assembly and package references")
+	{
+		for expected, references := range map[constants.TestFramework][]string{
+			constants.TestFrameworkNunitTest:     {"System", "nunit.framework, Version=3.6.0.0, Culture=neutral, PublicKeyToken=2638cd05610744eb"},
+			constants.TestFrameworkXamarinUITest: {"NUnit", "Xamarin.UITest"},
+			constants.TestFrameworkNunitLiteTest: {"MonoTouch.NUnitLite"},
+			constants.TestFrameworkXunit:         {"Microsoft.NET.Test.Sdk", "xunit", "xunit.runner.visualstudio"},
+			constants.TestFrameworkMSTest:        {"Microsoft.NET.Test.Sdk", "MSTest.TestAdapter", "MSTest.TestFramework"},
+		} {
+			testFramework, err := GetResolvedTestFramework(references)
+			require.NoError(t, err)
+			require.Equal(t, expected, testFramework)
+		}
+
+		testFramework, err := GetResolvedTestFramework([]string{"System", "Newtonsoft.Json"})
+		require.Error(t, err)
+		require.Equal(t, constants.TestFrameworkUnknown, testFramework)
+	}
+
+	t.Log("it detects the test framework of SDK-style test projects")
+	{
+		pth := tmpProjectWithContent(t, `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Microsoft.NET.Test.Sdk" Version="17.8.0" />
+    <PackageReference Include="xunit" Version="2.6.2" />
+  </ItemGroup>
+</Project>
+`)
+		defer func() {
+			require.NoError(t, os.Remove(pth))
+		}()
+
+		project, err := analyzeProject(pth, nil, utility.OSFileSystem, nil)
+		require.NoError(t, err)
+		require.Equal(t, constants.TestFrameworkXunit, project.TestFramework)
+	}
+}
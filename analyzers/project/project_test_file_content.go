@@ -728,3 +728,208 @@ const nunitLiteTestProjectContent = `<?xml version="1.0" encoding="utf-8"?>
   </ItemGroup>
   <Import Project="$(MSBuildExtensionsPath)\Xamarin\iOS\Xamarin.iOS.CSharp.targets" />
 </Project>`
+
+const sdkStyleAndroidTestProjectContent = `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0-android</TargetFramework>
+    <SupportedOSPlatformVersion>21</SupportedOSPlatformVersion>
+    <OutputType>Exe</OutputType>
+    <Nullable>enable</Nullable>
+    <ApplicationId>com.bitrise.creditcardvalidator</ApplicationId>
+    <ApplicationVersion>1</ApplicationVersion>
+    <ApplicationDisplayVersion>1.0</ApplicationDisplayVersion>
+  </PropertyGroup>
+  <PropertyGroup Condition=" '$(Configuration)|$(Platform)' == 'Release|AnyCPU' ">
+    <AndroidKeyStore>True</AndroidKeyStore>
+    <AndroidPackageFormat>aab</AndroidPackageFormat>
+  </PropertyGroup>
+  <ItemGroup>
+    <ProjectReference Include="..\CreditCardValidator\CreditCardValidator.csproj" />
+  </ItemGroup>
+</Project>
+`
+
+const sdkStyleMauiTestProjectContent = `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFrameworks>net8.0-ios;net8.0-android;net8.0-maccatalyst</TargetFrameworks>
+    <TargetFrameworks Condition="$([MSBuild]::IsOSPlatform('windows'))">$(TargetFrameworks);net8.0-windows10.0.19041.0</TargetFrameworks>
+    <OutputType>Exe</OutputType>
+    <RootNamespace>MauiApp</RootNamespace>
+    <UseMaui>true</UseMaui>
+    <SingleProject>true</SingleProject>
+    <ApplicationTitle>MauiApp</ApplicationTitle>
+    <ApplicationId>com.bitrise.mauiapp</ApplicationId>
+  </PropertyGroup>
+  <PropertyGroup Condition="'$(TargetFramework)' == 'net8.0-android' And '$(Configuration)' == 'Release'">
+    <AndroidPackageFormat>aab</AndroidPackageFormat>
+  </PropertyGroup>
+</Project>
+`
+
+const sdkStyleLibraryTestProjectContent = `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>netstandard2.0</TargetFramework>
+  </PropertyGroup>
+</Project>
+`
+
+const complexConditionsTestProjectContent = `<?xml version="1.0" encoding="utf-8"?>
+<Project DefaultTargets="Build" ToolsVersion="4.0" xmlns="http://schemas.microsoft.com/developer/msbuild/2003">
+  <PropertyGroup>
+    <Configuration Condition=" '$(Configuration)' == '' ">Debug</Configuration>
+    <Platform Condition=" '$(Platform)' == '' ">iPhoneSimulator</Platform>
+    <ProjectTypeGuids>{FEACFBD2-3405-455C-9665-78FE426C6842};{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}</ProjectTypeGuids>
+    <ProjectGuid>{90F3C584-FD69-4926-9903-6B9771847782}</ProjectGuid>
+    <OutputType>Exe</OutputType>
+    <AssemblyName>CreditCardValidator.iOS</AssemblyName>
+    <OutputPath>bin\$(Platform)\$(Configuration)</OutputPath>
+  </PropertyGroup>
+  <PropertyGroup Condition="'$(Configuration)'=='Debug'   And   '$(Platform)'=='iPhoneSimulator'">
+    <MtouchArch>x86_64</MtouchArch>
+  </PropertyGroup>
+  <PropertyGroup Condition=" '$(Configuration)' == 'Release' and '$(Platform)' == 'iPhone' ">
+    <MtouchArch>ARM64</MtouchArch>
+    <BuildIpa>true</BuildIpa>
+  </PropertyGroup>
+  <PropertyGroup Condition=" '$(Configuration)|$(Platform)' == 'Ad-Hoc|iPhone' Or '$(Configuration)|$(Platform)' == 'AppStore|iPhone' ">
+    <MtouchArch>ARM64</MtouchArch>
+    <OutputPath>bin\Distribution\$(Configuration)</OutputPath>
+  </PropertyGroup>
+  <PropertyGroup Condition=" '$(Configuration)' != 'Debug' And !Exists('$(MSBuildProjectDirectory)\does-not-exist.props') ">
+    <BuildIpa>true</BuildIpa>
+  </PropertyGroup>
+  <PropertyGroup Condition=" Exists('Local.props') ">
+    <OutputPath>local</OutputPath>
+  </PropertyGroup>
+</Project>
+`
+
+const propertyEvaluationTestProjectContent = `<?xml version="1.0" encoding="utf-8"?>
+<Project DefaultTargets="Build" ToolsVersion="4.0" xmlns="http://schemas.microsoft.com/developer/msbuild/2003">
+  <PropertyGroup>
+    <Configuration Condition=" '$(Configuration)' == '' ">Debug</Configuration>
+    <Platform Condition=" '$(Platform)' == '' ">AnyCPU</Platform>
+    <ProjectTypeGuids>{EFBA0AD7-5A72-4C68-AF49-83D382785DCF};{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}</ProjectTypeGuids>
+    <ProjectGuid>{90F3C584-FD69-4926-9903-6B9771847782}</ProjectGuid>
+    <OutputType>Library</OutputType>
+    <AppName>CreditCardValidator</AppName>
+    <AssemblyName>$(AppName).Droid</AssemblyName>
+    <AndroidApplication>True</AndroidApplication>
+    <BaseOutputDir Condition=" '$(SolutionDir)' != '' ">$(SolutionDir)artifacts\$(MSBuildProjectName)</BaseOutputDir>
+    <BaseOutputDir Condition=" '$(BaseOutputDir)' == '' ">bin</BaseOutputDir>
+  </PropertyGroup>
+  <PropertyGroup Condition=" '$(Configuration)|$(Platform)' == 'Debug|AnyCPU' ">
+    <OutputPath>$(BaseOutputDir)\$(Configuration.ToLower())</OutputPath>
+  </PropertyGroup>
+  <PropertyGroup Condition=" '$(Configuration)|$(Platform)' == 'Release|AnyCPU' ">
+    <OutputPath>$(BaseOutputDir)\$(Configuration)</OutputPath>
+    <AppName>Overridden</AppName>
+    <IsMac>$([MSBuild]::IsOSPlatform('osx'))</IsMac>
+  </PropertyGroup>
+</Project>
+`
+
+const directoryBuildPropsTestContent = `<Project>
+  <PropertyGroup>
+    <BaseOutputPath>$(MSBuildThisFileDirectory)artifacts\$(MSBuildProjectName)</BaseOutputPath>
+    <AndroidPackageFormat>aab</AndroidPackageFormat>
+  </PropertyGroup>
+  <Import Project="$(MSBuildThisFileDirectory)build\signing.props" Condition="Exists('$(MSBuildThisFileDirectory)build\signing.props')" />
+  <Import Project="$(MSBuildThisFileDirectory)build\missing.props" Condition="Exists('$(MSBuildThisFileDirectory)build\missing.props')" />
+</Project>
+`
+
+const signingPropsTestContent = `<Project>
+  <PropertyGroup Condition=" '$(Configuration)' == 'Release' ">
+    <AndroidKeyStore>True</AndroidKeyStore>
+  </PropertyGroup>
+</Project>
+`
+
+const directoryBuildTargetsTestContent = `<Project>
+  <PropertyGroup>
+    <ImportedTargets>true</ImportedTargets>
+  </PropertyGroup>
+</Project>
+`
+
+const importingAndroidTestProjectContent = `<?xml version="1.0" encoding="utf-8"?>
+<Project DefaultTargets="Build" ToolsVersion="4.0" xmlns="http://schemas.microsoft.com/developer/msbuild/2003">
+  <Import Project="$(MSBuildExtensionsPath)\$(MSBuildToolsVersion)\Microsoft.Common.props" Condition="Exists('$(MSBuildExtensionsPath)\$(MSBuildToolsVersion)\Microsoft.Common.props')" />
+  <PropertyGroup>
+    <Configuration Condition=" '$(Configuration)' == '' ">Debug</Configuration>
+    <Platform Condition=" '$(Platform)' == '' ">AnyCPU</Platform>
+    <ProjectTypeGuids>{EFBA0AD7-5A72-4C68-AF49-83D382785DCF};{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}</ProjectTypeGuids>
+    <ProjectGuid>{90F3C584-FD69-4926-9903-6B9771847782}</ProjectGuid>
+    <OutputType>Library</OutputType>
+    <AssemblyName>CreditCardValidator.Droid</AssemblyName>
+    <AndroidApplication>True</AndroidApplication>
+  </PropertyGroup>
+  <PropertyGroup Condition=" '$(Configuration)|$(Platform)' == 'Debug|AnyCPU' ">
+    <OutputPath>$(BaseOutputPath)\$(Configuration)</OutputPath>
+  </PropertyGroup>
+  <PropertyGroup Condition=" '$(Configuration)|$(Platform)' == 'Release|AnyCPU' ">
+    <OutputPath>$(BaseOutputPath)\$(Configuration)</OutputPath>
+  </PropertyGroup>
+  <ImportGroup Condition=" '$(Configuration)' == 'Release' ">
+    <Import Project="$(MSBuildProjectDirectory)\release.props" />
+  </ImportGroup>
+  <Import Project="$(MSBuildExtensionsPath)\Xamarin\Android\Xamarin.Android.CSharp.targets" />
+</Project>
+`
+
+const releasePropsTestContent = `<Project>
+  <PropertyGroup>
+    <AndroidPackageFormat>apk</AndroidPackageFormat>
+  </PropertyGroup>
+</Project>
+`
+
+const directoryPackagesPropsTestContent = `<Project>
+  <PropertyGroup>
+    <ManagePackageVersionsCentrally>true</ManagePackageVersionsCentrally>
+    <EssentialsVersion>1.8.0</EssentialsVersion>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageVersion Include="Newtonsoft.Json" Version="13.0.3" />
+    <PackageVersion Include="Xamarin.Essentials" Version="$(EssentialsVersion)" />
+  </ItemGroup>
+</Project>
+`
+
+const packageReferenceDirectoryBuildPropsTestContent = `<Project>
+  <ItemGroup>
+    <PackageReference Include="StyleCop.Analyzers" Version="1.1.118" PrivateAssets="all" />
+  </ItemGroup>
+</Project>
+`
+
+const packageReferenceTestProjectContent = `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0-android</TargetFramework>
+    <OutputType>Exe</OutputType>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" />
+    <PackageReference Include="xamarin.essentials" />
+    <PackageReference Include="NUnit">
+      <Version>3.13.3</Version>
+    </PackageReference>
+    <PackageReference Include="Serilog" VersionOverride="3.1.1" />
+  </ItemGroup>
+  <ItemGroup Condition=" '$(Configuration)' == 'Debug' ">
+    <PackageReference Include="Debug.Only" Version="1.0.0" />
+  </ItemGroup>
+  <ItemGroup>
+    <PackageReference Update="NUnit" Version="3.14.0" />
+  </ItemGroup>
+</Project>
+`
+
+const packagesConfigTestContent = `<?xml version="1.0" encoding="utf-8"?>
+<packages>
+  <package id="Newtonsoft.Json" version="12.0.1" targetFramework="monoandroid90" />
+  <package id="Xamarin.Forms" version="5.0.0.2612" targetFramework="monoandroid90" />
+  <package id="NuGet.Build.Tasks.Pack" version="6.0.0" developmentDependency="true" />
+</packages>
+`
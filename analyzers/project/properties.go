@@ -0,0 +1,574 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// ExpandProperties replaces the $(Name) property references and the well-known property functions,
+// like $([MSBuild]::IsOSPlatform('osx')) or $(Name.ToLower()), in the given value.
+// Undefined properties, unsupported property functions, item lists (@(...)) and item metadata (%(...)) are expanded to empty string.
+func ExpandProperties(value string, properties map[string]string) string {
+	return expandProperties(value, properties, utility.OSFileSystem)
+}
+
+func expandProperties(value string, properties map[string]string, fileSystem utility.FileSystem) string {
+	if !strings.ContainsAny(value, "$@%") {
+		return value
+	}
+
+	var expanded strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c == '$' || c == '@' || c == '%') && i+1 < len(value) && value[i+1] == '(' {
+			end, err := scanParens(value, i+1)
+			if err != nil {
+				// unterminated reference, keep it as it is
+				expanded.WriteString(value[i:])
+				break
+			}
+
+			if c == '$' {
+				propertyValue, err := evaluatePropertyExpression(value[i+2:end], properties, fileSystem)
+				if err != nil {
+					debugParseLog(fmt.Errorf("failed to expand %s: %s", value[i:end+1], err))
+				}
+				expanded.WriteString(propertyValue)
+			}
+
+			i = end
+			continue
+		}
+		expanded.WriteByte(c)
+	}
+	return expanded.String()
+}
+
+// lookupProperty returns the value of the given property, MSBuild property names are case insensitive.
+func lookupProperty(properties map[string]string, name string) string {
+	value, _ := lookupPropertyOK(properties, name)
+	return value
+}
+
+func lookupPropertyOK(properties map[string]string, name string) (string, bool) {
+	if value, ok := properties[name]; ok {
+		return value, true
+	}
+	for key, value := range properties {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+//
+// Property functions
+
+// evaluatePropertyExpression evaluates the content of a $(...) reference:
+// Name, Name.Method(args), [Type]::Method(args).Method(args) or [Type]::Property
+func evaluatePropertyExpression(expression string, properties map[string]string, fileSystem utility.FileSystem) (string, error) {
+	scanner := propertyExpressionScanner{expression: strings.TrimSpace(expression)}
+
+	var value string
+	if scanner.peek() == '[' {
+		typeName, member, arguments, err := scanner.scanStaticCall(properties, fileSystem)
+		if err != nil {
+			return "", err
+		}
+		value, err = callStaticFunction(typeName, member, arguments, properties, fileSystem)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		name := scanner.scanIdentifier()
+		if name == "" {
+			return "", fmt.Errorf("invalid property expression: %s", expression)
+		}
+		value = lookupProperty(properties, name)
+	}
+
+	for !scanner.done() {
+		if scanner.peek() != '.' {
+			return "", fmt.Errorf("unexpected character (%c) in: %s", scanner.peek(), expression)
+		}
+		scanner.pos++
+
+		method := scanner.scanIdentifier()
+		arguments, hasArguments, err := scanner.scanArguments(properties, fileSystem)
+		if err != nil {
+			return "", err
+		}
+
+		value, err = callStringMethod(value, method, arguments, hasArguments)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return value, nil
+}
+
+type propertyExpressionScanner struct {
+	expression string
+	pos        int
+}
+
+func (scanner *propertyExpressionScanner) done() bool {
+	return scanner.pos >= len(scanner.expression)
+}
+
+func (scanner *propertyExpressionScanner) peek() byte {
+	if scanner.done() {
+		return 0
+	}
+	return scanner.expression[scanner.pos]
+}
+
+func (scanner *propertyExpressionScanner) skipSpaces() {
+	for !scanner.done() && (scanner.peek() == ' ' || scanner.peek() == '\t') {
+		scanner.pos++
+	}
+}
+
+func (scanner *propertyExpressionScanner) scanIdentifier() string {
+	scanner.skipSpaces()
+	start := scanner.pos
+	for !scanner.done() {
+		c := scanner.peek()
+		if !(c == '_' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			break
+		}
+		scanner.pos++
+	}
+	identifier := scanner.expression[start:scanner.pos]
+	scanner.skipSpaces()
+	return identifier
+}
+
+// scanStaticCall scans: [Type]::Member(args) or [Type]::Member
+func (scanner *propertyExpressionScanner) scanStaticCall(properties map[string]string, fileSystem utility.FileSystem) (string, string, []string, error) {
+	end := strings.Index(scanner.expression[scanner.pos:], "]")
+	if end == -1 {
+		return "", "", nil, fmt.Errorf("unterminated type name in: %s", scanner.expression)
+	}
+	typeName := strings.TrimSpace(scanner.expression[scanner.pos+1 : scanner.pos+end])
+	scanner.pos += end + 1
+
+	if !strings.HasPrefix(scanner.expression[scanner.pos:], "::") {
+		return "", "", nil, fmt.Errorf("expected :: after type name in: %s", scanner.expression)
+	}
+	scanner.pos += 2
+
+	member := scanner.scanIdentifier()
+	arguments, _, err := scanner.scanArguments(properties, fileSystem)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return typeName, member, arguments, nil
+}
+
+// scanArguments scans an optional argument list: ('a', $(B), 1)
+func (scanner *propertyExpressionScanner) scanArguments(properties map[string]string, fileSystem utility.FileSystem) ([]string, bool, error) {
+	scanner.skipSpaces()
+	if scanner.peek() != '(' {
+		return nil, false, nil
+	}
+
+	end, err := scanParens(scanner.expression, scanner.pos)
+	if err != nil {
+		return nil, false, err
+	}
+	content := scanner.expression[scanner.pos+1 : end]
+	scanner.pos = end + 1
+	scanner.skipSpaces()
+
+	var arguments []string
+	for _, argument := range splitArguments(content) {
+		arguments = append(arguments, evaluateArgument(argument, properties, fileSystem))
+	}
+	return arguments, true, nil
+}
+
+// splitArguments splits the argument list at the top level commas.
+func splitArguments(content string) []string {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+
+	var arguments []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			arguments = append(arguments, content[start:i])
+			start = i + 1
+		}
+	}
+	return append(arguments, content[start:])
+}
+
+func evaluateArgument(argument string, properties map[string]string, fileSystem utility.FileSystem) string {
+	argument = strings.TrimSpace(argument)
+	if len(argument) >= 2 {
+		first, last := argument[0], argument[len(argument)-1]
+		if first == last && (first == '\'' || first == '"' || first == '`') {
+			argument = argument[1 : len(argument)-1]
+		}
+	}
+	return expandProperties(argument, properties, fileSystem)
+}
+
+func callStaticFunction(typeName, member string, arguments []string, properties map[string]string, fileSystem utility.FileSystem) (string, error) {
+	argument := func(i int) string {
+		if i < len(arguments) {
+			return arguments[i]
+		}
+		return ""
+	}
+	projectDir := lookupProperty(properties, "MSBuildProjectDirectory")
+
+	switch strings.ToLower(typeName) {
+	case "msbuild":
+		switch strings.ToLower(member) {
+		case "isosplatform":
+			return formatBool(isOSPlatform(argument(0))), nil
+		case "isosunixlike":
+			return formatBool(runtime.GOOS != "windows"), nil
+		case "gettargetplatformidentifier":
+			platform, _ := splitTargetPlatform(argument(0))
+			return platform, nil
+		case "gettargetplatformversion":
+			_, version := splitTargetPlatform(argument(0))
+			return version, nil
+		case "gettargetframeworkidentifier":
+			return targetFrameworkIdentifier(argument(0)), nil
+		case "valueordefault":
+			if argument(0) != "" {
+				return argument(0), nil
+			}
+			return argument(1), nil
+		case "ensuretrailingslash":
+			return ensureTrailingSlash(utility.FixWindowsPath(argument(0))), nil
+		case "normalizepath":
+			return resolvePropertyFunctionPath(projectDir, arguments...), nil
+		case "normalizedirectory":
+			return ensureTrailingSlash(resolvePropertyFunctionPath(projectDir, arguments...)), nil
+		case "getdirectorynameoffileabove":
+			dir, err := findFileAbove(resolvePropertyFunctionPath(projectDir, argument(0)), argument(1), fileSystem)
+			if err != nil || dir == "" {
+				return "", err
+			}
+			return dir, nil
+		case "getpathoffileabove":
+			startDir := projectDir
+			if argument(1) != "" {
+				startDir = resolvePropertyFunctionPath(projectDir, argument(1))
+			}
+			dir, err := findFileAbove(startDir, argument(0), fileSystem)
+			if err != nil || dir == "" {
+				return "", err
+			}
+			return filepath.Join(dir, argument(0)), nil
+		case "add", "subtract", "multiply", "divide":
+			return arithmetic(member, argument(0), argument(1))
+		case "versionequals", "versionnotequals", "versiongreaterthan", "versiongreaterthanorequals", "versionlessthan", "versionlessthanorequals":
+			return compareVersionFunction(member, argument(0), argument(1))
+		}
+	case "system.io.path":
+		switch strings.ToLower(member) {
+		case "combine":
+			var parts []string
+			for _, part := range arguments {
+				parts = append(parts, utility.FixWindowsPath(part))
+			}
+			return filepath.Join(parts...), nil
+		case "getfullpath":
+			return resolvePropertyFunctionPath(projectDir, argument(0)), nil
+		case "getdirectoryname":
+			return filepath.Dir(utility.FixWindowsPath(argument(0))), nil
+		case "getfilename":
+			return filepath.Base(utility.FixWindowsPath(argument(0))), nil
+		case "getfilenamewithoutextension":
+			name := filepath.Base(utility.FixWindowsPath(argument(0)))
+			return strings.TrimSuffix(name, filepath.Ext(name)), nil
+		case "getextension":
+			return filepath.Ext(utility.FixWindowsPath(argument(0))), nil
+		case "directoryseparatorchar":
+			return string(filepath.Separator), nil
+		}
+	case "system.io.file":
+		if strings.EqualFold(member, "Exists") {
+			exist, err := fileSystem.IsPathExists(resolvePropertyFunctionPath(projectDir, argument(0)))
+			return formatBool(exist), err
+		}
+	case "system.io.directory":
+		if strings.EqualFold(member, "Exists") {
+			exist, err := fileSystem.IsDirExists(resolvePropertyFunctionPath(projectDir, argument(0)))
+			return formatBool(exist), err
+		}
+	case "system.string":
+		switch strings.ToLower(member) {
+		case "copy":
+			return argument(0), nil
+		case "isnullorempty":
+			return formatBool(argument(0) == ""), nil
+		case "isnullorwhitespace":
+			return formatBool(strings.TrimSpace(argument(0)) == ""), nil
+		case "concat":
+			return strings.Join(arguments, ""), nil
+		case "join":
+			if len(arguments) == 0 {
+				return "", nil
+			}
+			return strings.Join(arguments[1:], argument(0)), nil
+		}
+	case "system.environment":
+		if strings.EqualFold(member, "GetEnvironmentVariable") {
+			return os.Getenv(argument(0)), nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported property function: [%s]::%s", typeName, member)
+}
+
+func callStringMethod(value, method string, arguments []string, hasArguments bool) (string, error) {
+	argument := func(i int) string {
+		if i < len(arguments) {
+			return arguments[i]
+		}
+		return ""
+	}
+
+	switch strings.ToLower(method) {
+	case "length":
+		if !hasArguments {
+			return strconv.Itoa(len(value)), nil
+		}
+	case "tolower", "tolowerinvariant":
+		return strings.ToLower(value), nil
+	case "toupper", "toupperinvariant":
+		return strings.ToUpper(value), nil
+	case "trim":
+		if len(arguments) > 0 {
+			return strings.Trim(value, strings.Join(arguments, "")), nil
+		}
+		return strings.TrimSpace(value), nil
+	case "trimstart":
+		if len(arguments) > 0 {
+			return strings.TrimLeft(value, strings.Join(arguments, "")), nil
+		}
+		return strings.TrimLeft(value, " \t\r\n"), nil
+	case "trimend":
+		if len(arguments) > 0 {
+			return strings.TrimRight(value, strings.Join(arguments, "")), nil
+		}
+		return strings.TrimRight(value, " \t\r\n"), nil
+	case "replace":
+		return strings.Replace(value, argument(0), argument(1), -1), nil
+	case "contains":
+		return formatBool(strings.Contains(value, argument(0))), nil
+	case "startswith":
+		return formatBool(strings.HasPrefix(value, argument(0))), nil
+	case "endswith":
+		return formatBool(strings.HasSuffix(value, argument(0))), nil
+	case "equals":
+		return formatBool(value == argument(0)), nil
+	case "indexof":
+		return strconv.Itoa(strings.Index(value, argument(0))), nil
+	case "lastindexof":
+		return strconv.Itoa(strings.LastIndex(value, argument(0))), nil
+	case "substring":
+		start, err := strconv.Atoi(strings.TrimSpace(argument(0)))
+		if err != nil || start < 0 || start > len(value) {
+			return "", fmt.Errorf("invalid Substring start index: %s", argument(0))
+		}
+		if len(arguments) < 2 {
+			return value[start:], nil
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(argument(1)))
+		if err != nil || length < 0 || start+length > len(value) {
+			return "", fmt.Errorf("invalid Substring length: %s", argument(1))
+		}
+		return value[start : start+length], nil
+	}
+
+	return "", fmt.Errorf("unsupported string method: %s", method)
+}
+
+// isOSPlatform maps the .NET OS platform names to the current GOOS.
+func isOSPlatform(platform string) bool {
+	switch strings.ToLower(platform) {
+	case "osx", "macos":
+		return runtime.GOOS == "darwin"
+	case "linux":
+		return runtime.GOOS == "linux"
+	case "windows":
+		return runtime.GOOS == "windows"
+	case "freebsd":
+		return runtime.GOOS == "freebsd"
+	default:
+		return false
+	}
+}
+
+// splitTargetPlatform splits the platform part of a target framework: net8.0-ios17.0 -> ios, 17.0
+func splitTargetPlatform(targetFramework string) (string, string) {
+	idx := strings.Index(targetFramework, "-")
+	if idx == -1 {
+		return "", ""
+	}
+	platform := targetFramework[idx+1:]
+	versionIdx := strings.IndexAny(platform, "0123456789")
+	if versionIdx == -1 {
+		return platform, ""
+	}
+	return platform[:versionIdx], platform[versionIdx:]
+}
+
+func targetFrameworkIdentifier(targetFramework string) string {
+	tfm := strings.ToLower(strings.TrimSpace(targetFramework))
+	switch {
+	case strings.HasPrefix(tfm, "netstandard"):
+		return ".NETStandard"
+	case strings.HasPrefix(tfm, "netcoreapp"):
+		return ".NETCoreApp"
+	case strings.HasPrefix(tfm, "net") && strings.Contains(tfm, "."):
+		return ".NETCoreApp"
+	case strings.HasPrefix(tfm, "net"):
+		return ".NETFramework"
+	case strings.HasPrefix(tfm, "monoandroid"):
+		return "MonoAndroid"
+	case strings.HasPrefix(tfm, "xamarinios"), strings.HasPrefix(tfm, "xamarin.ios"):
+		return "Xamarin.iOS"
+	default:
+		return ""
+	}
+}
+
+func resolvePropertyFunctionPath(baseDir string, parts ...string) string {
+	var fixedParts []string
+	for _, part := range parts {
+		fixedParts = append(fixedParts, utility.FixWindowsPath(part))
+	}
+	pth := filepath.Join(fixedParts...)
+	if !filepath.IsAbs(pth) && baseDir != "" {
+		pth = filepath.Join(baseDir, pth)
+	}
+	return filepath.Clean(pth)
+}
+
+// findFileAbove returns the first directory, starting with the given one and walking up, which contains the given file.
+func findFileAbove(startDir, fileName string, fileSystem utility.FileSystem) (string, error) {
+	if fileName == "" || startDir == "" {
+		return "", nil
+	}
+
+	dir := filepath.Clean(startDir)
+	for {
+		if exist, err := fileSystem.IsPathExists(filepath.Join(dir, fileName)); err != nil {
+			return "", err
+		} else if exist {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func arithmetic(operation, left, right string) (string, error) {
+	l, err := strconv.ParseFloat(strings.TrimSpace(left), 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid number: %s", left)
+	}
+	r, err := strconv.ParseFloat(strings.TrimSpace(right), 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid number: %s", right)
+	}
+
+	var result float64
+	switch strings.ToLower(operation) {
+	case "add":
+		result = l + r
+	case "subtract":
+		result = l - r
+	case "multiply":
+		result = l * r
+	case "divide":
+		if r == 0 {
+			return "", fmt.Errorf("division by zero")
+		}
+		result = l / r
+	}
+	return strconv.FormatFloat(result, 'f', -1, 64), nil
+}
+
+func compareVersionFunction(function, left, right string) (string, error) {
+	leftVersion, ok := parseFunctionVersion(left)
+	if !ok {
+		return "", fmt.Errorf("invalid version: %s", left)
+	}
+	rightVersion, ok := parseFunctionVersion(right)
+	if !ok {
+		return "", fmt.Errorf("invalid version: %s", right)
+	}
+
+	cmp := compareVersions(leftVersion, rightVersion)
+	switch strings.ToLower(function) {
+	case "versionequals":
+		return formatBool(cmp == 0), nil
+	case "versionnotequals":
+		return formatBool(cmp != 0), nil
+	case "versiongreaterthan":
+		return formatBool(cmp > 0), nil
+	case "versiongreaterthanorequals":
+		return formatBool(cmp >= 0), nil
+	case "versionlessthan":
+		return formatBool(cmp < 0), nil
+	default:
+		return formatBool(cmp <= 0), nil
+	}
+}
+
+func parseFunctionVersion(value string) ([]int, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	if version, ok := parseConditionVersion(value); ok {
+		return version, true
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, false
+	}
+	return []int{number}, true
+}
+
+// formatBool formats the given bool like .NET does.
+func formatBool(value bool) string {
+	if value {
+		return "True"
+	}
+	return "False"
+}
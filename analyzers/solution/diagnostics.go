@@ -0,0 +1,63 @@
+package solution
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Severity ...
+type Severity string
+
+const (
+	// SeverityError ...
+	SeverityError Severity = "error"
+	// SeverityWarning ...
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found while analyzing the solution, like a malformed line or a project which failed to parse.
+type Diagnostic struct {
+	Pth      string // The solution file the diagnostic refers to
+	Line     int    // The 1-based line number in the solution file, 0 if unknown
+	Severity Severity
+	Message  string
+}
+
+// String returns the diagnostic in MSBuild's canonical format, like solution.sln(12): warning: message.
+func (diagnostic Diagnostic) String() string {
+	location := diagnostic.Pth
+	if diagnostic.Line > 0 {
+		location += "(" + strconv.Itoa(diagnostic.Line) + ")"
+	}
+	return fmt.Sprintf("%s: %s: %s", location, diagnostic.Severity, diagnostic.Message)
+}
+
+// Errors returns the error diagnostics of the solution analysis.
+func (solution Model) Errors() []Diagnostic {
+	return solution.filterDiagnostics(SeverityError)
+}
+
+// Warnings returns the warning diagnostics of the solution analysis.
+func (solution Model) Warnings() []Diagnostic {
+	return solution.filterDiagnostics(SeverityWarning)
+}
+
+func (solution Model) filterDiagnostics(severity Severity) []Diagnostic {
+	filtered := []Diagnostic{}
+	for _, diagnostic := range solution.Diagnostics {
+		if diagnostic.Severity == severity {
+			filtered = append(filtered, diagnostic)
+		}
+	}
+	return filtered
+}
+
+// addDiagnostic adds a diagnostic referring to the given line of the solution file.
+func (solution *Model) addDiagnostic(line int, severity Severity, format string, args ...interface{}) {
+	solution.Diagnostics = append(solution.Diagnostics, Diagnostic{
+		Pth:      solution.Pth,
+		Line:     line,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
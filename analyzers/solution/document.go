@@ -0,0 +1,284 @@
+package solution
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+)
+
+const byteOrderMark = "\ufeff"
+
+var (
+	documentSectionRegexp = regexp.MustCompile(`^GlobalSection\((?P<name>[^)]*)\) = (?P<type>\w+)$`)
+	documentGUIDRegexp    = regexp.MustCompile(`{([0-9A-Fa-f-]+)}`)
+)
+
+// Document is the lossless representation of a text based (.sln) solution file, it is used to write modified or derived solutions.
+// The content of the projects (like ProjectSection(ProjectDependencies)) and the global sections is kept as is.
+type Document struct {
+	Pth string // The solution file's path, the project paths are relative to its directory
+
+	ByteOrderMark bool
+	LineEnding    string
+
+	Header   []string // The lines before the first project, like the format version
+	Projects []DocumentProject
+	Sections []DocumentSection // The global sections in document order
+	Trailer  []string          // The lines after EndGlobal
+}
+
+// DocumentProject is a Project entry of the solution file, solution folders are project entries too.
+type DocumentProject struct {
+	TypeID string
+	Name   string
+	Path   string // The solution relative path as written in the solution file
+	ID     string
+
+	Lines []string // The lines between the Project and EndProject lines, as is
+}
+
+// DocumentSection is a global section of the solution file, like GlobalSection(ProjectConfigurationPlatforms) = postSolution.
+type DocumentSection struct {
+	Name  string
+	Type  string   // preSolution or postSolution
+	Lines []string // The section's entries, without indentation
+}
+
+// ParseDocumentContent parses the given solution file content.
+func ParseDocumentContent(content string) (Document, error) {
+	document := Document{LineEnding: "\n"}
+	if strings.HasPrefix(content, byteOrderMark) {
+		document.ByteOrderMark = true
+		content = strings.TrimPrefix(content, byteOrderMark)
+	}
+	if strings.Contains(content, "\r\n") {
+		document.LineEnding = "\r\n"
+	}
+
+	var project *DocumentProject
+	var section *DocumentSection
+	isGlobal := false
+	isEnded := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		rawLine := strings.TrimRight(scanner.Text(), "\r")
+		line := strings.TrimSpace(rawLine)
+
+		switch {
+		case isEnded:
+			document.Trailer = append(document.Trailer, rawLine)
+		case project != nil:
+			if line == "EndProject" {
+				document.Projects = append(document.Projects, *project)
+				project = nil
+			} else {
+				project.Lines = append(project.Lines, rawLine)
+			}
+		case section != nil:
+			if line == "EndGlobalSection" {
+				document.Sections = append(document.Sections, *section)
+				section = nil
+			} else if line != "" {
+				section.Lines = append(section.Lines, line)
+			}
+		case isGlobal:
+			if line == "EndGlobal" {
+				isGlobal = false
+				isEnded = true
+			} else if matches := documentSectionRegexp.FindStringSubmatch(line); len(matches) == 3 {
+				section = &DocumentSection{Name: matches[1], Type: matches[2]}
+			}
+		case line == "Global":
+			isGlobal = true
+		default:
+			if matches := solutionProjectsRegexp.FindStringSubmatch(line); len(matches) == 5 && strings.HasPrefix(line, "Project(") {
+				project = &DocumentProject{TypeID: matches[1], Name: matches[2], Path: matches[3], ID: matches[4]}
+			} else if len(document.Projects) == 0 {
+				document.Header = append(document.Header, rawLine)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Document{}, err
+	}
+
+	if project != nil {
+		return Document{}, fmt.Errorf("project (%s) is not terminated with EndProject", project.Name)
+	}
+	if section != nil {
+		return Document{}, fmt.Errorf("global section (%s) is not terminated with EndGlobalSection", section.Name)
+	}
+
+	return document, nil
+}
+
+// ParseDocument parses the solution file at the given path.
+func ParseDocument(pth string) (Document, error) {
+	content, err := fileutil.ReadStringFromFile(pth)
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to read solution (%s), error: %s", pth, err)
+	}
+
+	document, err := ParseDocumentContent(content)
+	if err != nil {
+		return Document{}, err
+	}
+	document.Pth = pth
+	return document, nil
+}
+
+// String returns the solution file content.
+func (document Document) String() string {
+	lineEnding := document.LineEnding
+	if lineEnding == "" {
+		lineEnding = "\n"
+	}
+
+	var builder strings.Builder
+	if document.ByteOrderMark {
+		builder.WriteString(byteOrderMark)
+	}
+
+	writeLine := func(line string) {
+		builder.WriteString(line)
+		builder.WriteString(lineEnding)
+	}
+
+	for _, line := range document.Header {
+		writeLine(line)
+	}
+
+	for _, project := range document.Projects {
+		writeLine(fmt.Sprintf(`Project("{%s}") = "%s", "%s", "{%s}"`, project.TypeID, project.Name, project.Path, project.ID))
+		for _, line := range project.Lines {
+			writeLine(line)
+		}
+		writeLine("EndProject")
+	}
+
+	writeLine("Global")
+	for _, section := range document.Sections {
+		writeLine(fmt.Sprintf("\tGlobalSection(%s) = %s", section.Name, section.Type))
+		for _, line := range section.Lines {
+			writeLine("\t\t" + line)
+		}
+		writeLine("\tEndGlobalSection")
+	}
+	writeLine("EndGlobal")
+
+	for _, line := range document.Trailer {
+		writeLine(line)
+	}
+
+	return builder.String()
+}
+
+// Write writes the solution file content to the given writer.
+func (document Document) Write(writer io.Writer) error {
+	_, err := io.WriteString(writer, document.String())
+	return err
+}
+
+// Section returns the global section with the given name.
+func (document Document) Section(name string) (DocumentSection, bool) {
+	for _, section := range document.Sections {
+		if section.Name == name {
+			return section, true
+		}
+	}
+	return DocumentSection{}, false
+}
+
+// Project returns the project entry with the given ID, the ID is case insensitive.
+func (document Document) Project(id string) (DocumentProject, bool) {
+	for _, project := range document.Projects {
+		if strings.EqualFold(project.ID, id) {
+			return project, true
+		}
+	}
+	return DocumentProject{}, false
+}
+
+// copy returns a deep copy of the document, to be modified without affecting the original document.
+func (document Document) copy() Document {
+	documentCopy := document
+	documentCopy.Header = append([]string{}, document.Header...)
+	documentCopy.Trailer = append([]string{}, document.Trailer...)
+
+	documentCopy.Projects = []DocumentProject{}
+	for _, project := range document.Projects {
+		project.Lines = append([]string{}, project.Lines...)
+		documentCopy.Projects = append(documentCopy.Projects, project)
+	}
+
+	documentCopy.Sections = []DocumentSection{}
+	for _, section := range document.Sections {
+		section.Lines = append([]string{}, section.Lines...)
+		documentCopy.Sections = append(documentCopy.Sections, section)
+	}
+
+	return documentCopy
+}
+
+// withoutProjects returns a copy of the document without the given project entries (upper case ID set),
+// the project and section lines referring to the removed projects (like configuration mappings, nesting
+// and project dependencies) are removed, everything else is kept as is.
+func (document Document) withoutProjects(removedIDs map[string]bool) Document {
+	refersRemoved := func(line string) bool {
+		for _, match := range documentGUIDRegexp.FindAllStringSubmatch(line, -1) {
+			if removedIDs[strings.ToUpper(match[1])] {
+				return true
+			}
+		}
+		return false
+	}
+
+	filtered := document.copy()
+
+	filtered.Projects = []DocumentProject{}
+	for _, project := range document.Projects {
+		if removedIDs[strings.ToUpper(project.ID)] {
+			continue
+		}
+
+		lines := []string{}
+		for _, line := range project.Lines {
+			if !refersRemoved(line) {
+				lines = append(lines, line)
+			}
+		}
+		project.Lines = lines
+
+		filtered.Projects = append(filtered.Projects, project)
+	}
+
+	for i, section := range filtered.Sections {
+		lines := []string{}
+		for _, line := range section.Lines {
+			if !refersRemoved(line) {
+				lines = append(lines, line)
+			}
+		}
+		filtered.Sections[i].Lines = lines
+	}
+
+	return filtered
+}
+
+// ensureSection returns the index of the global section with the given name, the section is added if it does not exist.
+func (document *Document) ensureSection(name, sectionType string) int {
+	for i, section := range document.Sections {
+		if section.Name == name {
+			return i
+		}
+	}
+
+	document.Sections = append(document.Sections, DocumentSection{Name: name, Type: sectionType})
+	return len(document.Sections) - 1
+}
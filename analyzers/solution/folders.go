@@ -0,0 +1,162 @@
+package solution
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SolutionFolderTypeID is the project type ID of the solution folders in .sln files.
+const SolutionFolderTypeID = "2150E333-8FDC-42A3-9474-1A3956D46DE8"
+
+// Folder is a solution folder, solution folders organize the solution's projects into a tree.
+type Folder struct {
+	ID       string
+	Name     string
+	Path     string // The folder's path in the solution tree, like Apps/Android
+	ParentID string // Empty for the root folders
+
+	FolderIDs  []string // The child folders' IDs
+	ProjectIDs []string // The IDs of the projects directly in the folder
+}
+
+// RootFolders returns the solution folders without parent folder, sorted by name.
+func (solution Model) RootFolders() []Folder {
+	folders := []Folder{}
+	for _, folder := range solution.FolderMap {
+		if folder.ParentID == "" {
+			folders = append(folders, folder)
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].Name < folders[j].Name })
+	return folders
+}
+
+// FolderByPath returns the solution folder with the given path (like Apps/Android), the path is case insensitive.
+func (solution Model) FolderByPath(pth string) (Folder, bool) {
+	pth = normalizeFolderPath(pth)
+	for _, folder := range solution.FolderMap {
+		if strings.EqualFold(folder.Path, pth) {
+			return folder, true
+		}
+	}
+	return Folder{}, false
+}
+
+// FolderPaths returns the paths of the solution folders, sorted.
+func (solution Model) FolderPaths() []string {
+	paths := []string{}
+	for _, folder := range solution.FolderMap {
+		paths = append(paths, folder.Path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ProjectIDsUnderFolder returns the IDs of the projects in the given solution folder and its sub folders, sorted.
+func (solution Model) ProjectIDsUnderFolder(pth string) ([]string, error) {
+	folder, ok := solution.FolderByPath(pth)
+	if !ok {
+		return nil, fmt.Errorf("solution folder (%s) not found, available: %v", pth, solution.FolderPaths())
+	}
+
+	projectIDs := []string{}
+	visited := map[string]bool{}
+
+	var collect func(folder Folder)
+	collect = func(folder Folder) {
+		if visited[folder.ID] {
+			return
+		}
+		visited[folder.ID] = true
+
+		projectIDs = append(projectIDs, folder.ProjectIDs...)
+		for _, folderID := range folder.FolderIDs {
+			collect(solution.FolderMap[folderID])
+		}
+	}
+	collect(folder)
+
+	sort.Strings(projectIDs)
+	return projectIDs, nil
+}
+
+// FolderContains returns true if the given solution folder path equals to or is under the given folder path.
+func FolderContains(folderPth, pth string) bool {
+	folderPth = normalizeFolderPath(folderPth)
+	pth = normalizeFolderPath(pth)
+	if folderPth == "" {
+		return true
+	}
+	return strings.EqualFold(pth, folderPth) || strings.HasPrefix(strings.ToLower(pth), strings.ToLower(folderPth)+"/")
+}
+
+func normalizeFolderPath(pth string) string {
+	return strings.Trim(strings.Replace(strings.TrimSpace(pth), `\`, "/", -1), "/")
+}
+
+// setFolders links the given solution folders and the solution's projects to their parent folder
+// (parentIDs is the child ID - parent folder ID map, defined by the NestedProjects section)
+// and sets the folder paths, including the projects' SolutionFolder.
+func (solution *Model) setFolders(folderMap map[string]Folder, parentIDs map[string]string) {
+	for folderID, folder := range folderMap {
+		if parentID, ok := parentIDs[folderID]; ok {
+			if _, found := folderMap[parentID]; found && parentID != folderID {
+				folder.ParentID = parentID
+			}
+		}
+		folder.FolderIDs = []string{}
+		folder.ProjectIDs = []string{}
+		folderMap[folderID] = folder
+	}
+
+	for folderID, folder := range folderMap {
+		if folder.ParentID == "" {
+			continue
+		}
+		parent := folderMap[folder.ParentID]
+		parent.FolderIDs = append(parent.FolderIDs, folderID)
+		folderMap[folder.ParentID] = parent
+	}
+
+	for projectID := range solution.ProjectMap {
+		parentID, ok := parentIDs[projectID]
+		if !ok {
+			continue
+		}
+		parent, found := folderMap[parentID]
+		if !found {
+			continue
+		}
+		parent.ProjectIDs = append(parent.ProjectIDs, projectID)
+		folderMap[parentID] = parent
+	}
+
+	for folderID, folder := range folderMap {
+		folder.Path = folderPath(folderMap, folderID)
+		sort.Strings(folder.FolderIDs)
+		sort.Strings(folder.ProjectIDs)
+		folderMap[folderID] = folder
+	}
+
+	for folderID, folder := range folderMap {
+		for _, projectID := range folder.ProjectIDs {
+			proj := solution.ProjectMap[projectID]
+			proj.SolutionFolder = folderMap[folderID].Path
+			solution.ProjectMap[projectID] = proj
+		}
+	}
+
+	solution.FolderMap = folderMap
+}
+
+// folderPath joins the names of the given folder and its ancestors, a nesting cycle ends the path.
+func folderPath(folderMap map[string]Folder, folderID string) string {
+	names := []string{}
+	visited := map[string]bool{}
+	for id := folderID; id != "" && !visited[id]; id = folderMap[id].ParentID {
+		visited[id] = true
+		names = append([]string{folderMap[id].Name}, names...)
+	}
+	return strings.Join(names, "/")
+}
@@ -0,0 +1,173 @@
+package solution
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+)
+
+// ProjectGraph is the build dependency graph of the solution's projects,
+// a project depends on its explicit solution dependencies (DependencyProjectIDs) and its project references:
+// the referenced project GUIDs (ReferredProjectIDs) and the referenced project paths (ReferredProjectPaths),
+// as the SDK-style project references do not contain the referenced project's GUID.
+type ProjectGraph struct {
+	projectMap   map[string]project.Model
+	dependencies map[string][]string // Project ID - dependency project IDs map, sorted by build order tie-breaker
+}
+
+// NewProjectGraph creates the build dependency graph of the given projects,
+// the dependencies not in the given project map are ignored.
+func NewProjectGraph(projectMap map[string]project.Model) ProjectGraph {
+	graph := ProjectGraph{
+		projectMap:   projectMap,
+		dependencies: map[string][]string{},
+	}
+
+	projectIDsByPath := map[string]string{}
+	for projectID, proj := range projectMap {
+		if proj.Pth != "" {
+			projectIDsByPath[filepath.Clean(proj.Pth)] = projectID
+		}
+	}
+
+	for projectID, proj := range projectMap {
+		candidateIDs := append(append([]string{}, proj.DependencyProjectIDs...), proj.ReferredProjectIDs...)
+		for _, referencePth := range proj.ReferredProjectPaths {
+			if referenceID, ok := projectIDsByPath[filepath.Clean(referencePth)]; ok {
+				candidateIDs = append(candidateIDs, referenceID)
+			}
+		}
+
+		dependencyIDs := []string{}
+		added := map[string]bool{}
+		for _, dependencyID := range candidateIDs {
+			dependencyID = strings.ToUpper(dependencyID)
+			if _, ok := projectMap[dependencyID]; !ok || added[dependencyID] || dependencyID == projectID {
+				continue
+			}
+			added[dependencyID] = true
+			dependencyIDs = append(dependencyIDs, dependencyID)
+		}
+		graph.sortIDs(dependencyIDs)
+
+		graph.dependencies[projectID] = dependencyIDs
+	}
+
+	return graph
+}
+
+// ProjectGraph returns the build dependency graph of the solution's projects.
+func (solution Model) ProjectGraph() ProjectGraph {
+	return NewProjectGraph(solution.ProjectMap)
+}
+
+// Dependencies returns the IDs of the projects the given project directly depends on.
+func (graph ProjectGraph) Dependencies(projectID string) []string {
+	return append([]string{}, graph.dependencies[strings.ToUpper(projectID)]...)
+}
+
+// Order returns the project IDs in build order: every project follows its dependencies,
+// the independent projects are ordered by name (and ID), so the order is the same between runs.
+// If the graph contains a dependency cycle, the order still contains every project (the cycle is broken at the first revisited project)
+// and an error describing the cycle is returned.
+func (graph ProjectGraph) Order() ([]string, error) {
+	projectIDs := []string{}
+	for projectID := range graph.projectMap {
+		projectIDs = append(projectIDs, projectID)
+	}
+	graph.sortIDs(projectIDs)
+
+	order := []string{}
+	visited := map[string]bool{}
+	visiting := map[string]bool{}
+	var cycle []string
+
+	var visit func(projectID string, pth []string)
+	visit = func(projectID string, pth []string) {
+		if visited[projectID] {
+			return
+		}
+		if visiting[projectID] {
+			if cycle == nil {
+				for i, id := range pth {
+					if id == projectID {
+						cycle = append(append([]string{}, pth[i:]...), projectID)
+						break
+					}
+				}
+			}
+			return
+		}
+
+		visiting[projectID] = true
+		for _, dependencyID := range graph.dependencies[projectID] {
+			visit(dependencyID, append(pth, projectID))
+		}
+		visiting[projectID] = false
+
+		visited[projectID] = true
+		order = append(order, projectID)
+	}
+
+	for _, projectID := range projectIDs {
+		visit(projectID, nil)
+	}
+
+	if cycle != nil {
+		names := []string{}
+		for _, projectID := range cycle {
+			names = append(names, graph.projectMap[projectID].Name)
+		}
+		return order, fmt.Errorf("project dependency cycle detected: %s", strings.Join(names, " -> "))
+	}
+
+	return order, nil
+}
+
+// Sort returns the given projects in build order, the projects not in the graph are appended by name.
+func (graph ProjectGraph) Sort(projects []project.Model) []project.Model {
+	// the order contains every project of the graph, even if it has a dependency cycle
+	order, _ := graph.Order()
+	// the analyzed project's ID might differ from the solution's project ID
+	index := map[string]int{}
+	for i, projectID := range order {
+		index[strings.ToUpper(graph.projectMap[projectID].ID)] = i
+	}
+	for i, projectID := range order {
+		index[projectID] = i
+	}
+
+	sorted := append([]project.Model{}, projects...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		iIndex, iFound := index[strings.ToUpper(sorted[i].ID)]
+		jIndex, jFound := index[strings.ToUpper(sorted[j].ID)]
+		if iFound && jFound {
+			return iIndex < jIndex
+		}
+		if iFound != jFound {
+			return iFound
+		}
+		return projectLess(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+func (graph ProjectGraph) sortIDs(projectIDs []string) {
+	sort.Slice(projectIDs, func(i, j int) bool {
+		iName, jName := graph.projectMap[projectIDs[i]].Name, graph.projectMap[projectIDs[j]].Name
+		if iName != jName {
+			return iName < jName
+		}
+		return projectIDs[i] < projectIDs[j]
+	})
+}
+
+func projectLess(a, b project.Model) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.ID < b.ID
+}
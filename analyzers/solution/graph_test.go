@@ -0,0 +1,153 @@
+package solution
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeSolutionProjectDependencies(t *testing.T) {
+	t.Log("it parses the ProjectDependencies sections")
+	{
+		pth := tmpSolutionWithContent(t, writerTestSolutionContent)
+		defer func() {
+			require.NoError(t, os.Remove(pth))
+		}()
+
+		solution, err := analyzeSolution(pth, false)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC"}, solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].DependencyProjectIDs)
+		require.Equal(t, 0, len(solution.ProjectMap["BBBBBBBB-BBBB-BBBB-BBBB-BBBBBBBBBBBB"].DependencyProjectIDs))
+		require.Equal(t, 0, len(solution.ProjectMap["CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC"].DependencyProjectIDs))
+	}
+
+	t.Log("it parses the BuildDependency elements of xml solutions")
+	{
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, os.RemoveAll(tmpDir))
+		}()
+
+		content := `<Solution>
+  <Project Path="App.iOS/App.iOS.csproj">
+    <BuildDependency Project="App\App.csproj" />
+  </Project>
+  <Project Path="App/App.csproj" />
+</Solution>`
+		pth := filepath.Join(tmpDir, "solution.slnx")
+		require.NoError(t, fileutil.WriteStringToFile(pth, content))
+
+		solution, err := analyzeSolution(pth, false)
+		require.NoError(t, err)
+
+		iOSID := projectIDFromPath("App.iOS/App.iOS.csproj")
+		appID := projectIDFromPath("App/App.csproj")
+		require.Equal(t, []string{appID}, solution.ProjectMap[iOSID].DependencyProjectIDs)
+
+		// BuildDependency is not a configuration rule
+		require.True(t, solution.ProjectMap[iOSID].BuildEnabled("Release|Any CPU"))
+	}
+}
+
+func TestProjectGraph(t *testing.T) {
+	newProject := func(id, name string, dependencyIDs, referredIDs []string) project.Model {
+		return project.Model{ID: id, Name: name, DependencyProjectIDs: dependencyIDs, ReferredProjectIDs: referredIDs}
+	}
+
+	t.Log("it orders the projects after their dependencies and by name")
+	{
+		projectMap := map[string]project.Model{
+			"1": newProject("1", "App.iOS", []string{"4"}, []string{"3"}),
+			"2": newProject("2", "App.Droid", nil, []string{"3", "UNKNOWN"}),
+			"3": newProject("3", "Core", nil, nil),
+			"4": newProject("4", "Api", nil, []string{"3"}),
+			"5": newProject("5", "Analytics", nil, nil),
+		}
+		graph := NewProjectGraph(projectMap)
+
+		require.Equal(t, []string{"4", "3"}, graph.Dependencies("1"))
+		require.Equal(t, []string{"3"}, graph.Dependencies("2"))
+
+		for i := 0; i < 10; i++ {
+			order, err := graph.Order()
+			require.NoError(t, err)
+			require.Equal(t, []string{"5", "3", "4", "2", "1"}, order)
+		}
+
+		sorted := graph.Sort([]project.Model{projectMap["1"], projectMap["3"], projectMap["2"]})
+		require.Equal(t, []project.Model{projectMap["3"], projectMap["2"], projectMap["1"]}, sorted)
+	}
+
+	t.Log("it detects dependency cycles")
+	{
+		projectMap := map[string]project.Model{
+			"1": newProject("1", "App", []string{"2"}, nil),
+			"2": newProject("2", "Core", nil, []string{"3"}),
+			"3": newProject("3", "Data", nil, []string{"2"}),
+		}
+
+		order, err := NewProjectGraph(projectMap).Order()
+		require.EqualError(t, err, "project dependency cycle detected: Core -> Data -> Core")
+		require.Equal(t, 3, len(order))
+	}
+}
+
+func TestProjectGraphSDKStyleReferences(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	files := map[string]string{
+		"App/App.csproj": `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0-android</TargetFramework>
+    <OutputType>Exe</OutputType>
+  </PropertyGroup>
+  <ItemGroup>
+    <ProjectReference Include="..\Core\Core.csproj" />
+  </ItemGroup>
+</Project>`,
+		"Core/Core.csproj": `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>netstandard2.0</TargetFramework>
+  </PropertyGroup>
+</Project>`,
+		"solution.slnx": `<Solution>
+  <Project Path="App/App.csproj" />
+  <Project Path="Core/Core.csproj" />
+</Solution>`,
+	}
+	for pth, content := range files {
+		pth = filepath.Join(tmpDir, pth)
+		require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0777))
+		require.NoError(t, fileutil.WriteStringToFile(pth, content))
+	}
+
+	t.Log("it resolves the SDK-style project references by path")
+	{
+		solution, err := analyzeSolution(filepath.Join(tmpDir, "solution.slnx"), true)
+		require.NoError(t, err)
+
+		appID := projectIDFromPath("App/App.csproj")
+		coreID := projectIDFromPath("Core/Core.csproj")
+		require.Equal(t, 0, len(solution.ProjectMap[appID].ReferredProjectIDs))
+		require.Equal(t, []string{filepath.Join(tmpDir, "Core", "Core.csproj")}, solution.ProjectMap[appID].ReferredProjectPaths)
+
+		graph := solution.ProjectGraph()
+		require.Equal(t, []string{coreID}, graph.Dependencies(appID))
+		require.Equal(t, []string{}, graph.Dependencies(coreID))
+
+		order, err := graph.Order()
+		require.NoError(t, err)
+		require.Equal(t, []string{coreID, appID}, order)
+	}
+}
@@ -0,0 +1,143 @@
+package solution
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// NewFromProject creates a synthetic solution for building a single project without a solution file:
+// it contains the given project and the projects it references (directly or transitively) by ProjectReference items.
+// The synthetic solution has no path, its configurations are the given project's Configuration|Platform pairs,
+// the referenced projects are mapped to the same Configuration|Platform, or to the same configuration with AnyCPU platform.
+// The referenced projects depend on each other by their references, so they can be ordered by the ProjectGraph.
+func NewFromProject(pth string) (Model, error) {
+	return newFromProject(pth, utility.OSFileSystem)
+}
+
+func newFromProject(pth string, fileSystem utility.FileSystem) (Model, error) {
+	absPth, err := fileSystem.AbsPath(pth)
+	if err != nil {
+		return Model{}, fmt.Errorf("Failed to expand path (%s), error: %s", pth, err)
+	}
+	rootDir := filepath.Dir(absPth)
+
+	fileCache := project.NewFileCache()
+
+	// the projects are collected breadth first, starting with the given project
+	projectPths := []string{absPth}
+	queued := map[string]bool{absPth: true}
+	references := map[string][]string{} // Project path - referenced project paths
+	projects := map[string]project.Model{}
+	var referenceWarnings []string
+	for i := 0; i < len(projectPths); i++ {
+		projectPth := projectPths[i]
+
+		proj, err := project.NewWithFileSystem(fileSystem, projectPth, nil, fileCache)
+		if err != nil {
+			return Model{}, fmt.Errorf("failed to analyze project (%s), error: %s", projectPth, err)
+		}
+		projects[projectPth] = proj
+
+		referencePths, warnings, err := projectReferencePaths(projectPth, fileSystem, fileCache)
+		if err != nil {
+			return Model{}, err
+		}
+		referenceWarnings = append(referenceWarnings, warnings...)
+		for _, referencePth := range referencePths {
+			if exist, err := fileSystem.IsPathExists(referencePth); err != nil {
+				return Model{}, err
+			} else if !exist {
+				log.Warnf("Project (%s) referenced by (%s) does not exist, skipping...", referencePth, projectPth)
+				continue
+			}
+
+			references[projectPth] = append(references[projectPth], referencePth)
+			if !queued[referencePth] {
+				queued[referencePth] = true
+				projectPths = append(projectPths, referencePth)
+			}
+		}
+	}
+
+	// the projects are identified by their GUID, or by their path (relative to the given project) if they do not have one
+	projectIDs := map[string]string{} // Project path - project ID
+	usedIDs := map[string]bool{}
+	for _, projectPth := range projectPths {
+		id := strings.ToUpper(projects[projectPth].ID)
+		if id == "" || usedIDs[id] {
+			relPth, err := filepath.Rel(rootDir, projectPth)
+			if err != nil {
+				relPth = projectPth
+			}
+			id = projectIDFromPath(relPth)
+		}
+		projectIDs[projectPth] = id
+		usedIDs[id] = true
+	}
+
+	rootProject := projects[absPth]
+	solution := Model{
+		Name:         rootProject.Name,
+		ConfigMap:    map[string]string{},
+		ProjectMap:   map[string]project.Model{},
+		FolderMap:    map[string]Folder{},
+		Diagnostics:  []Diagnostic{},
+		projectLines: map[string]int{},
+		fileSystem:   fileSystem,
+	}
+	for _, warning := range referenceWarnings {
+		solution.addDiagnostic(0, SeverityWarning, "%s", warning)
+	}
+	for config := range rootProject.Configs {
+		solution.ConfigMap[config] = config
+	}
+
+	for _, projectPth := range projectPths {
+		proj := projects[projectPth]
+		proj.ID = projectIDs[projectPth]
+
+		proj.DependencyProjectIDs = nil
+		for _, referencePth := range references[projectPth] {
+			proj.DependencyProjectIDs = append(proj.DependencyProjectIDs, projectIDs[referencePth])
+		}
+
+		proj.ConfigMap = map[string]string{}
+		for config := range solution.ConfigMap {
+			proj.ConfigMap[config] = config
+		}
+		applyDefaultPlatforms(&proj)
+		for config, projectConfig := range proj.ConfigMap {
+			if _, ok := proj.Configs[projectConfig]; !ok {
+				delete(proj.ConfigMap, config)
+			}
+		}
+
+		solution.ProjectMap[proj.ID] = proj
+	}
+
+	return solution, nil
+}
+
+// projectReferencePaths returns the absolute paths of the projects referenced by the given project's ProjectReference items,
+// and the warnings of the paths differing from the referenced files in case.
+func projectReferencePaths(projectPth string, fileSystem utility.FileSystem, fileCache *project.FileCache) ([]string, []string, error) {
+	parsedProject, err := fileCache.ParseProjectWithFileSystem(fileSystem, projectPth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pths, warnings []string
+	for _, include := range project.GetReferencedProjectPaths(parsedProject, filepath.Dir(projectPth)) {
+		pth, warning := fileSystem.ResolvePath(include)
+		if warning != "" {
+			warnings = append(warnings, fmt.Sprintf("project (%s) reference: %s", projectPth, warning))
+		}
+		pths = append(pths, pth)
+	}
+	return pths, warnings, nil
+}
@@ -0,0 +1,102 @@
+package solution
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/utility"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromProject(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	appPth := filepath.Join(tmpDir, "App", "App.csproj")
+	corePth := filepath.Join(tmpDir, "Core", "Core.csproj")
+	require.NoError(t, os.MkdirAll(filepath.Dir(appPth), 0777))
+	require.NoError(t, os.MkdirAll(filepath.Dir(corePth), 0777))
+	require.NoError(t, fileutil.WriteStringToFile(appPth, projectSetTestAppProjectContent))
+	require.NoError(t, fileutil.WriteStringToFile(corePth, anyCPUTestProjectContent))
+
+	solution, err := NewFromProject(appPth)
+	require.NoError(t, err)
+
+	t.Log("it collects the project and the projects it references")
+	{
+		require.Equal(t, "App", solution.Name)
+		require.Equal(t, "", solution.Pth)
+		require.Equal(t, 2, len(solution.ProjectMap))
+
+		app := solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"]
+		require.Equal(t, appPth, app.Pth)
+
+		coreID := projectIDFromPath(filepath.Join("..", "Core", "Core.csproj"))
+		core := solution.ProjectMap[coreID]
+		require.Equal(t, corePth, core.Pth)
+
+		require.Equal(t, []string{coreID}, app.DependencyProjectIDs)
+		require.Equal(t, 0, len(core.DependencyProjectIDs))
+	}
+
+	t.Log("it uses the project's configurations")
+	{
+		require.Equal(t, map[string]string{
+			"Debug|iPhone":   "Debug|iPhone",
+			"Release|iPhone": "Release|iPhone",
+		}, solution.ConfigMap)
+
+		app := solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"]
+		require.Equal(t, solution.ConfigMap, app.ConfigMap)
+	}
+
+	t.Log("it maps the referenced projects to the AnyCPU platform")
+	{
+		core := solution.ProjectMap[projectIDFromPath(filepath.Join("..", "Core", "Core.csproj"))]
+		require.Equal(t, map[string]string{
+			"Debug|iPhone":   "Debug|AnyCPU",
+			"Release|iPhone": "Release|AnyCPU",
+		}, core.ConfigMap)
+	}
+
+	t.Log("it orders the projects by their references")
+	{
+		order, err := solution.ProjectGraph().Order()
+		require.NoError(t, err)
+		require.Equal(t, 2, len(order))
+		require.Equal(t, "AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA", order[1])
+	}
+}
+
+func TestNewFromProjectCaseInsensitivePaths(t *testing.T) {
+	fsys := fstest.MapFS{
+		"App/App.csproj":   {Data: []byte(strings.Replace(projectSetTestAppProjectContent, `..\Core\Core.csproj`, `..\core\core.csproj`, 1))},
+		"Core/Core.csproj": {Data: []byte(anyCPUTestProjectContent)},
+	}
+
+	solution, err := newFromProject(filepath.Join(utility.FSRoot, "App", "App.csproj"), utility.NewFSFileSystem(fsys))
+	require.NoError(t, err)
+
+	t.Log("it resolves the project references through the file system")
+	{
+		require.Equal(t, 2, len(solution.ProjectMap))
+
+		core := solution.ProjectMap[projectIDFromPath(filepath.Join("..", "Core", "Core.csproj"))]
+		require.Equal(t, filepath.Join(utility.FSRoot, "Core", "Core.csproj"), core.Pth)
+	}
+
+	t.Log("it reports the project references differing in case")
+	{
+		warnings := solution.Warnings()
+		require.Equal(t, 1, len(warnings))
+		require.Contains(t, warnings[0].Message, "differs only in case")
+	}
+}
@@ -0,0 +1,116 @@
+package solution
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// Filter is a solution filter (.slnf), it selects a subset of a solution's projects.
+type Filter struct {
+	Pth         string
+	SolutionPth string   // The absolute path of the filtered solution
+	Projects    []string // The solution relative paths of the selected projects
+}
+
+type solutionFilterJSON struct {
+	Solution struct {
+		Path     string   `json:"path"`
+		Projects []string `json:"projects"`
+	} `json:"solution"`
+}
+
+// ParseSolutionFilterContent parses the given solution filter content,
+// the filtered solution's path is resolved relative to the given solution filter path.
+func ParseSolutionFilterContent(pth, content string) (Filter, error) {
+	var filterJSON solutionFilterJSON
+	if err := json.Unmarshal([]byte(content), &filterJSON); err != nil {
+		return Filter{}, fmt.Errorf("failed to unmarshall solution filter content. Error: %v", err)
+	}
+
+	solutionPth := utility.FixWindowsPath(filterJSON.Solution.Path)
+	if solutionPth == "" {
+		return Filter{}, fmt.Errorf("solution filter (%s) does not specify the solution path", pth)
+	}
+	if !filepath.IsAbs(solutionPth) {
+		solutionPth = filepath.Join(filepath.Dir(pth), solutionPth)
+	}
+
+	ext := strings.ToLower(filepath.Ext(solutionPth))
+	if ext != constants.SolutionExt && ext != constants.SolutionXMLExt {
+		return Filter{}, fmt.Errorf("solution filter (%s) refers to an invalid solution path: %s", pth, solutionPth)
+	}
+
+	projects := []string{}
+	for _, projectPth := range filterJSON.Solution.Projects {
+		projects = append(projects, filepath.Clean(utility.FixWindowsPath(projectPth)))
+	}
+
+	return Filter{Pth: pth, SolutionPth: filepath.Clean(solutionPth), Projects: projects}, nil
+}
+
+// ParseSolutionFilter parses the solution filter at the given path.
+func ParseSolutionFilter(pth string) (Filter, error) {
+	return parseSolutionFilter(pth, utility.OSFileSystem)
+}
+
+func parseSolutionFilter(pth string, fileSystem utility.FileSystem) (Filter, error) {
+	content, err := fileSystem.ReadStringFromFile(pth)
+	if err != nil {
+		return Filter{}, fmt.Errorf("failed to read solution filter (%s), error: %s", pth, err)
+	}
+	return ParseSolutionFilterContent(pth, content)
+}
+
+// analyzeSolutionFilter parses the filtered solution and keeps the projects selected by the filter.
+// The returned solution's path is the solution filter's path, MSBuild builds the selected projects of the solution filter.
+func analyzeSolutionFilter(absPth string, filter Filter, fileSystem utility.FileSystem) (Model, error) {
+	solutionPth, warning := fileSystem.ResolvePath(filter.SolutionPth)
+	filter.SolutionPth = solutionPth
+
+	solution, err := analyzeSolutionFile(filter.SolutionPth, fileSystem)
+	if err != nil {
+		return Model{}, err
+	}
+
+	solutionDir := filepath.Dir(filter.SolutionPth)
+	projectMap := map[string]project.Model{}
+	for _, projectRelativePth := range filter.Projects {
+		found := false
+		for projectID, proj := range solution.ProjectMap {
+			if relPth, err := filepath.Rel(solutionDir, proj.Pth); err == nil && strings.EqualFold(relPth, projectRelativePth) {
+				projectMap[projectID] = proj
+				found = true
+				break
+			}
+		}
+		if !found {
+			return Model{}, fmt.Errorf("project (%s) of the solution filter (%s) not found in the solution (%s)", projectRelativePth, absPth, filter.SolutionPth)
+		}
+	}
+
+	projectIDs := []string{}
+	for projectID := range projectMap {
+		projectIDs = append(projectIDs, projectID)
+	}
+
+	// the projects are not analyzed yet, so the filter selects the projects without their references, like MSBuild does
+	filtered, err := solution.DeriveSolution(projectIDs...)
+	if err != nil {
+		return Model{}, err
+	}
+
+	fileName := filepath.Base(absPth)
+	filtered.Pth = absPth
+	filtered.Name = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	if warning != "" {
+		filtered.addDiagnostic(0, SeverityWarning, "solution: %s", warning)
+	}
+
+	return filtered, nil
+}
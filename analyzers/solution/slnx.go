@@ -0,0 +1,312 @@
+package solution
+
+import (
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// SolutionXML is the XML based (.slnx) solution format.
+type SolutionXML struct {
+	XMLName        xml.Name `xml:"Solution"`
+	Configurations struct {
+		BuildTypes []struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"BuildType"`
+		Platforms []struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Platform"`
+	} `xml:"Configurations"`
+	Folders []struct {
+		Name     string       `xml:"Name,attr"` // The folder's path, like /Apps/Android/
+		ID       string       `xml:"Id,attr"`
+		Projects []ProjectXML `xml:"Project"`
+	} `xml:"Folder"`
+	Projects []ProjectXML `xml:"Project"`
+}
+
+// ProjectXML is a project of an XML based (.slnx) solution.
+type ProjectXML struct {
+	Path        string `xml:"Path,attr"`
+	Type        string `xml:"Type,attr"`
+	DisplayName string `xml:"DisplayName,attr"`
+	ID          string `xml:"Id,attr"`
+
+	Rules []ProjectConfigurationRuleXML `xml:",any"`
+}
+
+// ProjectConfigurationRuleXML is a BuildType, Platform, Build or Deploy rule of a project,
+// it maps the matching solution configurations (like Release|*) to the given project value.
+// BuildDependency elements are parsed as rules too, their Project attribute is the solution relative path of the dependency.
+type ProjectConfigurationRuleXML struct {
+	XMLName  xml.Name
+	Solution string `xml:"Solution,attr"`
+	Project  string `xml:"Project,attr"`
+}
+
+// ParseSolutionXMLContent parses the given string content to SolutionXML struct.
+func ParseSolutionXMLContent(content string) (SolutionXML, error) {
+	var solution SolutionXML
+	if err := xml.Unmarshal([]byte(content), &solution); err != nil {
+		return SolutionXML{}, fmt.Errorf("failed to unmarshall solution content. Error: %v", err)
+	}
+	return solution, nil
+}
+
+// analyzeSolutionXML parses the XML based (.slnx) solution format.
+// The solution configurations default to Debug and Release build types and the Any CPU platform,
+// the projects are built in every solution configuration with the same build type and platform, unless their rules say otherwise.
+func analyzeSolutionXML(absPth string, fileSystem utility.FileSystem) (Model, error) {
+	content, err := fileSystem.ReadStringFromFile(absPth)
+	if err != nil {
+		return Model{}, fmt.Errorf("failed to read solution (%s), error: %s", absPth, err)
+	}
+
+	solutionXML, err := ParseSolutionXMLContent(content)
+	if err != nil {
+		return Model{}, err
+	}
+
+	fileName := filepath.Base(absPth)
+	solution := Model{
+		Pth:        absPth,
+		Name:       strings.TrimSuffix(fileName, filepath.Ext(fileName)),
+		ConfigMap:  map[string]string{},
+		ProjectMap: map[string]project.Model{},
+		FolderMap:  map[string]Folder{},
+	}
+
+	buildTypes := []string{}
+	for _, buildType := range solutionXML.Configurations.BuildTypes {
+		buildTypes = append(buildTypes, buildType.Name)
+	}
+	if len(buildTypes) == 0 {
+		buildTypes = []string{"Debug", "Release"}
+	}
+
+	platforms := []string{}
+	for _, platform := range solutionXML.Configurations.Platforms {
+		platforms = append(platforms, platform.Name)
+	}
+	if len(platforms) == 0 {
+		platforms = []string{"Any CPU"}
+	}
+
+	for _, buildType := range buildTypes {
+		for _, platform := range platforms {
+			config := utility.ToConfig(buildType, platform)
+			solution.ConfigMap[config] = config
+		}
+	}
+
+	// the folders are listed with their full path, the parent folders are not necessarily listed
+	folderMap := map[string]Folder{}
+	parentIDs := map[string]string{}
+	folderIDs := map[string]string{} // lower case folder path - folder ID map
+	ensureFolder := func(pth, id string) string {
+		parentID := ""
+		folderPth := ""
+		for _, name := range strings.Split(normalizeFolderPath(pth), "/") {
+			if name == "" {
+				continue
+			}
+			folderPth = strings.TrimPrefix(folderPth+"/"+name, "/")
+
+			folderID, ok := folderIDs[strings.ToLower(folderPth)]
+			if !ok {
+				folderID = projectIDFromPath("/" + folderPth + "/")
+				if id != "" && strings.EqualFold(folderPth, normalizeFolderPath(pth)) {
+					folderID = strings.ToUpper(strings.Trim(id, "{}"))
+				}
+				folderIDs[strings.ToLower(folderPth)] = folderID
+				folderMap[folderID] = Folder{ID: folderID, Name: name}
+				if parentID != "" {
+					parentIDs[folderID] = parentID
+				}
+			}
+			parentID = folderID
+		}
+		return parentID
+	}
+
+	projects := append([]ProjectXML{}, solutionXML.Projects...)
+	projectFolderIDs := make([]string, len(projects))
+	for _, folder := range solutionXML.Folders {
+		folderID := ensureFolder(folder.Name, folder.ID)
+		for _, projectXML := range folder.Projects {
+			projects = append(projects, projectXML)
+			projectFolderIDs = append(projectFolderIDs, folderID)
+		}
+	}
+
+	projectIDsByPth := map[string]string{}      // lower case solution relative path - project ID map
+	dependencyPthsByID := map[string][]string{} // project ID - solution relative dependency paths map
+
+	solutionDir := filepath.Dir(absPth)
+	for i, projectXML := range projects {
+		projectRelativePth := utility.FixWindowsPath(projectXML.Path)
+		projectPth := filepath.Join(solutionDir, projectRelativePth)
+
+		if !strings.HasSuffix(projectPth, constants.CSProjExt) &&
+			!strings.HasSuffix(projectPth, constants.SHProjExt) &&
+			!strings.HasSuffix(projectPth, constants.FSProjExt) {
+			continue
+		}
+
+		projectPth, warning := fileSystem.ResolvePath(projectPth)
+		if warning != "" {
+			solution.addDiagnostic(0, SeverityWarning, "project (%s): %s", projectXML.Path, warning)
+		}
+
+		projectID := strings.ToUpper(strings.Trim(projectXML.ID, "{}"))
+		if projectID == "" {
+			projectID = projectIDFromPath(projectRelativePth)
+		}
+
+		projectName := projectXML.DisplayName
+		if projectName == "" {
+			projectName = strings.TrimSuffix(filepath.Base(projectPth), filepath.Ext(projectPth))
+		}
+
+		proj := project.Model{
+			ID:   projectID,
+			Name: projectName,
+			Pth:  projectPth,
+
+			ConfigMap:      map[string]string{},
+			ConfigMappings: map[string]project.ConfigMapping{},
+			Configs:        map[string]project.ConfigurationPlatformModel{},
+		}
+
+		for _, buildType := range buildTypes {
+			for _, platform := range platforms {
+				solutionConfig := utility.ToConfig(buildType, platform)
+				mapping := projectXML.configMapping(buildType, platform)
+
+				proj.ConfigMap[solutionConfig] = mapping.Config
+				proj.ConfigMappings[solutionConfig] = mapping
+			}
+		}
+
+		solution.ProjectMap[projectID] = proj
+		if projectFolderIDs[i] != "" {
+			parentIDs[projectID] = projectFolderIDs[i]
+		}
+
+		projectIDsByPth[strings.ToLower(filepath.Clean(projectRelativePth))] = projectID
+		for _, rule := range projectXML.Rules {
+			if rule.XMLName.Local == "BuildDependency" {
+				dependencyPthsByID[projectID] = append(dependencyPthsByID[projectID], rule.Project)
+			}
+		}
+	}
+
+	for projectID, dependencyPths := range dependencyPthsByID {
+		proj := solution.ProjectMap[projectID]
+		for _, dependencyPth := range dependencyPths {
+			dependencyPth = filepath.Clean(utility.FixWindowsPath(dependencyPth))
+			if dependencyID, ok := projectIDsByPth[strings.ToLower(dependencyPth)]; ok {
+				proj.DependencyProjectIDs = append(proj.DependencyProjectIDs, dependencyID)
+			}
+		}
+		solution.ProjectMap[projectID] = proj
+	}
+
+	solution.setFolders(folderMap, parentIDs)
+
+	return solution, nil
+}
+
+// configMapping returns the project configuration mapped to the given solution configuration
+// and whether the project is built and deployed in the solution configuration, the rules are applied in document order.
+func (projectXML ProjectXML) configMapping(buildType, platform string) project.ConfigMapping {
+	projectBuildType := buildType
+	projectPlatform := platform
+	build := true
+	deploy := false
+
+	for _, rule := range projectXML.Rules {
+		if !ruleMatches(rule.Solution, buildType, platform) {
+			continue
+		}
+
+		switch rule.XMLName.Local {
+		case "BuildType":
+			projectBuildType = rule.Project
+		case "Platform":
+			projectPlatform = rule.Project
+		case "Build":
+			build = !strings.EqualFold(strings.TrimSpace(rule.Project), "false")
+		case "Deploy":
+			deploy = !strings.EqualFold(strings.TrimSpace(rule.Project), "false")
+		}
+	}
+
+	if projectPlatform == "Any CPU" {
+		projectPlatform = "AnyCPU"
+	}
+
+	return project.ConfigMapping{
+		Config: utility.ToConfig(projectBuildType, projectPlatform),
+		Build:  build,
+		Deploy: deploy,
+	}
+}
+
+// ruleMatches returns true if the given rule's solution configuration pattern (BuildType|Platform, * matches any)
+// matches the given solution configuration, an empty pattern matches every configuration.
+func ruleMatches(pattern, buildType, platform string) bool {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return true
+	}
+
+	patternBuildType, patternPlatform := pattern, "*"
+	if split := strings.SplitN(pattern, "|", 2); len(split) == 2 {
+		patternBuildType, patternPlatform = split[0], split[1]
+	}
+
+	matches := func(pattern, value string) bool {
+		return pattern == "*" || strings.EqualFold(pattern, value)
+	}
+	return matches(patternBuildType, buildType) && matches(patternPlatform, platform)
+}
+
+// projectIDFromPath returns a stable project ID for the projects without Id attribute,
+// based on the project's solution relative path.
+func projectIDFromPath(relativePth string) string {
+	sum := md5.Sum([]byte(strings.ToLower(relativePth)))
+	id := fmt.Sprintf("%X", sum)
+	return strings.Join([]string{id[0:8], id[8:12], id[12:16], id[16:20], id[20:32]}, "-")
+}
+
+// applyDefaultPlatforms maps the solution configurations to the AnyCPU platform
+// if the project does not define the solution's platform, like Visual Studio does for .slnx solutions.
+func applyDefaultPlatforms(proj *project.Model) {
+	if len(proj.Configs) == 0 {
+		return
+	}
+
+	for solutionConfig, projectConfig := range proj.ConfigMap {
+		if _, ok := proj.Configs[projectConfig]; ok {
+			continue
+		}
+
+		configuration := strings.SplitN(projectConfig, "|", 2)[0]
+		anyCPUConfig := utility.ToConfig(configuration, "AnyCPU")
+		if _, ok := proj.Configs[anyCPUConfig]; ok {
+			proj.ConfigMap[solutionConfig] = anyCPUConfig
+
+			if mapping, ok := proj.ConfigMappings[solutionConfig]; ok {
+				mapping.Config = anyCPUConfig
+				proj.ConfigMappings[solutionConfig] = mapping
+			}
+		}
+	}
+}
@@ -3,12 +3,15 @@ package solution
 import (
 	"bufio"
 	"fmt"
+	"io/fs"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
-	"github.com/bitrise-io/go-utils/fileutil"
-	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/log"
 	"github.com/bitrise-io/go-xamarin/analyzers/project"
 	"github.com/bitrise-io/go-xamarin/constants"
 	"github.com/bitrise-io/go-xamarin/utility"
@@ -23,7 +26,34 @@ const (
 
 	projectConfigurationPlatformsSectionStartPattern = `GlobalSection\(ProjectConfigurationPlatforms\) = postSolution`
 	projectConfigurationPlatformsSectionEndPattern   = `EndGlobalSection`
-	projectConfigurationPlatformPattern              = `{(?P<project_id>.*)}.(?P<config>.*)\|(?P<platform>.*)\.Build.* = (?P<mapped_config>.*)\|(?P<mapped_platform>.*)`
+	projectConfigurationPlatformPattern              = `{(?P<project_id>[^}]*)}\.(?P<config>[^|]*)\|(?P<platform>.*)\.(?P<flag>ActiveCfg|Build\.0|Deploy\.0) = (?P<mapped_config>[^|]*)\|(?P<mapped_platform>.*)`
+
+	nestedProjectsSectionStartPattern = `GlobalSection\(NestedProjects\) = preSolution`
+	nestedProjectsSectionEndPattern   = `EndGlobalSection`
+	nestedProjectPattern              = `{(?P<project_id>[^}]*)} = {(?P<parent_id>[^}]*)}`
+
+	projectDependenciesSectionStartPattern = `ProjectSection\(ProjectDependencies\) = postProject`
+	projectDependenciesSectionEndPattern   = `EndProjectSection`
+	projectDependencyPattern               = `{(?P<dependency_id>[^}]*)} = {[^}]*}`
+	projectEndPattern                      = `^EndProject$`
+)
+
+// the patterns are compiled once, the solution file is matched line by line
+var (
+	solutionProjectsRegexp                           = regexp.MustCompile(solutionProjectsPattern)
+	solutionConfigurationPlatformsSectionStartRegexp = regexp.MustCompile(solutionConfigurationPlatformsSectionStartPattern)
+	solutionConfigurationPlatformsSectionEndRegexp   = regexp.MustCompile(solutionConfigurationPlatformsSectionEndPattern)
+	solutionConfigurationPlatformRegexp              = regexp.MustCompile(solutionConfigurationPlatformPattern)
+	projectConfigurationPlatformsSectionStartRegexp  = regexp.MustCompile(projectConfigurationPlatformsSectionStartPattern)
+	projectConfigurationPlatformsSectionEndRegexp    = regexp.MustCompile(projectConfigurationPlatformsSectionEndPattern)
+	projectConfigurationPlatformRegexp               = regexp.MustCompile(projectConfigurationPlatformPattern)
+	nestedProjectsSectionStartRegexp                 = regexp.MustCompile(nestedProjectsSectionStartPattern)
+	nestedProjectsSectionEndRegexp                   = regexp.MustCompile(nestedProjectsSectionEndPattern)
+	nestedProjectRegexp                              = regexp.MustCompile(nestedProjectPattern)
+	projectDependenciesSectionStartRegexp            = regexp.MustCompile(projectDependenciesSectionStartPattern)
+	projectDependenciesSectionEndRegexp              = regexp.MustCompile(projectDependenciesSectionEndPattern)
+	projectDependencyRegexp                          = regexp.MustCompile(projectDependencyPattern)
+	projectEndRegexp                                 = regexp.MustCompile(projectEndPattern)
 )
 
 // Model ...
@@ -35,6 +65,16 @@ type Model struct {
 	ConfigMap map[string]string // Internal Configuartion|Platform - External Configuartion|Platform map
 
 	ProjectMap map[string]project.Model // Project ID - Project Model map
+	FolderMap  map[string]Folder        // Solution folder ID - Folder map
+
+	// The problems found while analyzing the solution, like malformed lines, duplicate project GUIDs
+	// or (with NewLenient) the projects which failed to analyze
+	Diagnostics []Diagnostic
+
+	content      string         // The .sln file's content, parsed into a Document by the writer, see parsedDocument
+	document     *Document      // The modified .sln document of the derived solutions
+	projectLines map[string]int // Project ID - the line number of the project's definition in the .sln file
+	fileSystem   utility.FileSystem
 }
 
 // New ...
@@ -42,6 +82,28 @@ func New(pth string, loadProjects bool) (Model, error) {
 	return analyzeSolution(pth, loadProjects)
 }
 
+// NewFS analyzes the solution at the given path of the fs.FS, like a git tree snapshot or an in-memory fixture.
+// The fs.FS is mounted at utility.FSRoot, the paths of the returned model (like the project paths) are under FSRoot.
+func NewFS(fsys fs.FS, pth string, loadProjects bool) (Model, error) {
+	return NewWithOptions(pth, AnalyzeOptions{LoadProjects: loadProjects, FS: fsys})
+}
+
+// NewLenient analyzes the solution like New, but the projects which fail to analyze (like missing or malformed project files)
+// do not fail the analysis: they are kept unanalyzed, with unknown SDK, and the problems are reported in the solution's Diagnostics.
+func NewLenient(pth string, loadProjects bool) (Model, error) {
+	return NewWithOptions(pth, AnalyzeOptions{LoadProjects: loadProjects, Lenient: true})
+}
+
+// NewWithOptions analyzes the solution with the given options.
+func NewWithOptions(pth string, options AnalyzeOptions) (Model, error) {
+	return analyzeSolutionWithOptions(pth, options)
+}
+
+// FileSystem returns the file system the solution was analyzed from.
+func (solution Model) FileSystem() utility.FileSystem {
+	return solution.fileSystem
+}
+
 // ConfigList ...
 func (solution Model) ConfigList() []string {
 	configList := []string{}
@@ -51,80 +113,397 @@ func (solution Model) ConfigList() []string {
 	return configList
 }
 
+// AnalyzeOptions ...
+type AnalyzeOptions struct {
+	LoadProjects bool // Analyze the solution's project files
+	Lenient      bool // Report the projects which fail to analyze as diagnostics instead of failing, see NewLenient
+
+	// The maximum number of projects analyzed in parallel, defaults to the number of CPUs
+	Workers int
+	// The directory of the on-disk project analysis cache, the cache is disabled if empty
+	CacheDir string
+
+	// The file system the solution and its projects are read from, the OS file system if nil
+	FS fs.FS
+}
+
 func analyzeSolution(pth string, analyzeProjects bool) (Model, error) {
-	absPth, err := pathutil.AbsPath(pth)
+	return analyzeSolutionWithOptions(pth, AnalyzeOptions{LoadProjects: analyzeProjects})
+}
+
+func analyzeSolutionWithOptions(pth string, options AnalyzeOptions) (Model, error) {
+	fileSystem := utility.NewFSFileSystem(options.FS)
+
+	absPth, err := fileSystem.AbsPath(pth)
 	if err != nil {
 		return Model{}, fmt.Errorf("Failed to expand path (%s), error: %s", pth, err)
 	}
 
+	// the projects are analyzed with the properties of the solution they belong to
+	propertiesPth := absPth
+	defaultPlatforms := false
+
+	var solution Model
+	switch strings.ToLower(filepath.Ext(absPth)) {
+	case constants.SolutionFilterExt:
+		filter, err := parseSolutionFilter(absPth, fileSystem)
+		if err != nil {
+			return Model{}, err
+		}
+
+		solution, err = analyzeSolutionFilter(absPth, filter, fileSystem)
+		if err != nil {
+			return Model{}, err
+		}
+
+		propertiesPth = filter.SolutionPth
+		defaultPlatforms = strings.EqualFold(filepath.Ext(propertiesPth), constants.SolutionXMLExt)
+	case constants.SolutionXMLExt:
+		solution, err = analyzeSolutionXML(absPth, fileSystem)
+		if err != nil {
+			return Model{}, err
+		}
+
+		defaultPlatforms = true
+	default:
+		solution, err = analyzeSolutionText(absPth, fileSystem)
+		if err != nil {
+			return Model{}, err
+		}
+	}
+	solution.fileSystem = fileSystem
+
+	if options.LoadProjects {
+		if err := solution.analyzeProjects(propertiesPth, defaultPlatforms, options); err != nil {
+			return Model{}, err
+		}
+	}
+
+	return solution, nil
+}
+
+// analyzeProjects analyzes the solution's project files with a bounded worker pool.
+// The projects share a parsed file cache, so the files imported by many projects (like Directory.Build.props) are parsed once,
+// the analyzed projects are loaded from and stored in the on-disk cache, if it is enabled.
+// The projects which fail to analyze fail the analysis, or are reported as diagnostics if the analysis is lenient.
+func (solution *Model) analyzeProjects(propertiesPth string, defaultPlatforms bool, options AnalyzeOptions) error {
+	globalProperties := solutionProperties(propertiesPth)
+
+	var diskCache *project.DiskCache
+	if options.CacheDir != "" {
+		cache, err := project.NewDiskCache(options.CacheDir)
+		if err != nil {
+			return err
+		}
+		cache = cache.WithFileSystem(solution.fileSystem)
+		diskCache = &cache
+	}
+	fileCache := project.NewFileCache()
+
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	projectIDs := []string{}
+	for projectID := range solution.ProjectMap {
+		projectIDs = append(projectIDs, projectID)
+	}
+	sort.Strings(projectIDs)
+
+	type analyzeResult struct {
+		project project.Model
+		err     error
+	}
+	results := make([]analyzeResult, len(projectIDs))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(projectIDs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				pth := solution.ProjectMap[projectIDs[idx]].Pth
+				results[idx].project, results[idx].err = analyzeProject(solution.fileSystem, pth, globalProperties, fileCache, diskCache)
+			}
+		}()
+	}
+	for idx := range projectIDs {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	// the results are merged in project ID order, so the reported error and the diagnostics do not depend on the scheduling
+	projectMap := map[string]project.Model{}
+	for idx, projectID := range projectIDs {
+		proj := solution.ProjectMap[projectID]
+		projectDefinition, err := results[idx].project, results[idx].err
+		if err != nil {
+			if !options.Lenient {
+				return fmt.Errorf("failed to analyze project (%s), error: %s", proj.Pth, err)
+			}
+
+			// the project lines refer to the solution the projects are defined in
+			solution.Diagnostics = append(solution.Diagnostics, Diagnostic{
+				Pth:      propertiesPth,
+				Line:     solution.projectLines[projectID],
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("failed to analyze project (%s), error: %s", proj.Pth, err),
+			})
+			proj.SDK = constants.SDKUnknown
+			projectMap[projectID] = proj
+			continue
+		}
+
+		projectDefinition.Name = proj.Name
+		projectDefinition.Pth = proj.Pth
+		projectDefinition.ConfigMap = proj.ConfigMap
+		projectDefinition.ConfigMappings = proj.ConfigMappings
+		projectDefinition.SolutionFolder = proj.SolutionFolder
+		projectDefinition.DependencyProjectIDs = proj.DependencyProjectIDs
+		// SDK-style projects do not contain their GUID
+		if projectDefinition.ID == "" {
+			projectDefinition.ID = proj.ID
+		}
+		if defaultPlatforms {
+			applyDefaultPlatforms(&projectDefinition)
+		}
+
+		projectMap[projectID] = projectDefinition
+	}
+
+	solution.ProjectMap = projectMap
+
+	return nil
+}
+
+// analyzeProject analyzes the given project file, or loads its analysis from the on-disk cache (if not nil).
+func analyzeProject(fileSystem utility.FileSystem, pth string, globalProperties map[string]string, fileCache *project.FileCache, diskCache *project.DiskCache) (project.Model, error) {
+	if diskCache != nil {
+		if proj, ok := diskCache.Load(pth, globalProperties); ok {
+			return proj, nil
+		}
+	}
+
+	// the paths probed by the analysis (like the conditional imports of not existing files) are inputs of the cached analysis
+	recorder := &utility.PathRecorder{}
+	if diskCache != nil {
+		fileSystem = fileSystem.WithRecorder(recorder)
+	}
+
+	proj, err := project.NewWithFileSystem(fileSystem, pth, globalProperties, fileCache)
+	if err != nil {
+		return project.Model{}, err
+	}
+
+	if diskCache != nil {
+		if err := diskCache.Store(pth, globalProperties, proj, recorder.Paths()...); err != nil {
+			log.Debugf("Failed to store the analysis of project (%s) in the cache, error: %s", pth, err)
+		}
+	}
+
+	return proj, nil
+}
+
+// analyzeSolutionFile parses the given solution (.sln or .slnx) without analyzing its projects.
+func analyzeSolutionFile(absPth string, fileSystem utility.FileSystem) (Model, error) {
+	if strings.EqualFold(filepath.Ext(absPth), constants.SolutionXMLExt) {
+		return analyzeSolutionXML(absPth, fileSystem)
+	}
+	return analyzeSolutionText(absPth, fileSystem)
+}
+
+// analyzeSolutionText parses the classic text based (.sln) solution format.
+// The problems (like the duplicate project GUIDs and the unterminated Project and GlobalSection blocks)
+// do not fail the parsing, they are reported in the solution's Diagnostics.
+func analyzeSolutionText(absPth string, fileSystem utility.FileSystem) (Model, error) {
 	fileName := filepath.Base(absPth)
 	ext := filepath.Ext(absPth)
 	fileName = strings.TrimSuffix(fileName, ext)
 
 	solution := Model{
-		Pth:        absPth,
-		Name:       fileName,
-		ConfigMap:  map[string]string{},
-		ProjectMap: map[string]project.Model{},
+		Pth:          absPth,
+		Name:         fileName,
+		ConfigMap:    map[string]string{},
+		ProjectMap:   map[string]project.Model{},
+		FolderMap:    map[string]Folder{},
+		Diagnostics:  []Diagnostic{},
+		projectLines: map[string]int{},
 	}
 
 	isSolutionConfigurationPlatformsSection := false
 	isProjectConfigurationPlatformsSection := false
+	isNestedProjectsSection := false
+	isProjectDependenciesSection := false
+	currentProjectID := ""
+
+	folderMap := map[string]Folder{}
+	parentIDs := map[string]string{}
+	definedIDs := map[string]int{} // The IDs of every project entry (including folders and unsupported projects) - line number map
+
+	// the open blocks, to report the unterminated ones
+	openProjectName, openProjectLine := "", 0
+	openSectionName, openSectionLine := "", 0
+	closeProject := func() {
+		if openProjectLine > 0 {
+			solution.addDiagnostic(openProjectLine, SeverityError, "project (%s) is not terminated with EndProject", openProjectName)
+		}
+		openProjectName, openProjectLine = "", 0
+	}
+	closeSection := func() {
+		if openSectionLine > 0 {
+			solution.addDiagnostic(openSectionLine, SeverityError, "global section (%s) is not terminated with EndGlobalSection", openSectionName)
+		}
+		openSectionName, openSectionLine = "", 0
+	}
 
 	solutionDir := filepath.Dir(absPth)
 
-	content, err := fileutil.ReadStringFromFile(absPth)
+	content, err := fileSystem.ReadStringFromFile(absPth)
 	if err != nil {
 		return Model{}, fmt.Errorf("failed to read solution (%s), error: %s", absPth, err)
 	}
 
+	lineNumber := 0
 	scanner := bufio.NewScanner(strings.NewReader(content))
 	for scanner.Scan() {
+		lineNumber++
 		line := strings.TrimSpace(scanner.Text())
 
+		// Project and GlobalSection blocks
+		switch {
+		case strings.HasPrefix(line, "Project("):
+			closeProject()
+			openProjectLine = lineNumber
+		case line == "EndProject":
+			openProjectName, openProjectLine = "", 0
+		case line == "Global":
+			closeProject()
+		case strings.HasPrefix(line, "GlobalSection("):
+			closeSection()
+			openSectionName, openSectionLine = line, lineNumber
+			if matches := documentSectionRegexp.FindStringSubmatch(line); len(matches) == 3 {
+				openSectionName = matches[1]
+			}
+		case line == "EndGlobalSection":
+			openSectionName, openSectionLine = "", 0
+		case line == "EndGlobal":
+			closeSection()
+		}
+
+		// a new section or the end of the global block ends an unterminated section
+		if strings.HasPrefix(line, "GlobalSection(") || line == "EndGlobal" {
+			isSolutionConfigurationPlatformsSection = false
+			isProjectConfigurationPlatformsSection = false
+			isNestedProjectsSection = false
+		}
+
 		// Projects
-		if matches := regexp.MustCompile(solutionProjectsPattern).FindStringSubmatch(line); len(matches) == 5 {
+		if matches := solutionProjectsRegexp.FindStringSubmatch(line); len(matches) == 5 {
 			ID := strings.ToUpper(matches[1])
 			projectName := matches[2]
 			projectID := strings.ToUpper(matches[4])
 			projectRelativePth := utility.FixWindowsPath(matches[3])
 			projectPth := filepath.Join(solutionDir, projectRelativePth)
 
+			openProjectName = projectName
+			currentProjectID = ""
+			isProjectDependenciesSection = false
+
+			if definedLine, ok := definedIDs[projectID]; ok {
+				solution.addDiagnostic(lineNumber, SeverityError, "duplicate project GUID ({%s}) of project (%s), already defined at line %d, skipping...", projectID, projectName, definedLine)
+				continue
+			}
+			definedIDs[projectID] = lineNumber
+
 			if strings.HasSuffix(projectPth, constants.CSProjExt) ||
 				strings.HasSuffix(projectPth, constants.SHProjExt) ||
 				strings.HasSuffix(projectPth, constants.FSProjExt) {
 
+				projectPth, warning := fileSystem.ResolvePath(projectPth)
+				if warning != "" {
+					solution.addDiagnostic(lineNumber, SeverityWarning, "project (%s): %s", projectName, warning)
+				}
+
 				project := project.Model{
 					ID:   projectID,
 					Name: projectName,
 					Pth:  projectPth,
 
-					ConfigMap: map[string]string{},
-					Configs:   map[string]project.ConfigurationPlatformModel{},
+					ConfigMap:      map[string]string{},
+					ConfigMappings: map[string]project.ConfigMapping{},
+					Configs:        map[string]project.ConfigurationPlatformModel{},
 				}
 				solution.ProjectMap[projectID] = project
+				solution.projectLines[projectID] = lineNumber
+				currentProjectID = projectID
+			} else if ID == SolutionFolderTypeID {
+				folderMap[projectID] = Folder{ID: projectID, Name: projectName}
 			}
 
 			solution.ID = ID
 
 			continue
+		} else if strings.HasPrefix(line, "Project(") {
+			solution.addDiagnostic(lineNumber, SeverityWarning, "malformed project definition (%s), skipping...", line)
+			openProjectName, openProjectLine = "", 0
+			currentProjectID = ""
+			continue
+		}
+
+		// ProjectSection(ProjectDependencies) = postProject
+		if currentProjectID != "" {
+			if match := projectEndRegexp.FindString(line); match != "" {
+				currentProjectID = ""
+				isProjectDependenciesSection = false
+				continue
+			}
+
+			// an unterminated project ends at the Global section
+			if line == "Global" {
+				currentProjectID = ""
+				isProjectDependenciesSection = false
+			} else {
+				if isProjectDependenciesSection {
+					if match := projectDependenciesSectionEndRegexp.FindString(line); match != "" {
+						isProjectDependenciesSection = false
+						continue
+					}
+
+					if matches := projectDependencyRegexp.FindStringSubmatch(line); len(matches) == 2 {
+						project := solution.ProjectMap[currentProjectID]
+						project.DependencyProjectIDs = append(project.DependencyProjectIDs, strings.ToUpper(matches[1]))
+						solution.ProjectMap[currentProjectID] = project
+					} else if line != "" {
+						solution.addDiagnostic(lineNumber, SeverityWarning, "malformed project dependency (%s), skipping...", line)
+					}
+					continue
+				}
+
+				if match := projectDependenciesSectionStartRegexp.FindString(line); match != "" {
+					isProjectDependenciesSection = true
+				}
+				continue
+			}
 		}
 
 		// GlobalSection(SolutionConfigurationPlatforms) = preSolution
 		if isSolutionConfigurationPlatformsSection {
-			if match := regexp.MustCompile(solutionConfigurationPlatformsSectionEndPattern).FindString(line); match != "" {
+			if match := solutionConfigurationPlatformsSectionEndRegexp.FindString(line); match != "" {
 				isSolutionConfigurationPlatformsSection = false
 				continue
 			}
 		}
 
-		if match := regexp.MustCompile(solutionConfigurationPlatformsSectionStartPattern).FindString(line); match != "" {
+		if match := solutionConfigurationPlatformsSectionStartRegexp.FindString(line); match != "" {
 			isSolutionConfigurationPlatformsSection = true
 			continue
 		}
 
 		if isSolutionConfigurationPlatformsSection {
-			if matches := regexp.MustCompile(solutionConfigurationPlatformPattern).FindStringSubmatch(line); len(matches) == 5 {
+			if matches := solutionConfigurationPlatformRegexp.FindStringSubmatch(line); len(matches) == 5 {
 				configuration := matches[1]
 				platform := matches[2]
 
@@ -133,44 +512,95 @@ func analyzeSolution(pth string, analyzeProjects bool) (Model, error) {
 
 				solution.ConfigMap[utility.ToConfig(configuration, platform)] = utility.ToConfig(mappedConfiguration, mappedPlatform)
 
+				continue
+			} else if line != "" {
+				solution.addDiagnostic(lineNumber, SeverityWarning, "malformed solution configuration (%s), skipping...", line)
+				continue
+			}
+		}
+
+		// GlobalSection(NestedProjects) = preSolution
+		if isNestedProjectsSection {
+			if match := nestedProjectsSectionEndRegexp.FindString(line); match != "" {
+				isNestedProjectsSection = false
+				continue
+			}
+		}
+
+		if match := nestedProjectsSectionStartRegexp.FindString(line); match != "" {
+			isNestedProjectsSection = true
+			continue
+		}
+
+		if isNestedProjectsSection {
+			if matches := nestedProjectRegexp.FindStringSubmatch(line); len(matches) == 3 {
+				parentIDs[strings.ToUpper(matches[1])] = strings.ToUpper(matches[2])
+				continue
+			} else if line != "" {
+				solution.addDiagnostic(lineNumber, SeverityWarning, "malformed nested project (%s), skipping...", line)
 				continue
 			}
 		}
 
 		// GlobalSection(ProjectConfigurationPlatforms) = postSolution
 		if isProjectConfigurationPlatformsSection {
-			if match := regexp.MustCompile(projectConfigurationPlatformsSectionEndPattern).FindString(line); match != "" {
+			if match := projectConfigurationPlatformsSectionEndRegexp.FindString(line); match != "" {
 				isProjectConfigurationPlatformsSection = false
 				continue
 			}
 		}
 
-		if match := regexp.MustCompile(projectConfigurationPlatformsSectionStartPattern).FindString(line); match != "" {
+		if match := projectConfigurationPlatformsSectionStartRegexp.FindString(line); match != "" {
 			isProjectConfigurationPlatformsSection = true
 			continue
 		}
 
 		if isProjectConfigurationPlatformsSection {
-			if matches := regexp.MustCompile(projectConfigurationPlatformPattern).FindStringSubmatch(line); len(matches) == 6 {
+			if matches := projectConfigurationPlatformRegexp.FindStringSubmatch(line); len(matches) == 7 {
 				projectID := strings.ToUpper(matches[1])
 
 				project, found := solution.ProjectMap[projectID]
 				if !found {
+					if _, defined := definedIDs[projectID]; !defined {
+						solution.addDiagnostic(lineNumber, SeverityWarning, "configuration of unknown project ({%s}), skipping...", projectID)
+					}
 					continue
 				}
 
 				solutionConfiguration := matches[2]
 				solutionPlatform := matches[3]
-				projectConfiguration := matches[4]
-				projectPlatform := matches[5]
+				flag := matches[4]
+				projectConfiguration := matches[5]
+				projectPlatform := matches[6]
 				if projectPlatform == "Any CPU" {
 					projectPlatform = "AnyCPU"
 				}
 
-				project.ConfigMap[utility.ToConfig(solutionConfiguration, solutionPlatform)] = utility.ToConfig(projectConfiguration, projectPlatform)
+				solutionConfig := utility.ToConfig(solutionConfiguration, solutionPlatform)
+				projectConfig := utility.ToConfig(projectConfiguration, projectPlatform)
+
+				// ActiveCfg defines the project config, Build.0 and Deploy.0 mark the project for build and deploy
+				mapping := project.ConfigMappings[solutionConfig]
+				switch flag {
+				case "ActiveCfg":
+					mapping.Config = projectConfig
+				case "Build.0":
+					mapping.Build = true
+				case "Deploy.0":
+					mapping.Deploy = true
+				}
+				if mapping.Config == "" {
+					mapping.Config = projectConfig
+				}
+
+				project.ConfigMappings[solutionConfig] = mapping
+				project.ConfigMap[solutionConfig] = mapping.Config
 
 				solution.ProjectMap[projectID] = project
 
+				continue
+			} else if line != "" {
+				solution.addDiagnostic(lineNumber, SeverityWarning, "malformed project configuration (%s), skipping...", line)
 				continue
 			}
 		}
@@ -178,25 +608,34 @@ func analyzeSolution(pth string, analyzeProjects bool) (Model, error) {
 	if err := scanner.Err(); err != nil {
 		return Model{}, err
 	}
+	closeProject()
+	closeSection()
 
-	if analyzeProjects {
-		projectMap := map[string]project.Model{}
+	// shared projects are not built, they do not have configurations
+	for _, proj := range sortedProjects(solution.ProjectMap) {
+		if len(proj.ConfigMap) == 0 && !strings.HasSuffix(proj.Pth, constants.SHProjExt) {
+			solution.addDiagnostic(solution.projectLines[proj.ID], SeverityWarning, "project (%s) is missing from ProjectConfigurationPlatforms, it is not built in any solution configuration", proj.Name)
+		}
+	}
 
-		for projectID, proj := range solution.ProjectMap {
-			projectDefinition, err := project.New(proj.Pth)
-			if err != nil {
-				return Model{}, fmt.Errorf("failed to analyze project (%s), error: %s", proj.Pth, err)
-			}
+	solution.setFolders(folderMap, parentIDs)
 
-			projectDefinition.Name = proj.Name
-			projectDefinition.Pth = proj.Pth
-			projectDefinition.ConfigMap = proj.ConfigMap
+	// only the writer needs the lossless Document, it is parsed on first use
+	solution.content = content
 
-			projectMap[projectID] = projectDefinition
-		}
+	return solution, nil
+}
 
-		solution.ProjectMap = projectMap
+// solutionProperties returns the global properties msbuild defines when building a project of the solution.
+func solutionProperties(solutionPth string) map[string]string {
+	fileName := filepath.Base(solutionPth)
+	ext := filepath.Ext(solutionPth)
+
+	return map[string]string{
+		"SolutionDir":      strings.TrimSuffix(filepath.Dir(solutionPth), "/") + "/",
+		"SolutionPath":     solutionPth,
+		"SolutionFileName": fileName,
+		"SolutionName":     strings.TrimSuffix(fileName, ext),
+		"SolutionExt":      ext,
 	}
-
-	return solution, nil
 }
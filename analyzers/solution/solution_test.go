@@ -1,12 +1,17 @@
 package solution
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/utility"
 	"github.com/stretchr/testify/require"
 )
 
@@ -482,3 +487,495 @@ func TestAnalyzePCLSolution(t *testing.T) {
 		}
 	}
 }
+
+func TestAnalyzeSolutionXML(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	pth := filepath.Join(tmpDir, "solution.slnx")
+	require.NoError(t, fileutil.WriteStringToFile(pth, xmlTestSolutionContent))
+
+	t.Log("xml solution test")
+	{
+		solution, err := analyzeSolution(pth, false)
+		require.NoError(t, err)
+		require.Equal(t, pth, solution.Pth)
+		require.Equal(t, "solution", solution.Name)
+
+		require.Equal(t, map[string]string{
+			"Debug|Any CPU":   "Debug|Any CPU",
+			"Debug|iPhone":    "Debug|iPhone",
+			"Release|Any CPU": "Release|Any CPU",
+			"Release|iPhone":  "Release|iPhone",
+		}, solution.ConfigMap)
+
+		// the not C#/F# projects are skipped
+		require.Equal(t, 3, len(solution.ProjectMap))
+
+		iosProject, ok := solution.ProjectMap["90F3C584-FD69-4926-9903-6B9771847782"]
+		require.True(t, ok)
+		require.Equal(t, "App.iOS", iosProject.Name)
+		require.Equal(t, filepath.Join(tmpDir, "src/App.iOS/App.iOS.csproj"), iosProject.Pth)
+		require.Equal(t, map[string]string{
+			"Debug|Any CPU":   "Debug|iPhoneSimulator",
+			"Debug|iPhone":    "Debug|iPhone",
+			"Release|Any CPU": "Release|iPhoneSimulator",
+			"Release|iPhone":  "Release|iPhone",
+		}, iosProject.ConfigMap)
+
+		appProjectID := projectIDFromPath("src/App/App.csproj")
+		appProject, ok := solution.ProjectMap[appProjectID]
+		require.True(t, ok)
+		require.Equal(t, "App", appProject.Name)
+		require.Equal(t, map[string]string{
+			"Debug|Any CPU":   "Debug|AnyCPU",
+			"Debug|iPhone":    "Debug|iPhone",
+			"Release|Any CPU": "Release|AnyCPU",
+			"Release|iPhone":  "Release|iPhone",
+		}, appProject.ConfigMap)
+
+		testProject, ok := solution.ProjectMap[projectIDFromPath("tests/App.Tests/App.Tests.csproj")]
+		require.True(t, ok)
+		require.Equal(t, "Tests", testProject.Name)
+		require.Equal(t, map[string]string{
+			"Debug|Any CPU":   "Debug|AnyCPU",
+			"Debug|iPhone":    "Debug|iPhone",
+			"Release|Any CPU": "Debug|AnyCPU",
+			"Release|iPhone":  "Debug|iPhone",
+		}, testProject.ConfigMap)
+		require.Equal(t, project.ConfigMapping{Config: "Debug|AnyCPU", Build: true}, testProject.ConfigMappings["Release|Any CPU"])
+		require.Equal(t, project.ConfigMapping{Config: "Debug|iPhone", Build: false}, testProject.ConfigMappings["Release|iPhone"])
+		require.True(t, testProject.BuildEnabled("Release|Any CPU"))
+		require.False(t, testProject.BuildEnabled("Release|iPhone"))
+	}
+
+	t.Log("it maps the solution platforms to AnyCPU, if the project does not define them")
+	{
+		projectPth := filepath.Join(tmpDir, "src/App/App.csproj")
+		require.NoError(t, os.MkdirAll(filepath.Dir(projectPth), 0777))
+		require.NoError(t, fileutil.WriteStringToFile(projectPth, anyCPUTestProjectContent))
+
+		content := `<Solution>
+  <Configurations>
+    <Platform Name="Any CPU" />
+    <Platform Name="iPhone" />
+  </Configurations>
+  <Project Path="src/App/App.csproj" />
+</Solution>`
+		xmlPth := filepath.Join(tmpDir, "app.slnx")
+		require.NoError(t, fileutil.WriteStringToFile(xmlPth, content))
+
+		solution, err := analyzeSolution(xmlPth, true)
+		require.NoError(t, err)
+
+		appProject, ok := solution.ProjectMap[projectIDFromPath("src/App/App.csproj")]
+		require.True(t, ok)
+		require.Equal(t, map[string]string{
+			"Debug|Any CPU":   "Debug|AnyCPU",
+			"Debug|iPhone":    "Debug|AnyCPU",
+			"Release|Any CPU": "Release|AnyCPU",
+			"Release|iPhone":  "Release|AnyCPU",
+		}, appProject.ConfigMap)
+	}
+}
+
+func TestAnalyzeSolutionFilter(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	solutionPth := filepath.Join(tmpDir, "solution.slnx")
+	require.NoError(t, fileutil.WriteStringToFile(solutionPth, xmlTestSolutionContent))
+
+	t.Log("solution filter test")
+	{
+		pth := filepath.Join(tmpDir, "filters", "app.slnf")
+		require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0777))
+		require.NoError(t, fileutil.WriteStringToFile(pth, filterTestSolutionContent))
+
+		filter, err := ParseSolutionFilter(pth)
+		require.NoError(t, err)
+		require.Equal(t, solutionPth, filter.SolutionPth)
+		require.Equal(t, []string{"src/App.iOS/App.iOS.csproj", "src/App/App.csproj"}, filter.Projects)
+
+		solution, err := analyzeSolution(pth, false)
+		require.NoError(t, err)
+		require.Equal(t, pth, solution.Pth)
+		require.Equal(t, "app", solution.Name)
+		require.Equal(t, 4, len(solution.ConfigMap))
+
+		require.Equal(t, 2, len(solution.ProjectMap))
+		_, ok := solution.ProjectMap["90F3C584-FD69-4926-9903-6B9771847782"]
+		require.True(t, ok)
+		_, ok = solution.ProjectMap[projectIDFromPath("src/App/App.csproj")]
+		require.True(t, ok)
+	}
+
+	t.Log("it fails if the filtered project is not in the solution")
+	{
+		pth := filepath.Join(tmpDir, "missing.slnf")
+		content := `{"solution": {"path": "solution.slnx", "projects": ["src\\Missing\\Missing.csproj"]}}`
+		require.NoError(t, fileutil.WriteStringToFile(pth, content))
+
+		_, err := analyzeSolution(pth, false)
+		require.Error(t, err)
+	}
+
+	t.Log("it fails if the filter does not refer to a solution")
+	{
+		_, err := ParseSolutionFilterContent(filepath.Join(tmpDir, "invalid.slnf"), `{"solution": {"path": "App.csproj"}}`)
+		require.Error(t, err)
+	}
+}
+
+func TestAnalyzeSolutionFolders(t *testing.T) {
+	t.Log("solution folders test")
+	{
+		pth := tmpSolutionWithContent(t, foldersTestSolutionContent)
+		defer func() {
+			require.NoError(t, os.Remove(pth))
+		}()
+
+		solution, err := analyzeSolution(pth, false)
+		require.NoError(t, err)
+
+		// solution folders are not projects
+		require.Equal(t, 4, len(solution.ProjectMap))
+		require.Equal(t, 4, len(solution.FolderMap))
+		require.Equal(t, []string{"Apps", "Apps/Android", "Apps/iOS", "Tests"}, solution.FolderPaths())
+
+		rootFolders := solution.RootFolders()
+		require.Equal(t, 2, len(rootFolders))
+		require.Equal(t, "Apps", rootFolders[0].Name)
+		require.Equal(t, []string{"22222222-2222-2222-2222-222222222222", "33333333-3333-3333-3333-333333333333"}, rootFolders[0].FolderIDs)
+		require.Equal(t, []string{}, rootFolders[0].ProjectIDs)
+
+		android, ok := solution.FolderByPath("apps/android/")
+		require.True(t, ok)
+		require.Equal(t, "22222222-2222-2222-2222-222222222222", android.ID)
+		require.Equal(t, "Apps/Android", android.Path)
+		require.Equal(t, "11111111-1111-1111-1111-111111111111", android.ParentID)
+		require.Equal(t, []string{"AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"}, android.ProjectIDs)
+
+		require.Equal(t, "Apps/Android", solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].SolutionFolder)
+		require.Equal(t, "Apps/iOS", solution.ProjectMap["BBBBBBBB-BBBB-BBBB-BBBB-BBBBBBBBBBBB"].SolutionFolder)
+		require.Equal(t, "Tests", solution.ProjectMap["CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC"].SolutionFolder)
+		require.Equal(t, "", solution.ProjectMap["DDDDDDDD-DDDD-DDDD-DDDD-DDDDDDDDDDDD"].SolutionFolder)
+
+		projectIDs, err := solution.ProjectIDsUnderFolder("Apps")
+		require.NoError(t, err)
+		require.Equal(t, []string{"AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA", "BBBBBBBB-BBBB-BBBB-BBBB-BBBBBBBBBBBB"}, projectIDs)
+
+		_, err = solution.ProjectIDsUnderFolder("Libraries")
+		require.Error(t, err)
+	}
+
+	t.Log("xml solution folders test")
+	{
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, os.RemoveAll(tmpDir))
+		}()
+
+		content := `<Solution>
+  <Folder Name="/Apps/Android/">
+    <Project Path="App.Droid/App.Droid.csproj" />
+  </Folder>
+  <Folder Name="/Tests/" />
+  <Project Path="App/App.csproj" />
+</Solution>`
+		pth := filepath.Join(tmpDir, "solution.slnx")
+		require.NoError(t, fileutil.WriteStringToFile(pth, content))
+
+		solution, err := analyzeSolution(pth, false)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Apps", "Apps/Android", "Tests"}, solution.FolderPaths())
+
+		apps, ok := solution.FolderByPath("Apps")
+		require.True(t, ok)
+		require.Equal(t, "", apps.ParentID)
+		require.Equal(t, 1, len(apps.FolderIDs))
+
+		require.Equal(t, "Apps/Android", solution.ProjectMap[projectIDFromPath("App.Droid/App.Droid.csproj")].SolutionFolder)
+		require.Equal(t, "", solution.ProjectMap[projectIDFromPath("App/App.csproj")].SolutionFolder)
+	}
+}
+
+func TestAnalyzeSolutionBuildFlags(t *testing.T) {
+	t.Log("it parses the ActiveCfg, Build.0 and Deploy.0 flags")
+	{
+		pth := tmpSolutionWithContent(t, buildFlagsTestSolutionContent)
+		defer func() {
+			require.NoError(t, os.Remove(pth))
+		}()
+
+		solution, err := analyzeSolution(pth, false)
+		require.NoError(t, err)
+
+		app := solution.ProjectMap["90F3C584-FD69-4926-9903-6B9771847782"]
+		require.Equal(t, map[string]string{
+			"Debug|iPhone":   "Debug|iPhone",
+			"Release|iPhone": "Release|iPhone",
+		}, app.ConfigMap)
+		require.Equal(t, map[string]project.ConfigMapping{
+			"Debug|iPhone":   {Config: "Debug|iPhone", Build: true, Deploy: true},
+			"Release|iPhone": {Config: "Release|iPhone", Build: true},
+		}, app.ConfigMappings)
+		require.True(t, app.BuildEnabled("Release|iPhone"))
+
+		// mapped, but unchecked for build in Release|iPhone
+		tests := solution.ProjectMap["ED150913-76EB-446F-8B78-DC77E5795703"]
+		require.Equal(t, map[string]string{
+			"Debug|iPhone":   "Debug|iPhone",
+			"Release|iPhone": "Release|AnyCPU",
+		}, tests.ConfigMap)
+		require.Equal(t, project.ConfigMapping{Config: "Release|AnyCPU"}, tests.ConfigMappings["Release|iPhone"])
+		require.True(t, tests.BuildEnabled("Debug|iPhone"))
+		require.False(t, tests.BuildEnabled("Release|iPhone"))
+		require.False(t, tests.BuildEnabled("Release|iPhoneSimulator"))
+	}
+}
+
+func TestAnalyzeSolutionDiagnostics(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	pth := tmpSolutionWithContentInDir(t, diagnosticsTestSolutionContent, tmpDir)
+
+	t.Log("it reports the duplicate project GUIDs as diagnostics")
+	{
+		solution, err := New(pth, false)
+		require.NoError(t, err)
+
+		errs := solution.Errors()
+		require.Equal(t, 3, len(errs))
+		require.Equal(t, Diagnostic{Pth: pth, Line: 5, Severity: SeverityError, Message: "duplicate project GUID ({AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}) of project (App.Copy), already defined at line 3, skipping..."}, errs[0])
+		require.Equal(t, "App.iOS", solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].Name)
+	}
+
+	t.Log("it reports the unterminated blocks as diagnostics")
+	{
+		content := `
+Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "App", "App\App.csproj", "{AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}"
+Global
+	GlobalSection(SolutionConfigurationPlatforms) = preSolution
+		Release|Any CPU = Release|Any CPU
+	EndGlobalSection
+EndGlobal
+`
+		unterminatedPth := filepath.Join(tmpDir, "unterminated.sln")
+		require.NoError(t, fileutil.WriteStringToFile(unterminatedPth, content))
+
+		solution, err := New(unterminatedPth, false)
+		require.NoError(t, err)
+		require.Equal(t, []Diagnostic{
+			{Pth: unterminatedPth, Line: 3, Severity: SeverityError, Message: "project (App) is not terminated with EndProject"},
+		}, solution.Errors())
+		require.Equal(t, map[string]string{"Release|Any CPU": "Release|Any CPU"}, solution.ConfigMap)
+	}
+
+	t.Log("it reports the problems as diagnostics in lenient mode")
+	{
+		solution, err := NewLenient(pth, false)
+		require.NoError(t, err)
+
+		require.Equal(t, []Diagnostic{
+			{Pth: pth, Line: 5, Severity: SeverityError, Message: "duplicate project GUID ({AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}) of project (App.Copy), already defined at line 3, skipping..."},
+			{Pth: pth, Line: 9, Severity: SeverityWarning, Message: `malformed project definition (Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Broken"), skipping...`},
+			{Pth: pth, Line: 10, Severity: SeverityError, Message: "project (App.Tests) is not terminated with EndProject"},
+			{Pth: pth, Line: 14, Severity: SeverityWarning, Message: "malformed solution configuration (Release), skipping..."},
+			{Pth: pth, Line: 20, Severity: SeverityWarning, Message: "configuration of unknown project ({DDDDDDDD-DDDD-DDDD-DDDD-DDDDDDDDDDDD}), skipping..."},
+			{Pth: pth, Line: 16, Severity: SeverityError, Message: "global section (ProjectConfigurationPlatforms) is not terminated with EndGlobalSection"},
+			{Pth: pth, Line: 7, Severity: SeverityWarning, Message: "project (App.Droid) is missing from ProjectConfigurationPlatforms, it is not built in any solution configuration"},
+		}, solution.Diagnostics)
+		require.Equal(t, 3, len(solution.Errors()))
+		require.Equal(t, 4, len(solution.Warnings()))
+		require.Equal(t, pth+"(5): error: duplicate project GUID ({AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}) of project (App.Copy), already defined at line 3, skipping...", solution.Diagnostics[0].String())
+
+		// the rest of the solution is usable
+		require.Equal(t, map[string]string{"Release|iPhone": "Release|iPhone"}, solution.ConfigMap)
+		require.Equal(t, 3, len(solution.ProjectMap))
+		require.Equal(t, "App.iOS", solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].Name)
+		require.True(t, solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].BuildEnabled("Release|iPhone"))
+		require.Equal(t, "Release|iPhone", solution.ProjectMap["CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC"].ConfigMap["Release|iPhone"])
+	}
+
+	t.Log("it reports the projects which fail to analyze in lenient mode")
+	{
+		content := `
+Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "App", "App\App.csproj", "{AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}"
+EndProject
+Global
+	GlobalSection(SolutionConfigurationPlatforms) = preSolution
+		Release|Any CPU = Release|Any CPU
+	EndGlobalSection
+	GlobalSection(ProjectConfigurationPlatforms) = postSolution
+		{AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}.Release|Any CPU.ActiveCfg = Release|Any CPU
+	EndGlobalSection
+EndGlobal
+`
+		pth := tmpSolutionWithContentInDir(t, content, tmpDir)
+
+		_, err := New(pth, true)
+		require.Error(t, err)
+
+		solution, err := NewLenient(pth, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(solution.Errors()))
+		require.Equal(t, 3, solution.Errors()[0].Line)
+		require.Contains(t, solution.Errors()[0].Message, "failed to analyze project")
+
+		proj := solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"]
+		require.Equal(t, "App", proj.Name)
+		require.Equal(t, constants.SDKUnknown, proj.SDK)
+	}
+}
+
+func TestAnalyzeSolutionWithOptions(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	content := `<Solution>
+  <Configurations>
+    <Platform Name="Any CPU" />
+  </Configurations>
+`
+	for i := 0; i < 8; i++ {
+		projectPth := filepath.Join(tmpDir, "src", fmt.Sprintf("App%d", i), fmt.Sprintf("App%d.csproj", i))
+		require.NoError(t, os.MkdirAll(filepath.Dir(projectPth), 0777))
+		require.NoError(t, fileutil.WriteStringToFile(projectPth, anyCPUTestProjectContent))
+		content += fmt.Sprintf("  <Project Path=\"src/App%d/App%d.csproj\" />\n", i, i)
+	}
+	content += `</Solution>`
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "src", "Directory.Build.props"), `<Project><PropertyGroup><Version>1.0</Version></PropertyGroup></Project>`))
+
+	pth := filepath.Join(tmpDir, "app.slnx")
+	require.NoError(t, fileutil.WriteStringToFile(pth, content))
+
+	expected, err := New(pth, true)
+	require.NoError(t, err)
+	require.Equal(t, 8, len(expected.ProjectMap))
+
+	t.Log("it analyzes the projects in parallel")
+	{
+		solution, err := NewWithOptions(pth, AnalyzeOptions{LoadProjects: true, Workers: 3})
+		require.NoError(t, err)
+		require.Equal(t, expected.ProjectMap, solution.ProjectMap)
+	}
+
+	t.Log("it stores and loads the analyzed projects in the cache directory")
+	{
+		cacheDir := filepath.Join(tmpDir, "cache")
+		options := AnalyzeOptions{LoadProjects: true, CacheDir: cacheDir}
+
+		solution, err := NewWithOptions(pth, options)
+		require.NoError(t, err)
+		require.Equal(t, expected.ProjectMap, solution.ProjectMap)
+
+		entries, err := os.ReadDir(cacheDir)
+		require.NoError(t, err)
+		require.Equal(t, 8, len(entries))
+
+		cached, err := NewWithOptions(pth, options)
+		require.NoError(t, err)
+		require.Equal(t, expected.ProjectMap, cached.ProjectMap)
+	}
+}
+
+func TestAnalyzeSolutionFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.slnx": {Data: []byte(`<Solution>
+  <Project Path="src/App/App.csproj" />
+</Solution>`)},
+		"src/App/App.csproj": {Data: []byte(anyCPUTestProjectContent)},
+		"Directory.Build.props": {Data: []byte(`<Project>
+  <PropertyGroup Condition="Exists('$(MSBuildThisFileDirectory)version.txt')">
+    <Version>2.0</Version>
+  </PropertyGroup>
+</Project>`)},
+		"version.txt": {Data: []byte("2.0")},
+	}
+
+	t.Log("it analyzes the solution and its projects from the fs.FS")
+	{
+		solution, err := NewFS(fsys, "app.slnx", true)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(utility.FSRoot, "app.slnx"), solution.Pth)
+		require.False(t, solution.FileSystem().IsOS())
+
+		appProject, ok := solution.ProjectMap[projectIDFromPath("src/App/App.csproj")]
+		require.True(t, ok)
+		require.Equal(t, filepath.Join(utility.FSRoot, "src", "App", "App.csproj"), appProject.Pth)
+		require.Equal(t, "Release|AnyCPU", appProject.ConfigMap["Release|Any CPU"])
+		require.Equal(t, filepath.Join(utility.FSRoot, "src", "App", "bin", "Release"), appProject.Configs["Release|AnyCPU"].OutputDir)
+
+		require.Equal(t, 1, len(appProject.Imports))
+		require.Equal(t, filepath.Join(utility.FSRoot, "Directory.Build.props"), appProject.Imports[0].Pth)
+		require.Equal(t, "2.0", appProject.Configs["Release|AnyCPU"].Properties["Version"])
+	}
+
+	t.Log("it fails for missing solutions")
+	{
+		_, err := NewFS(fsys, "missing.sln", false)
+		require.Error(t, err)
+	}
+}
+
+func TestAnalyzeSolutionCaseInsensitivePaths(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.sln": {Data: []byte(`
+Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Core", "src\core\core.csproj", "{AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}"
+EndProject
+Global
+	GlobalSection(SolutionConfigurationPlatforms) = preSolution
+		Debug|Any CPU = Debug|Any CPU
+	EndGlobalSection
+	GlobalSection(ProjectConfigurationPlatforms) = postSolution
+		{AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}.Debug|Any CPU.ActiveCfg = Debug|Any CPU
+	EndGlobalSection
+EndGlobal
+`)},
+		"src/Core/Core.csproj": {Data: []byte(`<Project>
+  <Import Project="..\build\common.props" />
+  <PropertyGroup>
+    <Configuration Condition=" '$(Configuration)' == '' ">Debug</Configuration>
+  </PropertyGroup>
+</Project>`)},
+		"src/Build/Common.props": {Data: []byte(`<Project><PropertyGroup><Version>1.0</Version></PropertyGroup></Project>`)},
+	}
+
+	solution, err := NewFS(fsys, "app.sln", true)
+	require.NoError(t, err)
+	core := solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"]
+
+	t.Log("it resolves the project paths case-insensitively")
+	{
+		require.Equal(t, filepath.Join(utility.FSRoot, "src", "Core", "Core.csproj"), core.Pth)
+
+		warnings := solution.Warnings()
+		require.Equal(t, 1, len(warnings))
+		require.Equal(t, 3, warnings[0].Line)
+		require.Contains(t, warnings[0].Message, "differs only in case")
+	}
+
+	t.Log("it resolves the imports case-insensitively")
+	{
+		require.Equal(t, 1, len(core.Imports))
+		require.Equal(t, filepath.Join(utility.FSRoot, "src", "Build", "Common.props"), core.Imports[0].Pth)
+	}
+}
@@ -0,0 +1,411 @@
+package solution
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// Project type IDs of the solution file's project entries.
+const (
+	// CSharpProjectTypeID ...
+	CSharpProjectTypeID = "FAE04EC0-301F-11D3-BF4B-00C04F79EFBC"
+	// FSharpProjectTypeID ...
+	FSharpProjectTypeID = "F2A71F9B-5D33-465A-A702-920D77279786"
+	// SharedProjectTypeID ...
+	SharedProjectTypeID = "D954291E-2A0B-460D-934E-DC6B0785DB48"
+)
+
+const (
+	solutionConfigurationPlatformsSection = "SolutionConfigurationPlatforms"
+	projectConfigurationPlatformsSection  = "ProjectConfigurationPlatforms"
+	nestedProjectsSection                 = "NestedProjects"
+)
+
+// Document returns the solution file representation of the solution, used to write the solution.
+// Solutions parsed from .sln files keep their original content, for other solutions (like .slnx)
+// and the .sln files which can not be parsed losslessly a new document is generated.
+func (solution Model) Document() Document {
+	if document := solution.parsedDocument(); document != nil {
+		return document.copy()
+	}
+	return newDocument(solution)
+}
+
+// parsedDocument returns the solution's (possibly modified) .sln document, the .sln content is parsed on each call.
+// It returns nil for the other solution formats and if the content can not be parsed losslessly,
+// like the solutions with unterminated Project or GlobalSection blocks.
+func (solution Model) parsedDocument() *Document {
+	if solution.document != nil {
+		return solution.document
+	}
+	if solution.content == "" {
+		return nil
+	}
+
+	document, err := ParseDocumentContent(solution.content)
+	if err != nil {
+		log.Debugf("Failed to parse solution (%s), the solution is written from the analyzed model, error: %s", solution.Pth, err)
+		return nil
+	}
+	document.Pth = solution.Pth
+	return &document
+}
+
+// Write writes the solution in the text based (.sln) solution format to the given writer.
+func (solution Model) Write(writer io.Writer) error {
+	return solution.Document().Write(writer)
+}
+
+// WriteFile writes the solution in the text based (.sln) solution format to the given path,
+// the project paths are rewritten relative to the given path's directory.
+// The project paths differing from the project files in case are reported in the solution's Diagnostics.
+func (solution *Model) WriteFile(pth string) error {
+	absPth, err := pathutil.AbsPath(pth)
+	if err != nil {
+		return fmt.Errorf("Failed to expand path (%s), error: %s", pth, err)
+	}
+
+	document := solution.Document()
+
+	solutionDir := filepath.Dir(document.Pth)
+	targetDir := filepath.Dir(absPth)
+	if solutionDir != targetDir {
+		for i, documentProject := range document.Projects {
+			if strings.EqualFold(documentProject.TypeID, SolutionFolderTypeID) {
+				continue
+			}
+
+			// the analysis resolved the paths of the analyzed projects and reported their case mismatches
+			projectPth := filepath.Join(solutionDir, utility.FixWindowsPath(documentProject.Path))
+			if proj, ok := solution.ProjectMap[strings.ToUpper(documentProject.ID)]; ok && proj.Pth != "" {
+				projectPth = proj.Pth
+			} else {
+				resolvedPth, warning := solution.fileSystem.ResolvePath(projectPth)
+				if warning != "" {
+					solution.addDiagnostic(0, SeverityWarning, "project (%s): %s", documentProject.Name, warning)
+				}
+				projectPth = resolvedPth
+			}
+
+			relPth, err := filepath.Rel(targetDir, projectPth)
+			if err != nil {
+				return err
+			}
+			document.Projects[i].Path = strings.Replace(relPth, "/", `\`, -1)
+		}
+	}
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("Failed to create directory (%s), error: %s", targetDir, err)
+	}
+
+	document.Pth = absPth
+	return fileutil.WriteStringToFile(absPth, document.String())
+}
+
+// DeriveSolution returns a solution containing only the given projects, their transitive dependencies
+// and the solution folders containing them, the configurations and the unknown sections are kept.
+// The dependencies are collected from the project graph: the explicit solution dependencies and the analyzed projects' project references.
+func (solution Model) DeriveSolution(projectIDs ...string) (Model, error) {
+	graph := solution.ProjectGraph()
+	keptIDs := map[string]bool{}
+
+	queue := []string{}
+	for _, projectID := range projectIDs {
+		projectID = strings.ToUpper(projectID)
+		if _, ok := solution.ProjectMap[projectID]; !ok {
+			return Model{}, fmt.Errorf("project (%s) not found in solution (%s)", projectID, solution.Pth)
+		}
+		queue = append(queue, projectID)
+	}
+
+	for len(queue) > 0 {
+		projectID := queue[0]
+		queue = queue[1:]
+
+		if keptIDs[projectID] {
+			continue
+		}
+		keptIDs[projectID] = true
+
+		for _, dependencyID := range graph.Dependencies(projectID) {
+			if !keptIDs[dependencyID] {
+				queue = append(queue, dependencyID)
+			}
+		}
+	}
+
+	// keep the folders containing the kept projects
+	for folderID, folder := range solution.FolderMap {
+		for _, projectID := range folder.ProjectIDs {
+			if !keptIDs[projectID] {
+				continue
+			}
+			for id := folderID; id != "" && !keptIDs[id]; id = solution.FolderMap[id].ParentID {
+				keptIDs[id] = true
+			}
+		}
+	}
+
+	derived := solution
+	derived.ConfigMap = map[string]string{}
+	for solutionConfig, mappedConfig := range solution.ConfigMap {
+		derived.ConfigMap[solutionConfig] = mappedConfig
+	}
+
+	derived.ProjectMap = map[string]project.Model{}
+	for projectID, proj := range solution.ProjectMap {
+		if keptIDs[projectID] {
+			derived.ProjectMap[projectID] = copyProjectConfigMaps(proj)
+		}
+	}
+
+	derived.FolderMap = map[string]Folder{}
+	for folderID, folder := range solution.FolderMap {
+		if !keptIDs[folderID] {
+			continue
+		}
+		folder.FolderIDs = filterIDs(folder.FolderIDs, keptIDs)
+		folder.ProjectIDs = filterIDs(folder.ProjectIDs, keptIDs)
+		derived.FolderMap[folderID] = folder
+	}
+
+	if solutionDocument := solution.parsedDocument(); solutionDocument != nil {
+		removedIDs := map[string]bool{}
+		for _, documentProject := range solutionDocument.Projects {
+			if id := strings.ToUpper(documentProject.ID); !keptIDs[id] {
+				removedIDs[id] = true
+			}
+		}
+
+		document := solutionDocument.withoutProjects(removedIDs)
+		derived.document = &document
+	}
+	derived.content = ""
+
+	return derived, nil
+}
+
+// AddConfigMapping returns a solution with the given solution Configuration|Platform added,
+// the projects are mapped to the new solution config by the given Project ID - project config mapping,
+// the projects not in the map are not part of the new solution config.
+func (solution Model) AddConfigMapping(configuration, platform string, projectMappings map[string]project.ConfigMapping) (Model, error) {
+	solutionConfig := utility.ToConfig(configuration, platform)
+	if _, ok := solution.ConfigMap[solutionConfig]; ok {
+		return Model{}, fmt.Errorf("solution config (%s) already exists", solutionConfig)
+	}
+
+	projectIDs := []string{}
+	for projectID, mapping := range projectMappings {
+		if _, ok := solution.ProjectMap[strings.ToUpper(projectID)]; !ok {
+			return Model{}, fmt.Errorf("project (%s) not found in solution (%s)", projectID, solution.Pth)
+		}
+		if len(strings.Split(mapping.Config, "|")) != 2 {
+			return Model{}, fmt.Errorf("invalid project config (%s) for project (%s), expected Configuration|Platform", mapping.Config, projectID)
+		}
+		projectIDs = append(projectIDs, projectID)
+	}
+	sort.Strings(projectIDs)
+
+	modified := solution
+	modified.ConfigMap = map[string]string{solutionConfig: solutionConfig}
+	for config, mappedConfig := range solution.ConfigMap {
+		modified.ConfigMap[config] = mappedConfig
+	}
+
+	modified.ProjectMap = map[string]project.Model{}
+	for projectID, proj := range solution.ProjectMap {
+		modified.ProjectMap[projectID] = copyProjectConfigMaps(proj)
+	}
+
+	for _, projectID := range projectIDs {
+		mapping := projectMappings[projectID]
+		proj := modified.ProjectMap[strings.ToUpper(projectID)]
+		proj.ConfigMap[solutionConfig] = mapping.Config
+		proj.ConfigMappings[solutionConfig] = mapping
+		modified.ProjectMap[strings.ToUpper(projectID)] = proj
+	}
+
+	modified.content = ""
+	if solutionDocument := solution.parsedDocument(); solutionDocument != nil {
+		document := solutionDocument.copy()
+
+		idx := document.ensureSection(solutionConfigurationPlatformsSection, "preSolution")
+		document.Sections[idx].Lines = append(document.Sections[idx].Lines, solutionConfig+" = "+solutionConfig)
+
+		idx = document.ensureSection(projectConfigurationPlatformsSection, "postSolution")
+		for _, projectID := range projectIDs {
+			documentProjectID := strings.ToUpper(projectID)
+			if documentProject, ok := document.Project(projectID); ok {
+				documentProjectID = documentProject.ID
+			}
+
+			document.Sections[idx].Lines = append(document.Sections[idx].Lines, configMappingLines(documentProjectID, solutionConfig, projectMappings[projectID])...)
+		}
+
+		modified.document = &document
+	}
+
+	return modified, nil
+}
+
+// newDocument generates the solution file representation of the given solution.
+func newDocument(solution Model) Document {
+	document := Document{
+		Pth:        solution.Pth,
+		LineEnding: "\n",
+		Header: []string{
+			"",
+			"Microsoft Visual Studio Solution File, Format Version 12.00",
+			"# Visual Studio Version 17",
+		},
+	}
+
+	folders := []Folder{}
+	for _, folder := range solution.FolderMap {
+		folders = append(folders, folder)
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].Path < folders[j].Path })
+
+	for _, folder := range folders {
+		document.Projects = append(document.Projects, DocumentProject{
+			TypeID: SolutionFolderTypeID,
+			Name:   folder.Name,
+			Path:   folder.Name,
+			ID:     folder.ID,
+		})
+	}
+
+	projects := sortedProjects(solution.ProjectMap)
+	solutionDir := filepath.Dir(solution.Pth)
+	for _, proj := range projects {
+		pth := proj.Pth
+		if relPth, err := filepath.Rel(solutionDir, proj.Pth); err == nil {
+			pth = relPth
+		}
+
+		typeID := CSharpProjectTypeID
+		switch filepath.Ext(proj.Pth) {
+		case constants.FSProjExt:
+			typeID = FSharpProjectTypeID
+		case constants.SHProjExt:
+			typeID = SharedProjectTypeID
+		}
+
+		document.Projects = append(document.Projects, DocumentProject{
+			TypeID: typeID,
+			Name:   proj.Name,
+			Path:   strings.Replace(pth, "/", `\`, -1),
+			ID:     proj.ID,
+		})
+	}
+
+	solutionConfigs := []string{}
+	for config := range solution.ConfigMap {
+		solutionConfigs = append(solutionConfigs, config)
+	}
+	sort.Strings(solutionConfigs)
+
+	solutionConfigSection := DocumentSection{Name: solutionConfigurationPlatformsSection, Type: "preSolution"}
+	for _, config := range solutionConfigs {
+		solutionConfigSection.Lines = append(solutionConfigSection.Lines, config+" = "+solution.ConfigMap[config])
+	}
+
+	projectConfigSection := DocumentSection{Name: projectConfigurationPlatformsSection, Type: "postSolution"}
+	for _, proj := range projects {
+		for _, config := range solutionConfigs {
+			projectConfig, ok := proj.ConfigMap[config]
+			if !ok {
+				continue
+			}
+
+			mapping, ok := proj.ConfigMappings[config]
+			if !ok {
+				mapping = project.ConfigMapping{Config: projectConfig, Build: true}
+			}
+			projectConfigSection.Lines = append(projectConfigSection.Lines, configMappingLines(proj.ID, config, mapping)...)
+		}
+	}
+
+	document.Sections = []DocumentSection{solutionConfigSection, projectConfigSection}
+
+	nestedSection := DocumentSection{Name: nestedProjectsSection, Type: "preSolution"}
+	for _, folder := range folders {
+		if folder.ParentID != "" {
+			nestedSection.Lines = append(nestedSection.Lines, fmt.Sprintf("{%s} = {%s}", folder.ID, folder.ParentID))
+		}
+		for _, projectID := range folder.ProjectIDs {
+			nestedSection.Lines = append(nestedSection.Lines, fmt.Sprintf("{%s} = {%s}", projectID, folder.ID))
+		}
+	}
+	if len(nestedSection.Lines) > 0 {
+		document.Sections = append(document.Sections, nestedSection)
+	}
+
+	return document
+}
+
+// configMappingLines returns the ProjectConfigurationPlatforms entries of the given project config mapping.
+func configMappingLines(projectID, solutionConfig string, mapping project.ConfigMapping) []string {
+	// solution files use "Any CPU", project files use "AnyCPU"
+	projectConfig := mapping.Config
+	if strings.HasSuffix(projectConfig, "|AnyCPU") {
+		projectConfig = strings.TrimSuffix(projectConfig, "AnyCPU") + "Any CPU"
+	}
+
+	lines := []string{fmt.Sprintf("{%s}.%s.ActiveCfg = %s", projectID, solutionConfig, projectConfig)}
+	if mapping.Build {
+		lines = append(lines, fmt.Sprintf("{%s}.%s.Build.0 = %s", projectID, solutionConfig, projectConfig))
+	}
+	if mapping.Deploy {
+		lines = append(lines, fmt.Sprintf("{%s}.%s.Deploy.0 = %s", projectID, solutionConfig, projectConfig))
+	}
+	return lines
+}
+
+// copyProjectConfigMaps returns the project with copied config maps, to be modified without affecting the original project.
+func copyProjectConfigMaps(proj project.Model) project.Model {
+	configMap := map[string]string{}
+	for solutionConfig, projectConfig := range proj.ConfigMap {
+		configMap[solutionConfig] = projectConfig
+	}
+
+	configMappings := map[string]project.ConfigMapping{}
+	for solutionConfig, mapping := range proj.ConfigMappings {
+		configMappings[solutionConfig] = mapping
+	}
+
+	proj.ConfigMap = configMap
+	proj.ConfigMappings = configMappings
+	return proj
+}
+
+func sortedProjects(projectMap map[string]project.Model) []project.Model {
+	projects := []project.Model{}
+	for _, proj := range projectMap {
+		projects = append(projects, proj)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projectLess(projects[i], projects[j]) })
+	return projects
+}
+
+func filterIDs(ids []string, keptIDs map[string]bool) []string {
+	filtered := []string{}
+	for _, id := range ids {
+		if keptIDs[id] {
+			filtered = append(filtered, id)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,289 @@
+package solution
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDocumentContent(t *testing.T) {
+	t.Log("it writes back the parsed content as is")
+	{
+		document, err := ParseDocumentContent(writerTestSolutionContent)
+		require.NoError(t, err)
+		require.Equal(t, writerTestSolutionContent, document.String())
+
+		require.Equal(t, 4, len(document.Projects))
+		require.Equal(t, 5, len(document.Sections))
+
+		section, ok := document.Section("ExtensibilityGlobals")
+		require.True(t, ok)
+		require.Equal(t, "postSolution", section.Type)
+		require.Equal(t, []string{"SolutionGuid = {0D3B4B5E-1F2A-4C3D-8E9F-A0B1C2D3E4F5}"}, section.Lines)
+	}
+
+	t.Log("it keeps the byte order mark and the CRLF line endings")
+	{
+		content := byteOrderMark + strings.Replace(writerTestSolutionContent, "\n", "\r\n", -1)
+
+		document, err := ParseDocumentContent(content)
+		require.NoError(t, err)
+		require.True(t, document.ByteOrderMark)
+		require.Equal(t, "\r\n", document.LineEnding)
+		require.Equal(t, content, document.String())
+	}
+
+	t.Log("it fails for unterminated project")
+	{
+		content := `Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "App", "App\App.csproj", "{CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC}"
+Global
+EndGlobal
+`
+		_, err := ParseDocumentContent(content)
+		require.Error(t, err)
+	}
+
+	t.Log("it fails for unterminated global section")
+	{
+		content := `Global
+	GlobalSection(SolutionProperties) = preSolution
+		HideSolutionNode = FALSE
+EndGlobal
+`
+		_, err := ParseDocumentContent(content)
+		require.Error(t, err)
+	}
+}
+
+func TestDeriveSolution(t *testing.T) {
+	pth := tmpSolutionWithContent(t, writerTestSolutionContent)
+	defer func() {
+		require.NoError(t, os.Remove(pth))
+	}()
+
+	solution, err := analyzeSolution(pth, false)
+	require.NoError(t, err)
+
+	iOSProject := solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"]
+	iOSProject.ReferredProjectIDs = []string{"CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC"}
+	solution.ProjectMap[iOSProject.ID] = iOSProject
+
+	t.Log("it keeps the given projects, their references, folders and the unknown sections")
+	{
+		derived, err := solution.DeriveSolution("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+		require.NoError(t, err)
+
+		require.Equal(t, 2, len(derived.ProjectMap))
+		require.Contains(t, derived.ProjectMap, "AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA")
+		require.Contains(t, derived.ProjectMap, "CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC")
+		require.Equal(t, 1, len(derived.FolderMap))
+		require.Equal(t, []string{"AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"}, derived.FolderMap["11111111-1111-1111-1111-111111111111"].ProjectIDs)
+
+		// the original solution is not modified
+		require.Equal(t, 3, len(solution.ProjectMap))
+		require.Equal(t, 2, len(solution.FolderMap["11111111-1111-1111-1111-111111111111"].ProjectIDs))
+
+		content := derived.Document().String()
+		require.NotContains(t, content, "BBBBBBBB-BBBB-BBBB-BBBB-BBBBBBBBBBBB")
+		require.Contains(t, content, "ProjectSection(ProjectDependencies) = postProject")
+		require.Contains(t, content, "HideSolutionNode = FALSE")
+		require.Contains(t, content, "SolutionGuid = {0D3B4B5E-1F2A-4C3D-8E9F-A0B1C2D3E4F5}")
+		require.Contains(t, content, "{AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA} = {11111111-1111-1111-1111-111111111111}")
+	}
+
+	t.Log("it drops the folders without kept projects")
+	{
+		derived, err := solution.DeriveSolution("CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC")
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(derived.ProjectMap))
+		require.Equal(t, 0, len(derived.FolderMap))
+
+		document := derived.Document()
+		require.Equal(t, 1, len(document.Projects))
+		section, ok := document.Section("NestedProjects")
+		require.True(t, ok)
+		require.Equal(t, []string{}, section.Lines)
+	}
+
+	t.Log("it fails for unknown project")
+	{
+		_, err := solution.DeriveSolution("DDDDDDDD-DDDD-DDDD-DDDD-DDDDDDDDDDDD")
+		require.Error(t, err)
+	}
+}
+
+func TestAddConfigMapping(t *testing.T) {
+	pth := tmpSolutionWithContent(t, writerTestSolutionContent)
+	defer func() {
+		require.NoError(t, os.Remove(pth))
+	}()
+
+	solution, err := analyzeSolution(pth, false)
+	require.NoError(t, err)
+
+	t.Log("it adds the solution config and the project mappings")
+	{
+		modified, err := solution.AddConfigMapping("Store", "Any CPU", map[string]project.ConfigMapping{
+			"AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA": {Config: "Release|iPhone", Build: true, Deploy: true},
+			"CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC": {Config: "Release|AnyCPU", Build: false},
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, "Store|Any CPU", modified.ConfigMap["Store|Any CPU"])
+		require.Equal(t, "Release|iPhone", modified.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].ConfigMap["Store|Any CPU"])
+		require.True(t, modified.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].BuildEnabled("Store|Any CPU"))
+		require.False(t, modified.ProjectMap["CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC"].BuildEnabled("Store|Any CPU"))
+		require.False(t, modified.ProjectMap["BBBBBBBB-BBBB-BBBB-BBBB-BBBBBBBBBBBB"].BuildEnabled("Store|Any CPU"))
+
+		// the original solution is not modified
+		_, ok := solution.ConfigMap["Store|Any CPU"]
+		require.False(t, ok)
+		_, ok = solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].ConfigMap["Store|Any CPU"]
+		require.False(t, ok)
+
+		document := modified.Document()
+		section, ok := document.Section("SolutionConfigurationPlatforms")
+		require.True(t, ok)
+		require.Equal(t, []string{"Release|Any CPU = Release|Any CPU", "Store|Any CPU = Store|Any CPU"}, section.Lines)
+
+		content := document.String()
+		require.Contains(t, content, "{AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}.Store|Any CPU.ActiveCfg = Release|iPhone")
+		require.Contains(t, content, "{AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}.Store|Any CPU.Build.0 = Release|iPhone")
+		require.Contains(t, content, "{AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}.Store|Any CPU.Deploy.0 = Release|iPhone")
+		require.Contains(t, content, "{CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC}.Store|Any CPU.ActiveCfg = Release|Any CPU")
+		require.NotContains(t, content, "{CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC}.Store|Any CPU.Build.0")
+
+		// the written solution is parsed to the same mappings
+		reparsedPth := filepath.Join(filepath.Dir(pth), "modified.sln")
+		require.NoError(t, modified.WriteFile(reparsedPth))
+		defer func() {
+			require.NoError(t, os.Remove(reparsedPth))
+		}()
+
+		reparsed, err := analyzeSolution(reparsedPth, false)
+		require.NoError(t, err)
+		require.Equal(t, modified.ConfigMap, reparsed.ConfigMap)
+		require.Equal(t, modified.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].ConfigMappings, reparsed.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].ConfigMappings)
+	}
+
+	t.Log("it fails for existing solution config")
+	{
+		_, err := solution.AddConfigMapping("Release", "Any CPU", nil)
+		require.Error(t, err)
+	}
+
+	t.Log("it fails for unknown project")
+	{
+		_, err := solution.AddConfigMapping("Store", "Any CPU", map[string]project.ConfigMapping{
+			"DDDDDDDD-DDDD-DDDD-DDDD-DDDDDDDDDDDD": {Config: "Release|AnyCPU", Build: true},
+		})
+		require.Error(t, err)
+	}
+}
+
+func TestWriteSolution(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	t.Log("it rewrites the project paths relative to the written solution")
+	{
+		pth := tmpSolutionWithContentInDir(t, writerTestSolutionContent, tmpDir)
+		solution, err := analyzeSolution(pth, false)
+		require.NoError(t, err)
+
+		writtenPth := filepath.Join(tmpDir, "ci", "solution.sln")
+		require.NoError(t, solution.WriteFile(writtenPth))
+
+		content, err := fileutil.ReadStringFromFile(writtenPth)
+		require.NoError(t, err)
+		require.Contains(t, content, `"App.iOS", "..\App.iOS\App.iOS.csproj"`)
+		require.Contains(t, content, `"Apps", "Apps"`)
+
+		written, err := analyzeSolution(writtenPth, false)
+		require.NoError(t, err)
+		require.Equal(t, solution.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].Pth, written.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].Pth)
+	}
+
+	t.Log("it resolves the paths of the not analyzed projects through the solution's file system")
+	{
+		libPth := filepath.Join(tmpDir, "Lib", "Lib.vbproj")
+		require.NoError(t, os.MkdirAll(filepath.Dir(libPth), 0777))
+		require.NoError(t, fileutil.WriteStringToFile(libPth, "<Project />"))
+
+		pth := tmpSolutionWithContentInDir(t, `
+Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{F184B08F-C81C-45F6-A57F-5ABD9991F28F}") = "Lib", "lib\lib.vbproj", "{EEEEEEEE-EEEE-EEEE-EEEE-EEEEEEEEEEEE}"
+EndProject
+Global
+EndGlobal
+`, tmpDir)
+		solution, err := analyzeSolution(pth, false)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(solution.Warnings()))
+
+		writtenPth := filepath.Join(tmpDir, "ci", "lib.sln")
+		require.NoError(t, solution.WriteFile(writtenPth))
+
+		content, err := fileutil.ReadStringFromFile(writtenPth)
+		require.NoError(t, err)
+		require.Contains(t, content, `"Lib", "..\Lib\Lib.vbproj"`)
+
+		warnings := solution.Warnings()
+		require.Equal(t, 1, len(warnings))
+		require.Contains(t, warnings[0].Message, "differs only in case")
+	}
+
+	t.Log("it generates the solution file for xml solutions")
+	{
+		pth := filepath.Join(tmpDir, "solution.slnx")
+		require.NoError(t, fileutil.WriteStringToFile(pth, xmlTestSolutionContent))
+
+		solution, err := analyzeSolution(pth, false)
+		require.NoError(t, err)
+
+		var buffer bytes.Buffer
+		require.NoError(t, solution.Write(&buffer))
+
+		writtenPth := filepath.Join(tmpDir, "generated.sln")
+		require.NoError(t, fileutil.WriteStringToFile(writtenPth, buffer.String()))
+
+		written, err := analyzeSolution(writtenPth, false)
+		require.NoError(t, err)
+		require.Equal(t, solution.ConfigMap, written.ConfigMap)
+		require.Equal(t, len(solution.ProjectMap), len(written.ProjectMap))
+		for projectID, proj := range solution.ProjectMap {
+			require.Equal(t, proj.Pth, written.ProjectMap[projectID].Pth)
+			require.Equal(t, proj.ConfigMappings, written.ProjectMap[projectID].ConfigMappings)
+		}
+	}
+	t.Log("it generates the solution file for the solutions which can not be parsed losslessly")
+	{
+		pth := tmpSolutionWithContentInDir(t, diagnosticsTestSolutionContent, tmpDir)
+		solution, err := NewLenient(pth, false)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(solution.Errors()))
+
+		derived, err := solution.DeriveSolution("AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA")
+		require.NoError(t, err)
+
+		writtenPth := filepath.Join(tmpDir, "derived.sln")
+		require.NoError(t, derived.WriteFile(writtenPth))
+
+		written, err := analyzeSolution(writtenPth, false)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(written.Errors()))
+		require.Equal(t, 1, len(written.ProjectMap))
+		require.Equal(t, "App.iOS", written.ProjectMap["AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA"].Name)
+	}
+}
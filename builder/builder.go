@@ -1,6 +1,7 @@
 package builder
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -15,18 +16,32 @@ import (
 	"github.com/bitrise-io/go-xamarin/tools"
 	"github.com/bitrise-io/go-xamarin/tools/buildtools"
 	"github.com/bitrise-io/go-xamarin/tools/nunit"
+	"github.com/bitrise-io/go-xamarin/tools/xunit"
 	"github.com/bitrise-io/go-xamarin/utility"
 )
 
 // Model ...
 type Model struct {
-	solution solution.Model
+	solution   solution.Model
+	projectPth string // The project built without a solution, see NewWithProject
 
 	projectTypeWhitelist []constants.SDK
+	solutionFolders      []string
 	buildTool            buildtools.BuildTool
 
 	outWriter io.Writer
 	errWriter io.Writer
+
+	commandTimeout time.Duration
+	executor       tools.Executor
+
+	maxParallelism int
+	keepGoing      bool
+	projectOutputs ProjectOutputsFactory
+
+	xcodeArchivesDir string // The dir Xcode archives the iOS and tvOS apps to, see SetXcodeArchivesDir
+
+	observers []Observer
 }
 
 // SetOutputs ...
@@ -35,6 +50,119 @@ func (builder *Model) SetOutputs(outWriter, errWriter io.Writer) {
 	builder.errWriter = errWriter
 }
 
+// SetCommandTimeout sets the maximum duration of a single build or test command, 0 means no timeout.
+func (builder *Model) SetCommandTimeout(timeout time.Duration) {
+	builder.commandTimeout = timeout
+}
+
+// SetExecutor sets the executor running the build and test commands, the commands run as OS processes by default.
+func (builder *Model) SetExecutor(executor tools.Executor) {
+	builder.executor = executor
+}
+
+// SetMaxParallelism sets the maximum number of projects BuildAllProjects builds concurrently,
+// the independent projects are built in parallel after the projects they depend on. 0 and 1 build one project at a time.
+func (builder *Model) SetMaxParallelism(maxParallelism int) {
+	builder.maxParallelism = maxParallelism
+}
+
+// SetKeepGoing sets whether BuildAllProjects keeps building the other projects if a project fails,
+// the projects depending on the failed ones are skipped and the errors are returned in a *BuildError.
+func (builder *Model) SetKeepGoing(keepGoing bool) {
+	builder.keepGoing = keepGoing
+}
+
+// SetProjectOutputs sets the factory of the per-project output writers used by the parallel and keep going builds.
+// By default the output of each project is buffered and written to the builder's outputs once the project is finished,
+// so the logs of the concurrently built projects do not interleave.
+func (builder *Model) SetProjectOutputs(factory ProjectOutputsFactory) {
+	builder.projectOutputs = factory
+}
+
+// SetXcodeArchivesDir sets the dir the device builds of the iOS and tvOS projects are archived to,
+// ~/Library/Developer/Xcode/Archives by default.
+func (builder *Model) SetXcodeArchivesDir(dir string) {
+	builder.xcodeArchivesDir = dir
+}
+
+// CommandError is returned by the builder if a build or test command fails,
+// it holds the errors and warnings parsed from the command's output.
+type CommandError struct {
+	Command     string
+	Diagnostics []buildtools.Diagnostic
+	Err         error
+}
+
+// Error ...
+func (err *CommandError) Error() string {
+	errs := err.Errors()
+	if len(errs) == 0 {
+		return err.Err.Error()
+	}
+
+	msg := fmt.Sprintf("%s, %d error(s): %s", err.Err, len(errs), errs[0])
+	if len(errs) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(errs)-1)
+	}
+	return msg
+}
+
+// Unwrap ...
+func (err *CommandError) Unwrap() error {
+	return err.Err
+}
+
+// Errors returns the error diagnostics of the failed command.
+func (err *CommandError) Errors() []buildtools.Diagnostic {
+	return buildtools.FilterDiagnostics(err.Diagnostics, buildtools.SeverityError)
+}
+
+// outputs returns the writers of the commands' output, the standard output and error by default.
+func (builder Model) outputs() (io.Writer, io.Writer) {
+	outWriter, errWriter := builder.outWriter, builder.errWriter
+	if outWriter == nil {
+		outWriter = os.Stdout
+	}
+	if errWriter == nil {
+		errWriter = os.Stderr
+	}
+	return outWriter, errWriter
+}
+
+// runCommand runs the given command with the builder's executor, outputs and command timeout,
+// the command's process tree is killed if the context is done or the timeout exceeds.
+// The command's output is parsed for MSBuild diagnostics, which are returned in a *CommandError if the command fails.
+func (builder Model) runCommand(ctx context.Context, command tools.Runnable) error {
+	if builder.commandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, builder.commandTimeout)
+		defer cancel()
+	}
+
+	outWriter, errWriter := builder.outputs()
+
+	if executable, ok := command.(tools.Executable); ok && builder.executor != nil {
+		executable.SetExecutor(builder.executor)
+	}
+
+	outParser, errParser := buildtools.NewOutputParser(), buildtools.NewOutputParser()
+	err := command.RunContext(ctx, io.MultiWriter(outWriter, outParser), io.MultiWriter(errWriter, errParser))
+	if err == nil {
+		return nil
+	}
+
+	outParser.Flush()
+	errParser.Flush()
+
+	diagnostics := outParser.Diagnostics()
+	for _, diagnostic := range errParser.Diagnostics() {
+		if !diagnosticsContain(diagnostics, diagnostic) {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	return &CommandError{Command: command.String(), Diagnostics: diagnostics, Err: err}
+}
+
 // OutputModel ...
 type OutputModel struct {
 	Pth        string
@@ -60,26 +188,47 @@ type TestProjectOutputModel struct {
 // TestProjectOutputMap ...
 type TestProjectOutputMap map[string]TestProjectOutputModel // Test Project Name - TestProjectOutputModel
 
-// PrepareCommandCallback ...
+// PrepareCommandCallback is called for the CommandPrepared events, see Observer.
 type PrepareCommandCallback func(solutionName string, projectName string, sdk constants.SDK, testFramework constants.TestFramework, command *tools.Editable)
 
-// BuildCommandCallback ...
+// BuildCommandCallback is called for the CommandStarted events, see Observer.
 type BuildCommandCallback func(solutionName string, projectName string, sdk constants.SDK, testFramework constants.TestFramework, commandStr string, alreadyPerformed bool)
 
-// ClearCommandCallback ...
+// ClearCommandCallback is called for the CleanRemoved events, see Observer.
 type ClearCommandCallback func(project project.Model, dir string)
 
 // New ...
 func New(solutionPth string, projectTypeWhitelist []constants.SDK, buildTool buildtools.BuildTool) (Model, error) {
-	if err := validateSolutionPth(solutionPth); err != nil {
+	if err := validateSolutionPth(solutionPth, utility.OSFileSystem); err != nil {
 		return Model{}, err
 	}
+	if buildTool == buildtools.Xbuild && filepath.Ext(solutionPth) != constants.SolutionExt {
+		return Model{}, fmt.Errorf("xbuild does not support %s solutions: %s", filepath.Ext(solutionPth), solutionPth)
+	}
 
 	solution, err := solution.New(solutionPth, true)
 	if err != nil {
 		return Model{}, err
 	}
 
+	return NewWithSolution(solution, projectTypeWhitelist, buildTool)
+}
+
+// NewWithSolution creates a builder for an already analyzed solution,
+// like one analyzed with solution.NewWithOptions to use the project analysis cache.
+func NewWithSolution(solution solution.Model, projectTypeWhitelist []constants.SDK, buildTool buildtools.BuildTool) (Model, error) {
+	if err := validateSolutionPth(solution.Pth, solution.FileSystem()); err != nil {
+		return Model{}, err
+	}
+	if buildTool == buildtools.Xbuild && filepath.Ext(solution.Pth) != constants.SolutionExt {
+		return Model{}, fmt.Errorf("xbuild does not support %s solutions: %s", filepath.Ext(solution.Pth), solution.Pth)
+	}
+
+	// the projects are built in dependency order
+	if _, err := solution.ProjectGraph().Order(); err != nil {
+		return Model{}, err
+	}
+
 	if projectTypeWhitelist == nil {
 		projectTypeWhitelist = []constants.SDK{}
 	}
@@ -89,11 +238,71 @@ func New(solutionPth string, projectTypeWhitelist []constants.SDK, buildTool bui
 
 		projectTypeWhitelist: projectTypeWhitelist,
 		buildTool:            buildTool,
+		xcodeArchivesDir:     defaultXcodeArchivesDir(),
 	}, nil
 }
 
+// NewWithProject creates a builder for a single project file without a solution (like a standalone Android or iOS project).
+// The builder's synthetic solution contains the project and the projects it references, see solution.NewFromProject,
+// the solution configs are the project's Configuration|Platform pairs. The build commands build the project file itself,
+// without the SolutionDir property.
+func NewWithProject(projectPth string, projectTypeWhitelist []constants.SDK, buildTool buildtools.BuildTool) (Model, error) {
+	if err := validateProjectPth(projectPth); err != nil {
+		return Model{}, err
+	}
+
+	solution, err := solution.NewFromProject(projectPth)
+	if err != nil {
+		return Model{}, err
+	}
+
+	if _, err := solution.ProjectGraph().Order(); err != nil {
+		return Model{}, err
+	}
+
+	if projectTypeWhitelist == nil {
+		projectTypeWhitelist = []constants.SDK{}
+	}
+
+	absProjectPth, err := pathutil.AbsPath(projectPth)
+	if err != nil {
+		return Model{}, fmt.Errorf("Failed to expand path (%s), error: %s", projectPth, err)
+	}
+
+	return Model{
+		solution:   solution,
+		projectPth: absProjectPth,
+
+		projectTypeWhitelist: projectTypeWhitelist,
+		buildTool:            buildTool,
+		xcodeArchivesDir:     defaultXcodeArchivesDir(),
+	}, nil
+}
+
+// NewWithSolutionFolders creates a builder for the projects in the given solution folders (like Apps/Android) and their sub folders,
+// as an alternative to the project type whitelist.
+func NewWithSolutionFolders(solutionPth string, solutionFolders []string, buildTool buildtools.BuildTool) (Model, error) {
+	builder, err := New(solutionPth, nil, buildTool)
+	if err != nil {
+		return Model{}, err
+	}
+
+	for _, folder := range solutionFolders {
+		if _, ok := builder.solution.FolderByPath(folder); !ok {
+			return Model{}, fmt.Errorf("solution folder (%s) not found, available: %v", folder, builder.solution.FolderPaths())
+		}
+	}
+	builder.solutionFolders = solutionFolders
+
+	return builder, nil
+}
+
 // CleanAll ...
 func (builder Model) CleanAll(callback ClearCommandCallback) error {
+	if callback != nil {
+		builder = builder.withObserver(clearCallbackObserver(callback))
+	}
+
 	whitelistedProjects := builder.whitelistedProjects()
 
 	for _, proj := range whitelistedProjects {
@@ -105,9 +314,7 @@ func (builder Model) CleanAll(callback ClearCommandCallback) error {
 			if exist, err := pathutil.IsDirExists(binPth); err != nil {
 				return err
 			} else if exist {
-				if callback != nil {
-					callback(proj, binPth)
-				}
+				builder.notify(CleanRemoved{Solution: builder.solution.Name, Project: proj, Dir: binPth})
 
 				if err := os.RemoveAll(binPth); err != nil {
 					return err
@@ -120,9 +327,7 @@ func (builder Model) CleanAll(callback ClearCommandCallback) error {
 			if exist, err := pathutil.IsDirExists(objPth); err != nil {
 				return err
 			} else if exist {
-				if callback != nil {
-					callback(proj, objPth)
-				}
+				builder.notify(CleanRemoved{Solution: builder.solution.Name, Project: proj, Dir: objPth})
 
 				if err := os.RemoveAll(objPth); err != nil {
 					return err
@@ -134,144 +339,88 @@ func (builder Model) CleanAll(callback ClearCommandCallback) error {
 	return nil
 }
 
-// BuildSolution ...
-func (builder Model) BuildSolution(configuration, platform string, callback BuildCommandCallback) error {
-	if err := validateSolutionConfig(builder.solution, configuration, platform); err != nil {
-		return err
+// WriteTrimmedSolution writes a solution containing only the whitelisted projects (filtered by project type and solution folder)
+// and their transitive project references to the given path, the solution can be built instead of the whole solution.
+func (builder Model) WriteTrimmedSolution(pth string) error {
+	projectIDs := []string{}
+	kept := map[string]bool{}
+	for _, proj := range builder.whitelistedProjects() {
+		// the multi-platform projects are whitelisted for each of their platforms
+		if !kept[proj.ID] {
+			kept[proj.ID] = true
+			projectIDs = append(projectIDs, proj.ID)
+		}
 	}
-
-	buildCommand, err := builder.buildSolutionCommand(configuration, platform)
-	if err != nil {
-		return fmt.Errorf("Failed to create build command, error: %s", err)
+	if len(projectIDs) == 0 {
+		return fmt.Errorf("no project to keep in solution (%s)", builder.solution.Pth)
 	}
 
-	// Callback to notify the caller about next running command
-	if callback != nil {
-		callback(builder.solution.Name, "", constants.SDKUnknown, constants.TestFrameworkUnknown, buildCommand.String(), false)
+	trimmed, err := builder.solution.DeriveSolution(projectIDs...)
+	if err != nil {
+		return err
 	}
 
-	return buildCommand.Run(builder.outWriter, builder.errWriter)
+	return trimmed.WriteFile(pth)
 }
 
-// BuildAllProjects ...
-func (builder Model) BuildAllProjects(configuration, platform string, buildIpa bool, prepareCallback PrepareCommandCallback, callback BuildCommandCallback) ([]string, error) {
-	warnings := []string{}
-
+// BuildSolution ...
+func (builder Model) BuildSolution(ctx context.Context, configuration, platform string, callback BuildCommandCallback) error {
 	if err := validateSolutionConfig(builder.solution, configuration, platform); err != nil {
-		return warnings, err
-	}
-
-	buildableProjects, warns := builder.buildableProjects(configuration, platform)
-	if len(buildableProjects) == 0 {
-		return warns, fmt.Errorf("No project to build found")
+		return err
 	}
 
-	perfomedCommands := []tools.Printable{}
-
-	for _, proj := range buildableProjects {
-		buildCommands, warns, err := builder.buildProjectCommand(configuration, platform, proj, buildIpa)
-		warnings = append(warnings, warns...)
-		if err != nil {
-			return warnings, fmt.Errorf("Failed to create build command, error: %s", err)
-		}
-
-		for _, buildCommand := range buildCommands {
-			// Callback to let the caller to modify the command
-			if prepareCallback != nil {
-				editabeCommand := tools.Editable(buildCommand)
-				prepareCallback(builder.solution.Name, proj.Name, proj.SDK, proj.TestFramework, &editabeCommand)
-			}
-
-			// Check if same command was already performed
-			alreadyPerformed := false
-			if tools.PrintableSliceContains(perfomedCommands, buildCommand) {
-				alreadyPerformed = true
-			}
-
-			// Callback to notify the caller about next running command
-			if callback != nil {
-				callback(builder.solution.Name, proj.Name, proj.SDK, proj.TestFramework, buildCommand.String(), alreadyPerformed)
-			}
-
-			if !alreadyPerformed {
-				if err := buildCommand.Run(builder.outWriter, builder.errWriter); err != nil {
-					return warnings, err
-				}
-				perfomedCommands = append(perfomedCommands, buildCommand)
-			}
-		}
+	buildCommand, err := builder.buildSolutionCommand(configuration, platform)
+	if err != nil {
+		return fmt.Errorf("Failed to create build command, error: %s", err)
 	}
 
-	return warnings, nil
+	return builder.withCallbacks(nil, callback).runObservedCommand(ctx, builder.solutionCommandInfo(), buildCommand, false)
 }
 
-// BuildAllUITestableXamarinProjects ...
-func (builder Model) BuildAllUITestableXamarinProjects(configuration, platform string, prepareCallback PrepareCommandCallback, callback BuildCommandCallback) ([]string, error) {
-	warnings := []string{}
+// BuildAllProjects builds the buildable projects of the solution in build order, see PlanBuildAllProjects.
+// The projects are built concurrently if the max parallelism is set, see SetMaxParallelism and SetKeepGoing.
+func (builder Model) BuildAllProjects(ctx context.Context, configuration, platform string, buildIpa bool, prepareCallback PrepareCommandCallback, callback BuildCommandCallback) ([]string, error) {
+	builder = builder.withCallbacks(prepareCallback, callback)
 
-	if err := validateSolutionConfig(builder.solution, configuration, platform); err != nil {
+	plan, err := builder.planBuildAllProjects(configuration, platform, buildIpa)
+	builder.notifySkipped(plan.Skipped)
+	warnings := append(skippedProjectWarnings(plan.Skipped), plan.Warnings...)
+	if err != nil {
 		return warnings, err
 	}
 
-	if err := builder.BuildSolution(configuration, platform, callback); err != nil {
-		return nil, err
-	}
-
-	_, buildableReferredProjects, warns := builder.buildableXamarinUITestProjectsAndReferredProjects(configuration, platform)
-	if len(buildableReferredProjects) == 0 {
-		return warns, fmt.Errorf("No project to build found")
-	}
-
-	perfomedCommands := []tools.Printable{}
-
-	for _, proj := range buildableReferredProjects {
-		buildCommands, warns, err := builder.buildProjectCommand(configuration, platform, proj, true)
-		warnings = append(warnings, warns...)
-		if err != nil {
-			return warnings, fmt.Errorf("Failed to create build command, error: %s", err)
-		}
-
-		for _, buildCommand := range buildCommands {
-			// Callback to let the caller to modify the command
-			if prepareCallback != nil {
-				editabeCommand := tools.Editable(buildCommand)
-				prepareCallback(builder.solution.Name, proj.Name, proj.SDK, proj.TestFramework, &editabeCommand)
-			}
-
-			// Check if same command was already performed
-			alreadyPerformed := false
-			if tools.PrintableSliceContains(perfomedCommands, buildCommand) {
-				alreadyPerformed = true
-			}
+	warns, err := builder.runProjectsPlan(ctx, plan)
+	return append(warnings, warns...), err
+}
 
-			// Callback to notify the caller about next running command
-			if callback != nil {
-				callback(builder.solution.Name, proj.Name, proj.SDK, proj.TestFramework, buildCommand.String(), alreadyPerformed)
-			}
+// BuildAllUITestableXamarinProjects builds the solution and the projects referred by the Xamarin UITest projects,
+// see PlanBuildAllUITestableXamarinProjects.
+func (builder Model) BuildAllUITestableXamarinProjects(ctx context.Context, configuration, platform string, prepareCallback PrepareCommandCallback, callback BuildCommandCallback) ([]string, error) {
+	builder = builder.withCallbacks(prepareCallback, callback)
 
-			if !alreadyPerformed {
-				if err := buildCommand.Run(builder.outWriter, builder.errWriter); err != nil {
-					return warnings, err
-				}
-				perfomedCommands = append(perfomedCommands, buildCommand)
-			}
-		}
+	plan, err := builder.planBuildAllUITestableXamarinProjects(configuration, platform)
+	builder.notifySkipped(plan.Skipped)
+	warnings := append(skippedProjectWarnings(plan.Skipped), plan.Warnings...)
+	if err != nil {
+		return warnings, err
 	}
 
-	return warnings, nil
+	return warnings, builder.runPlan(ctx, plan)
 }
 
 // RunAllXamarinUITests ...
-func (builder Model) RunAllXamarinUITests(configuration, platform string, prepareCallback PrepareCommandCallback, callback BuildCommandCallback) ([]string, error) {
+func (builder Model) RunAllXamarinUITests(ctx context.Context, configuration, platform string, prepareCallback PrepareCommandCallback, callback BuildCommandCallback) ([]string, error) {
 	warnings := []string{}
+	builder = builder.withCallbacks(prepareCallback, callback)
 
 	if err := validateSolutionConfig(builder.solution, configuration, platform); err != nil {
 		return warnings, err
 	}
 
-	buildableTestProjects, _, warns := builder.buildableXamarinUITestProjectsAndReferredProjects(configuration, platform)
+	buildableTestProjects, _, skipped, warns := builder.buildableXamarinUITestProjectsAndReferredProjectsAndSkipped(configuration, platform)
+	builder.notifySkipped(skipped)
 	if len(buildableTestProjects) == 0 {
-		return warns, fmt.Errorf("No project to build found")
+		return append(skippedProjectWarnings(skipped), warns...), fmt.Errorf("No project to build found")
 	}
 
 	perfomedCommands := []tools.Printable{}
@@ -283,27 +432,16 @@ func (builder Model) RunAllXamarinUITests(configuration, platform string, prepar
 			return warnings, fmt.Errorf("Failed to create build command, error: %s", err)
 		}
 
-		// Callback to let the caller to modify the command
-		if prepareCallback != nil {
-			editabeCommand := tools.Editable(buildCommand)
-			prepareCallback(builder.solution.Name, testProj.Name, testProj.SDK, testProj.TestFramework, &editabeCommand)
-		}
+		// Let the observers modify the command
+		builder.prepareCommand(builder.commandInfo(testProj), buildCommand)
 
 		// Check if same command was already performed
-		alreadyPerformed := false
-		if tools.PrintableSliceContains(perfomedCommands, buildCommand) {
-			alreadyPerformed = true
-		}
+		alreadyPerformed := tools.PrintableSliceContains(perfomedCommands, buildCommand)
 
-		// Callback to notify the caller about next running command
-		if callback != nil {
-			callback(builder.solution.Name, testProj.Name, testProj.SDK, testProj.TestFramework, buildCommand.String(), alreadyPerformed)
+		if err := builder.runObservedCommand(ctx, builder.commandInfo(testProj), buildCommand, alreadyPerformed); err != nil {
+			return warnings, err
 		}
-
 		if !alreadyPerformed {
-			if err := buildCommand.Run(builder.outWriter, builder.errWriter); err != nil {
-				return warnings, err
-			}
 			perfomedCommands = append(perfomedCommands, buildCommand)
 		}
 	}
@@ -312,16 +450,16 @@ func (builder Model) RunAllXamarinUITests(configuration, platform string, prepar
 }
 
 // BuildAndRunAllXamarinUITestAndReferredProjects ...
-func (builder Model) BuildAndRunAllXamarinUITestAndReferredProjects(configuration, platform string, prepareCallback PrepareCommandCallback, callback BuildCommandCallback) ([]string, error) {
+func (builder Model) BuildAndRunAllXamarinUITestAndReferredProjects(ctx context.Context, configuration, platform string, prepareCallback PrepareCommandCallback, callback BuildCommandCallback) ([]string, error) {
 	warnings := []string{}
 
-	buildWarnings, err := builder.BuildAllUITestableXamarinProjects(configuration, platform, prepareCallback, callback)
+	buildWarnings, err := builder.BuildAllUITestableXamarinProjects(ctx, configuration, platform, prepareCallback, callback)
 	warnings = append(warnings, buildWarnings...)
 	if err != nil {
 		return warnings, err
 	}
 
-	runWarnings, err := builder.RunAllXamarinUITests(configuration, platform, prepareCallback, callback)
+	runWarnings, err := builder.RunAllXamarinUITests(ctx, configuration, platform, prepareCallback, callback)
 	warnings = append(warnings, runWarnings...)
 	if err != nil {
 		return warnings, err
@@ -331,14 +469,15 @@ func (builder Model) BuildAndRunAllXamarinUITestAndReferredProjects(configuratio
 }
 
 // RunAllNunitTestProjects ...
-func (builder Model) RunAllNunitTestProjects(configuration, platform string, callback BuildCommandCallback, prepareCallback PrepareCommandCallback) ([]string, error) {
+func (builder Model) RunAllNunitTestProjects(ctx context.Context, configuration, platform string, callback BuildCommandCallback, prepareCallback PrepareCommandCallback) ([]string, error) {
 	if err := validateSolutionConfig(builder.solution, configuration, platform); err != nil {
 		return nil, err
 	}
 
-	buildableProjects, warns := builder.buildableNunitTestProjects(configuration, platform)
+	buildableProjects, skipped := builder.buildableTestProjectsAndSkipped(configuration, platform, constants.TestFrameworkNunitTest)
+	builder.withCallbacks(prepareCallback, callback).notifySkipped(skipped)
 	if len(buildableProjects) == 0 {
-		return warns, fmt.Errorf("No project to build found")
+		return skippedProjectWarnings(skipped), fmt.Errorf("No project to build found")
 	}
 
 	nunitConsolePth, err := nunit.SystemNunit3ConsolePath()
@@ -346,37 +485,81 @@ func (builder Model) RunAllNunitTestProjects(configuration, platform string, cal
 		return nil, err
 	}
 
+	return builder.runTestProjects(ctx, buildableProjects, constants.TestFrameworkNunitTest, func(testProj project.Model) (tools.Runnable, []string, error) {
+		return builder.buildNunitTestProjectCommand(configuration, platform, testProj, nunitConsolePth)
+	}, callback, prepareCallback)
+}
+
+// RunAllXunitTestProjects ...
+func (builder Model) RunAllXunitTestProjects(ctx context.Context, configuration, platform string, callback BuildCommandCallback, prepareCallback PrepareCommandCallback) ([]string, error) {
+	if err := validateSolutionConfig(builder.solution, configuration, platform); err != nil {
+		return nil, err
+	}
+
+	buildableProjects, skipped := builder.buildableTestProjectsAndSkipped(configuration, platform, constants.TestFrameworkXunit)
+	builder.withCallbacks(prepareCallback, callback).notifySkipped(skipped)
+	if len(buildableProjects) == 0 {
+		return skippedProjectWarnings(skipped), fmt.Errorf("No project to build found")
+	}
+
+	// the xunit console runs the test assemblies of the legacy projects only
+	xunitConsolePth := ""
+	for _, proj := range buildableProjects {
+		if !proj.IsSDKStyle() {
+			var err error
+			if xunitConsolePth, err = xunit.SystemXunitConsolePath(); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return builder.runTestProjects(ctx, buildableProjects, constants.TestFrameworkXunit, func(testProj project.Model) (tools.Runnable, []string, error) {
+		return builder.buildXunitTestProjectCommand(configuration, platform, testProj, xunitConsolePth)
+	}, callback, prepareCallback)
+}
+
+// RunAllMSTestProjects ...
+func (builder Model) RunAllMSTestProjects(ctx context.Context, configuration, platform string, callback BuildCommandCallback, prepareCallback PrepareCommandCallback) ([]string, error) {
+	if err := validateSolutionConfig(builder.solution, configuration, platform); err != nil {
+		return nil, err
+	}
+
+	buildableProjects, skipped := builder.buildableTestProjectsAndSkipped(configuration, platform, constants.TestFrameworkMSTest)
+	builder.withCallbacks(prepareCallback, callback).notifySkipped(skipped)
+	if len(buildableProjects) == 0 {
+		return skippedProjectWarnings(skipped), fmt.Errorf("No project to build found")
+	}
+
+	return builder.runTestProjects(ctx, buildableProjects, constants.TestFrameworkMSTest, func(testProj project.Model) (tools.Runnable, []string, error) {
+		return builder.buildMSTestProjectCommand(configuration, platform, testProj)
+	}, callback, prepareCallback)
+}
+
+func (builder Model) runTestProjects(ctx context.Context, testProjects []project.Model, testFramework constants.TestFramework, commandFactory func(testProj project.Model) (tools.Runnable, []string, error), callback BuildCommandCallback, prepareCallback PrepareCommandCallback) ([]string, error) {
 	warnings := []string{}
+	builder = builder.withCallbacks(prepareCallback, callback)
 	perfomedCommands := []tools.Printable{}
 
-	for _, testProj := range buildableProjects {
-		buildCommand, warns, err := builder.buildNunitTestProjectCommand(configuration, platform, testProj, nunitConsolePth)
+	for _, testProj := range testProjects {
+		buildCommand, warns, err := commandFactory(testProj)
 		warnings = append(warnings, warns...)
 		if err != nil {
 			return warnings, fmt.Errorf("Failed to create build command, error: %s", err)
 		}
 
-		// Callback to let the caller to modify the command
-		if prepareCallback != nil {
-			editabeCommand := tools.Editable(buildCommand)
-			prepareCallback(builder.solution.Name, testProj.Name, constants.SDKUnknown, constants.TestFrameworkNunitTest, &editabeCommand)
-		}
+		info := CommandInfo{Solution: builder.solution.Name, Project: testProj.Name, SDK: constants.SDKUnknown, TestFramework: testFramework}
+
+		// Let the observers modify the command
+		builder.prepareCommand(info, buildCommand)
 
 		// Check if same command was already performed
-		alreadyPerformed := false
-		if tools.PrintableSliceContains(perfomedCommands, buildCommand) {
-			alreadyPerformed = true
-		}
+		alreadyPerformed := tools.PrintableSliceContains(perfomedCommands, buildCommand)
 
-		// Callback to notify the caller about next running command
-		if callback != nil {
-			callback(builder.solution.Name, testProj.Name, constants.SDKUnknown, constants.TestFrameworkNunitTest, buildCommand.String(), alreadyPerformed)
+		if err := builder.runObservedCommand(ctx, info, buildCommand, alreadyPerformed); err != nil {
+			return warnings, err
 		}
-
 		if !alreadyPerformed {
-			if err := buildCommand.Run(builder.outWriter, builder.errWriter); err != nil {
-				return warnings, err
-			}
 			perfomedCommands = append(perfomedCommands, buildCommand)
 		}
 	}
@@ -385,16 +568,23 @@ func (builder Model) RunAllNunitTestProjects(configuration, platform string, cal
 }
 
 // BuildAndRunAllNunitTestProjects ...
-func (builder Model) BuildAndRunAllNunitTestProjects(configuration, platform string, callback BuildCommandCallback, prepareCallback PrepareCommandCallback) ([]string, error) {
-	if err := builder.BuildSolution(configuration, platform, callback); err != nil {
+func (builder Model) BuildAndRunAllNunitTestProjects(ctx context.Context, configuration, platform string, callback BuildCommandCallback, prepareCallback PrepareCommandCallback) ([]string, error) {
+	if err := builder.BuildSolution(ctx, configuration, platform, callback); err != nil {
 		return nil, err
 	}
 
-	return builder.RunAllNunitTestProjects(configuration, platform, callback, prepareCallback)
+	return builder.RunAllNunitTestProjects(ctx, configuration, platform, callback, prepareCallback)
 }
 
 // CollectProjectOutputs ...
 func (builder Model) CollectProjectOutputs(configuration, platform string, startTime, endTime time.Time) (ProjectOutputMap, error) {
+	return builder.collectProjectOutputs(configuration, platform, func(project.Model) (time.Time, time.Time) {
+		return startTime, endTime
+	})
+}
+
+// collectProjectOutputs collects the outputs of each project generated in the project's time window.
+func (builder Model) collectProjectOutputs(configuration, platform string, timeWindow func(proj project.Model) (time.Time, time.Time)) (ProjectOutputMap, error) {
 	projectOutputMap := ProjectOutputMap{}
 
 	buildableProjects, _ := builder.buildableProjects(configuration, platform)
@@ -402,6 +592,8 @@ func (builder Model) CollectProjectOutputs(configuration, platform string, start
 	solutionConfig := utility.ToConfig(configuration, platform)
 
 	for _, proj := range buildableProjects {
+		startTime, endTime := timeWindow(proj)
+
 		projectConfigKey, ok := proj.ConfigMap[solutionConfig]
 		if !ok {
 			continue
@@ -423,7 +615,7 @@ func (builder Model) CollectProjectOutputs(configuration, platform string, start
 		switch proj.SDK {
 		case constants.SDKIOS, constants.SDKTvOS:
 			if IsDeviceArch(projectConfig.MtouchArchs...) {
-				if xcarchivePth, err := exportLatestXCArchiveFromXcodeArchives(proj.AssemblyName, startTime, endTime); err != nil {
+				if xcarchivePth, err := exportLatestXCArchiveFromXcodeArchives(builder.xcodeArchivesDir, proj.AssemblyName, startTime, endTime); err != nil {
 					return ProjectOutputMap{}, err
 				} else if xcarchivePth != "" {
 					projectOutputs.Outputs = append(projectOutputs.Outputs, OutputModel{
@@ -490,9 +682,14 @@ func (builder Model) CollectProjectOutputs(configuration, platform string, start
 				log.Debugf("No valid pkg path found.")
 			}
 		case constants.SDKAndroid:
-			packageName, err := androidPackageName(proj.ManifestPth)
-			if err != nil {
-				return ProjectOutputMap{}, fmt.Errorf("could get package name from manifest file at %v. Error: %v", proj.ManifestPth, err)
+			// SDK-style projects define the package name by the ApplicationId property
+			packageName := proj.ApplicationID
+			if packageName == "" {
+				var err error
+				packageName, err = androidPackageName(proj.ManifestPth, builder.solution.FileSystem())
+				if err != nil {
+					return ProjectOutputMap{}, fmt.Errorf("could get package name from manifest file at %v. Error: %v", proj.ManifestPth, err)
+				}
 			}
 
 			if apkPth, err := exportApk(projectConfig.OutputDir, packageName, startTime, endTime); err != nil {
@@ -521,6 +718,9 @@ func (builder Model) CollectProjectOutputs(configuration, platform string, start
 		if len(projectOutputs.Outputs) > 0 {
 			projectOutputMap[proj.Name] = projectOutputs
 		}
+		for _, output := range projectOutputs.Outputs {
+			builder.notify(ArtifactCollected{Solution: builder.solution.Name, Project: proj.Name, SDK: proj.SDK, Output: output})
+		}
 	}
 
 	return projectOutputMap, nil
@@ -568,6 +768,7 @@ func (builder Model) CollectXamarinUITestProjectOutputs(configuration, platform
 					OutputType: constants.OutputTypeDLL,
 				},
 			}
+			builder.notify(ArtifactCollected{Solution: builder.solution.Name, Project: testProj.Name, SDK: testProj.SDK, Output: testProjectOutputMap[testProj.Name].Output})
 		}
 	}
 
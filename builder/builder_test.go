@@ -0,0 +1,175 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/analyzers/solution"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeToolchain creates placeholder executables for the given tools in the given directory, the tools are never run:
+// the builder runs the commands with a fake executor.
+func fakeToolchain(t *testing.T, dir string, tls ...toolchain.Tool) func() {
+	for _, tool := range tls {
+		pth := filepath.Join(dir, tool.Name)
+		require.NoError(t, fileutil.WriteStringToFile(pth, "#!/bin/sh\nexit 1\n"))
+		require.NoError(t, os.Chmod(pth, 0755))
+		toolchain.SetOverride(tool, pth)
+	}
+
+	return func() {
+		for _, tool := range tls {
+			toolchain.SetOverride(tool, "")
+		}
+	}
+}
+
+func TestBuildAllProjectsWithFakeExecutor(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	defer fakeToolchain(t, tmpDir, toolchain.Msbuild)()
+
+	outputDir := filepath.Join(tmpDir, "App.Droid", "bin", "Release")
+	app := project.Model{
+		ID:                 "APP",
+		Name:               "App.Droid",
+		Pth:                filepath.Join(tmpDir, "App.Droid", "App.Droid.csproj"),
+		SDK:                constants.SDKAndroid,
+		AndroidApplication: true,
+		ApplicationID:      "com.bitrise.app",
+		ConfigMap:          map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+		Configs: map[string]project.ConfigurationPlatformModel{
+			"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", OutputDir: outputDir, SignAndroid: true},
+		},
+	}
+	builder := Model{
+		solution: solution.Model{
+			Name:       "App",
+			Pth:        filepath.Join(tmpDir, "App.sln"),
+			ConfigMap:  map[string]string{"Release|Any CPU": "Release|Any CPU"},
+			ProjectMap: map[string]project.Model{app.ID: app},
+		},
+		buildTool: buildtools.Msbuild,
+		outWriter: io.Discard,
+		errWriter: io.Discard,
+	}
+
+	t.Log("it builds the projects and collects the generated artifacts")
+	{
+		apkPth := filepath.Join(outputDir, "com.bitrise.app-Signed.apk")
+		executor := tools.NewFakeExecutor().OnArg("/target:SignAndroidPackage", tools.FakeResult{
+			Stdout:    "Build succeeded.\n",
+			Artifacts: map[string]string{apkPth: "apk"},
+		})
+		builder.SetExecutor(executor)
+
+		startTime := time.Now().Add(-time.Second)
+		warnings, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{}, warnings)
+		endTime := time.Now().Add(time.Second)
+
+		commands := executor.Commands()
+		require.Equal(t, 1, len(commands))
+		require.Equal(t, app.Pth, commands[0][1])
+		require.Equal(t, "/target:SignAndroidPackage", commands[0][2])
+
+		outputs, err := builder.CollectProjectOutputs("Release", "Any CPU", startTime, endTime)
+		require.NoError(t, err)
+		require.Equal(t, ProjectOutputMap{
+			"App.Droid": {
+				ProjectType: constants.SDKAndroid,
+				Outputs:     []OutputModel{{Pth: apkPth, OutputType: constants.OutputTypeAPK}},
+			},
+		}, outputs)
+	}
+
+	t.Log("it returns the diagnostics of the failed commands")
+	{
+		builder.SetExecutor(tools.NewFakeExecutor().OnArg("/target:SignAndroidPackage", tools.FakeResult{
+			ExitCode: 1,
+			Stdout:   "MainActivity.cs(10,5): error CS1002: ; expected [" + app.Pth + "]\n",
+		}))
+
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, nil, nil)
+		var commandErr *CommandError
+		require.True(t, errors.As(err, &commandErr))
+		require.Equal(t, 1, len(commandErr.Errors()))
+		require.Equal(t, "CS1002", commandErr.Errors()[0].Code)
+
+		var exitErr *tools.ExitError
+		require.True(t, errors.As(err, &exitErr))
+		require.Equal(t, 1, exitErr.Code)
+	}
+}
+
+func TestRunAllNunitTestProjectsWithFakeExecutor(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	defer fakeToolchain(t, tmpDir, toolchain.Mono)()
+
+	nunitConsolePth := filepath.Join(tmpDir, "nunit3-console.exe")
+	require.NoError(t, fileutil.WriteStringToFile(nunitConsolePth, ""))
+	nunitPath, isSet := os.LookupEnv("NUNIT_PATH")
+	require.NoError(t, os.Setenv("NUNIT_PATH", tmpDir))
+	defer func() {
+		if isSet {
+			require.NoError(t, os.Setenv("NUNIT_PATH", nunitPath))
+		} else {
+			require.NoError(t, os.Unsetenv("NUNIT_PATH"))
+		}
+	}()
+
+	testProject := project.Model{
+		ID:            "TEST",
+		Name:          "App.Tests",
+		Pth:           filepath.Join(tmpDir, "App.Tests", "App.Tests.csproj"),
+		TestFramework: constants.TestFrameworkNunitTest,
+		ConfigMap:     map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+		Configs: map[string]project.ConfigurationPlatformModel{
+			"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU"},
+		},
+	}
+	builder := Model{
+		solution: solution.Model{
+			Name:       "App",
+			Pth:        filepath.Join(tmpDir, "App.sln"),
+			ConfigMap:  map[string]string{"Release|Any CPU": "Release|Any CPU"},
+			ProjectMap: map[string]project.Model{testProject.ID: testProject},
+		},
+		outWriter: io.Discard,
+		errWriter: io.Discard,
+	}
+
+	t.Log("it runs the test projects with the executor")
+	{
+		executor := tools.NewFakeExecutor()
+		builder.SetExecutor(executor)
+
+		_, err := builder.RunAllNunitTestProjects(context.Background(), "Release", "Any CPU", nil, nil)
+		require.NoError(t, err)
+
+		commands := executor.Commands()
+		require.Equal(t, 1, len(commands))
+		require.Equal(t, []string{filepath.Join(tmpDir, "mono"), nunitConsolePth, testProject.Pth, "/config:Release"}, commands[0])
+	}
+}
@@ -2,27 +2,57 @@ package builder
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/bitrise-io/go-xamarin/analyzers/project"
 	"github.com/bitrise-io/go-xamarin/constants"
 	"github.com/bitrise-io/go-xamarin/tools"
 	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools/dotnet"
 	"github.com/bitrise-io/go-xamarin/tools/buildtools/msbuild"
 	"github.com/bitrise-io/go-xamarin/tools/buildtools/xbuild"
+	"github.com/bitrise-io/go-xamarin/tools/mstest"
 	"github.com/bitrise-io/go-xamarin/tools/nunit"
+	"github.com/bitrise-io/go-xamarin/tools/xunit"
 	"github.com/bitrise-io/go-xamarin/utility"
 )
 
+// solutionTarget returns the solution and project path of the commands building the whole solution:
+// the builders without solution (see NewWithProject) build their project instead.
+func (builder Model) solutionTarget() (string, string) {
+	return builder.solution.Pth, builder.projectPth
+}
+
+// projectTarget returns the solution and project path of the commands building the given project,
+// the solution path is the one of the solution commands, see solutionTarget.
+func (builder Model) projectTarget(proj project.Model) (string, string) {
+	solutionPth, _ := builder.solutionTarget()
+	return solutionPth, proj.Pth
+}
+
 func (builder Model) buildSolutionCommand(configuration, platform string) (tools.Runnable, error) {
 	var buildCommand tools.Runnable
+	solutionPth, projectPth := builder.solutionTarget()
+
+	if builder.buildTool == buildtools.DotnetCLI {
+		command, err := dotnet.New(solutionPth, projectPth)
+		if err != nil {
+			return nil, err
+		}
+
+		command.SetConfiguration(configuration)
+		command.SetPlatform(platform)
+
+		return command, nil
+	}
 
 	var command *xbuild.Model
 	var err error
 
 	if builder.buildTool == buildtools.Msbuild {
-		command, err = msbuild.New(builder.solution.Pth, "")
+		command, err = msbuild.New(solutionPth, projectPth)
 	} else {
-		command, err = xbuild.New(builder.solution.Pth, "")
+		command, err = xbuild.New(solutionPth, projectPth)
 	}
 
 	if err != nil {
@@ -55,15 +85,28 @@ func (builder Model) buildProjectCommand(configuration, platform string, proj pr
 	// Prepare build commands
 	buildCommands := []tools.Runnable{}
 
+	// SDK-style projects can only be built by the dotnet CLI
+	if proj.IsSDKStyle() {
+		command, err := builder.buildDotnetProjectCommand(platform, proj, projectConfig, buildIpa)
+		if err != nil {
+			return []tools.Runnable{}, warnings, err
+		}
+		if command != nil {
+			buildCommands = append(buildCommands, command)
+		}
+		return buildCommands, warnings, nil
+	}
+
 	switch proj.SDK {
 	case constants.SDKIOS, constants.SDKTvOS:
 		var command *xbuild.Model
 		var err error
 
+		solutionPth, projectPth := builder.solutionTarget()
 		if builder.buildTool == buildtools.Msbuild {
-			command, err = msbuild.New(builder.solution.Pth, "")
+			command, err = msbuild.New(solutionPth, projectPth)
 		} else {
-			command, err = xbuild.New(builder.solution.Pth, "")
+			command, err = xbuild.New(solutionPth, projectPth)
 		}
 
 		if err != nil {
@@ -84,10 +127,11 @@ func (builder Model) buildProjectCommand(configuration, platform string, proj pr
 		var command *xbuild.Model
 		var err error
 
+		solutionPth, projectPth := builder.solutionTarget()
 		if builder.buildTool == buildtools.Msbuild {
-			command, err = msbuild.New(builder.solution.Pth, "")
+			command, err = msbuild.New(solutionPth, projectPth)
 		} else {
-			command, err = xbuild.New(builder.solution.Pth, "")
+			command, err = xbuild.New(solutionPth, projectPth)
 		}
 
 		if err != nil {
@@ -104,10 +148,11 @@ func (builder Model) buildProjectCommand(configuration, platform string, proj pr
 		var command *xbuild.Model
 		var err error
 
+		solutionPth, projectPth := builder.projectTarget(proj)
 		if builder.buildTool == buildtools.Msbuild {
-			command, err = msbuild.New(builder.solution.Pth, proj.Pth)
+			command, err = msbuild.New(solutionPth, projectPth)
 		} else {
-			command, err = xbuild.New(builder.solution.Pth, proj.Pth)
+			command, err = xbuild.New(solutionPth, projectPth)
 		}
 
 		if err != nil {
@@ -132,6 +177,56 @@ func (builder Model) buildProjectCommand(configuration, platform string, proj pr
 	return buildCommands, warnings, nil
 }
 
+func (builder Model) buildDotnetProjectCommand(platform string, proj project.Model, projectConfig project.ConfigurationPlatformModel, buildIpa bool) (*dotnet.Model, error) {
+	switch proj.SDK {
+	case constants.SDKIOS, constants.SDKTvOS, constants.SDKMacOS, constants.SDKAndroid:
+	default:
+		return nil, nil
+	}
+
+	command, err := dotnet.New(builder.projectTarget(proj))
+	if err != nil {
+		return nil, err
+	}
+
+	command.SetFramework(proj.TargetFrameworkForSDK(proj.SDK))
+	command.SetConfiguration(projectConfig.Configuration)
+
+	switch proj.SDK {
+	case constants.SDKIOS, constants.SDKTvOS:
+		runtimeIdentifier := projectConfig.Property("RuntimeIdentifier")
+		isDevice := platform == "iPhone" || (len(projectConfig.MtouchArchs) > 0 && IsDeviceArch(projectConfig.MtouchArchs...))
+		if runtimeIdentifier == "" && isDevice {
+			runtimeIdentifier = "ios-arm64"
+			if proj.SDK == constants.SDKTvOS {
+				runtimeIdentifier = "tvos-arm64"
+			}
+		}
+
+		// Simulator builds can not be archived
+		if isDeviceRuntimeIdentifier(runtimeIdentifier) {
+			command.SetRuntimeIdentifier(runtimeIdentifier)
+			command.SetProperty("ArchiveOnBuild", "true")
+
+			if buildIpa {
+				command.SetCommand(dotnet.CommandPublish)
+				command.SetProperty("BuildIpa", "true")
+			}
+		}
+	case constants.SDKMacOS:
+		command.SetProperty("ArchiveOnBuild", "true")
+	case constants.SDKAndroid:
+		// publish creates the signed apk or aab
+		command.SetCommand(dotnet.CommandPublish)
+
+		if projectConfig.AndroidPackageFormat != "" {
+			command.SetProperty("AndroidPackageFormat", projectConfig.AndroidPackageFormat)
+		}
+	}
+
+	return command, nil
+}
+
 func (builder Model) buildXamarinUITestProjectCommand(configuration, platform string, proj project.Model) (tools.Runnable, []string, error) {
 	warnings := []string{}
 
@@ -150,10 +245,11 @@ func (builder Model) buildXamarinUITestProjectCommand(configuration, platform st
 	var command *xbuild.Model
 	var err error
 
+	solutionPth, projectPth := builder.projectTarget(proj)
 	if builder.buildTool == buildtools.Msbuild {
-		command, err = msbuild.New(builder.solution.Pth, proj.Pth)
+		command, err = msbuild.New(solutionPth, projectPth)
 	} else {
-		command, err = xbuild.New(builder.solution.Pth, proj.Pth)
+		command, err = xbuild.New(solutionPth, projectPth)
 	}
 	if err != nil {
 		return nil, warnings, err
@@ -189,3 +285,65 @@ func (builder Model) buildNunitTestProjectCommand(configuration, platform string
 
 	return command, warnings, nil
 }
+
+func (builder Model) buildXunitTestProjectCommand(configuration, platform string, proj project.Model, xunitConsolePth string) (tools.Runnable, []string, error) {
+	warnings := []string{}
+
+	dllPth, err := testAssemblyPath(configuration, platform, proj)
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	// mono can not run the .NET test assemblies of the SDK-style projects
+	var command *xunit.Model
+	if proj.IsSDKStyle() {
+		command, err = xunit.NewVSTest()
+	} else {
+		command, err = xunit.New(xunitConsolePth)
+	}
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	command.SetDLLPth(dllPth)
+
+	return command, warnings, nil
+}
+
+func (builder Model) buildMSTestProjectCommand(configuration, platform string, proj project.Model) (tools.Runnable, []string, error) {
+	warnings := []string{}
+
+	dllPth, err := testAssemblyPath(configuration, platform, proj)
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	command, err := mstest.New()
+	if err != nil {
+		return nil, warnings, err
+	}
+	command.SetDLLPth(dllPth)
+
+	return command, warnings, nil
+}
+
+// testAssemblyPath returns the path of the test project's assembly built with the given solution configuration.
+func testAssemblyPath(configuration, platform string, proj project.Model) (string, error) {
+	solutionConfig := utility.ToConfig(configuration, platform)
+
+	projectConfigKey, ok := proj.ConfigMap[solutionConfig]
+	if !ok {
+		return "", fmt.Errorf("project (%s) do not have config for solution config (%s)", proj.Name, solutionConfig)
+	}
+
+	projectConfig, ok := proj.Configs[projectConfigKey]
+	if !ok {
+		return "", fmt.Errorf("project (%s) contains mapping for solution config (%s), but does not have project configuration", proj.Name, solutionConfig)
+	}
+
+	if proj.AssemblyName == "" {
+		return "", fmt.Errorf("project (%s) does not define assembly name", proj.Name)
+	}
+
+	return filepath.Join(projectConfig.OutputDir, proj.AssemblyName+".dll"), nil
+}
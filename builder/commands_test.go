@@ -0,0 +1,255 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/analyzers/solution"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
+	"github.com/stretchr/testify/require"
+)
+
+func sdkStyleProject(sdk constants.SDK, targetFrameworks ...string) project.Model {
+	return project.Model{
+		Pth:              "/solution/App/App.csproj",
+		Name:             "App",
+		SDK:              sdk,
+		Sdk:              "Microsoft.NET.Sdk",
+		TargetFrameworks: targetFrameworks,
+		ConfigMap: map[string]string{
+			"Release|iPhone":          "Release|AnyCPU",
+			"Release|iPhoneSimulator": "Release|AnyCPU",
+			"Release|Any CPU":         "Release|AnyCPU",
+		},
+		Configs: map[string]project.ConfigurationPlatformModel{
+			"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", AndroidPackageFormat: "aab"},
+		},
+	}
+}
+
+func TestBuildProjectCommandForSDKStyleProjects(t *testing.T) {
+	// the commands are created without the dotnet CLI, it is located when the command runs
+	toolchain.SetOverride(toolchain.Dotnet, "/not/existing/dotnet")
+	defer toolchain.SetOverride(toolchain.Dotnet, "")
+
+	builder := Model{solution: solution.Model{Pth: "/solution/App.sln"}, buildTool: buildtools.Msbuild}
+
+	t.Log("it publishes Android projects with the dotnet CLI")
+	{
+		proj := sdkStyleProject(constants.SDKAndroid, "net8.0-android", "net8.0-ios")
+		commands, warnings, err := builder.buildProjectCommand("Release", "Any CPU", proj, false)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+		require.Equal(t, 1, len(commands))
+		require.Equal(t, `"dotnet" "publish" "/solution/App/App.csproj" "-f" "net8.0-android" "-c" "Release" "-p:SolutionDir=/solution/" "-p:AndroidPackageFormat=aab"`, commands[0].String())
+	}
+
+	t.Log("it publishes iOS device builds")
+	{
+		proj := sdkStyleProject(constants.SDKIOS, "net8.0-android", "net8.0-ios")
+		commands, _, err := builder.buildProjectCommand("Release", "iPhone", proj, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(commands))
+		require.Equal(t, `"dotnet" "publish" "/solution/App/App.csproj" "-f" "net8.0-ios" "-c" "Release" "-r" "ios-arm64" "-p:SolutionDir=/solution/" "-p:ArchiveOnBuild=true" "-p:BuildIpa=true"`, commands[0].String())
+	}
+
+	t.Log("it builds iOS simulator builds")
+	{
+		proj := sdkStyleProject(constants.SDKIOS, "net8.0-ios")
+		commands, _, err := builder.buildProjectCommand("Release", "iPhoneSimulator", proj, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(commands))
+		require.Equal(t, `"dotnet" "build" "/solution/App/App.csproj" "-f" "net8.0-ios" "-c" "Release" "-p:SolutionDir=/solution/"`, commands[0].String())
+	}
+
+	t.Log("it builds the projects of the builders without solution without SolutionDir")
+	{
+		builder := Model{projectPth: "/solution/App/App.csproj", buildTool: buildtools.Msbuild}
+		proj := sdkStyleProject(constants.SDKAndroid, "net8.0-android")
+		commands, _, err := builder.buildProjectCommand("Release", "Any CPU", proj, false)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(commands))
+		require.Equal(t, `"dotnet" "publish" "/solution/App/App.csproj" "-f" "net8.0-android" "-c" "Release" "-p:AndroidPackageFormat=aab"`, commands[0].String())
+	}
+
+	t.Log("it does not build SDK-style library projects")
+	{
+		proj := sdkStyleProject(constants.SDKUnknown, "net8.0")
+		commands, _, err := builder.buildProjectCommand("Release", "Any CPU", proj, false)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(commands))
+	}
+}
+
+func TestBuildSolutionCommand(t *testing.T) {
+	toolchain.SetOverride(toolchain.Dotnet, "/not/existing/dotnet")
+	defer toolchain.SetOverride(toolchain.Dotnet, "")
+
+	t.Log("it builds the solution with the dotnet CLI")
+	{
+		builder := Model{solution: solution.Model{Pth: "/solution/App.sln"}, buildTool: buildtools.DotnetCLI}
+		command, err := builder.buildSolutionCommand("Release", "Any CPU")
+		require.NoError(t, err)
+		require.Equal(t, `"dotnet" "build" "/solution/App.sln" "-c" "Release" "-p:SolutionDir=/solution/" "-p:Platform=Any CPU"`, command.String())
+	}
+
+	t.Log("it builds the project of the builders without solution")
+	{
+		builder := Model{projectPth: "/project/App/App.csproj", buildTool: buildtools.DotnetCLI}
+		command, err := builder.buildSolutionCommand("Release", "iPhone")
+		require.NoError(t, err)
+		require.Equal(t, `"dotnet" "build" "/project/App/App.csproj" "-c" "Release" "-p:Platform=iPhone"`, command.String())
+	}
+
+	t.Log("it fails to run the command without the dotnet CLI")
+	{
+		builder := Model{projectPth: "/project/App/App.csproj", buildTool: buildtools.DotnetCLI}
+		command, err := builder.buildSolutionCommand("Release", "iPhone")
+		require.NoError(t, err)
+
+		err = command.Run(io.Discard, io.Discard)
+		require.EqualError(t, err, "the dotnet CLI is required to build the SDK-style projects, error: dotnet override (/not/existing/dotnet) is invalid: not exist: /not/existing/dotnet")
+	}
+}
+
+type waitingCommand struct{}
+
+func (command waitingCommand) String() string                     { return "wait" }
+func (command waitingCommand) SetCustomOptions(options ...string) {}
+func (command waitingCommand) Run(outWriter, errWriter io.Writer) error {
+	return command.RunContext(context.Background(), outWriter, errWriter)
+}
+func (command waitingCommand) RunContext(ctx context.Context, outWriter, errWriter io.Writer) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(10 * time.Second):
+		return nil
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	t.Log("it stops the command after the command timeout")
+	{
+		builder := Model{}
+		builder.SetCommandTimeout(100 * time.Millisecond)
+
+		err := builder.runCommand(context.Background(), waitingCommand{})
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+	}
+
+	t.Log("it stops the command if the context is cancelled")
+	{
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		err := Model{}.runCommand(ctx, waitingCommand{})
+		require.True(t, errors.Is(err, context.Canceled))
+	}
+}
+
+type failingBuildCommand struct{}
+
+func (command failingBuildCommand) String() string                     { return "build" }
+func (command failingBuildCommand) SetCustomOptions(options ...string) {}
+func (command failingBuildCommand) Run(outWriter, errWriter io.Writer) error {
+	return command.RunContext(context.Background(), outWriter, errWriter)
+}
+func (command failingBuildCommand) RunContext(ctx context.Context, outWriter, errWriter io.Writer) error {
+	if _, err := io.WriteString(outWriter, "/src/App/Main.cs(10,5): error CS0103: The name 'foo' does not exist in the current context [/src/App/App.csproj]\n"); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(errWriter, "MTOUCH : warning MT0079: The recommended Xcode version is 12.0"); err != nil {
+		return err
+	}
+	return errors.New("exit status 1")
+}
+
+func TestRunCommandDiagnostics(t *testing.T) {
+	t.Log("it returns the diagnostics of the failed command")
+	{
+		builder := Model{}
+		builder.SetOutputs(io.Discard, io.Discard)
+
+		err := builder.runCommand(context.Background(), failingBuildCommand{})
+		require.Error(t, err)
+		require.Equal(t, "exit status 1, 1 error(s): /src/App/Main.cs(10,5): error CS0103: The name 'foo' does not exist in the current context", err.Error())
+
+		var commandErr *CommandError
+		require.True(t, errors.As(err, &commandErr))
+		require.Equal(t, "build", commandErr.Command)
+		require.Equal(t, []buildtools.Diagnostic{
+			{
+				Project:  "/src/App/App.csproj",
+				File:     "/src/App/Main.cs",
+				Line:     10,
+				Column:   5,
+				Severity: buildtools.SeverityError,
+				Code:     "CS0103",
+				Message:  "The name 'foo' does not exist in the current context",
+			},
+			{
+				File:     "MTOUCH",
+				Severity: buildtools.SeverityWarning,
+				Code:     "MT0079",
+				Message:  "The recommended Xcode version is 12.0",
+			},
+		}, commandErr.Diagnostics)
+	}
+}
+
+func TestBuildTestProjectCommands(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	defer fakeToolchain(t, tmpDir, toolchain.Mono, toolchain.Dotnet)()
+
+	builder := Model{solution: solution.Model{Pth: "/solution/App.sln"}, buildTool: buildtools.Msbuild}
+	testProject := func(sdkStyle bool) project.Model {
+		proj := project.Model{
+			Pth:          "/solution/Tests/Tests.csproj",
+			Name:         "Tests",
+			AssemblyName: "Tests",
+			ConfigMap:    map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+			Configs: map[string]project.ConfigurationPlatformModel{
+				"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", OutputDir: "/solution/Tests/bin/Release"},
+			},
+		}
+		if sdkStyle {
+			proj.Sdk = "Microsoft.NET.Sdk"
+		}
+		return proj
+	}
+
+	t.Log("it runs the xunit tests of the SDK-style projects with dotnet vstest")
+	{
+		command, _, err := builder.buildXunitTestProjectCommand("Release", "Any CPU", testProject(true), "")
+		require.NoError(t, err)
+		require.Equal(t, `"`+filepath.Join(tmpDir, "dotnet")+`" "vstest" "/solution/Tests/bin/Release/Tests.dll"`, command.String())
+	}
+
+	t.Log("it runs the xunit tests of the legacy projects with the xunit console")
+	{
+		command, _, err := builder.buildXunitTestProjectCommand("Release", "Any CPU", testProject(false), "/xunit/xunit.console.exe")
+		require.NoError(t, err)
+		require.Equal(t, `"`+filepath.Join(tmpDir, "mono")+`" "/xunit/xunit.console.exe" "/solution/Tests/bin/Release/Tests.dll"`, command.String())
+	}
+
+	t.Log("it runs the mstest tests with the located dotnet")
+	{
+		command, _, err := builder.buildMSTestProjectCommand("Release", "Any CPU", testProject(true))
+		require.NoError(t, err)
+		require.Equal(t, `"`+filepath.Join(tmpDir, "dotnet")+`" "vstest" "/solution/Tests/bin/Release/Tests.dll"`, command.String())
+	}
+}
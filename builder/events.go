@@ -0,0 +1,246 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools"
+)
+
+// Event is an event of the builder reported to its observers,
+// one of ProjectSkipped, CommandPrepared, CommandStarted, CommandFinished, ArtifactCollected and CleanRemoved.
+type Event interface {
+	Name() string
+}
+
+// Observer observes the builder's events, the events are reported synchronously in the builder's goroutine.
+// The parallel builds report the events of the concurrently built projects one at a time, see SetMaxParallelism.
+type Observer interface {
+	OnEvent(event Event)
+}
+
+// ObserverFunc is an Observer function.
+type ObserverFunc func(event Event)
+
+// OnEvent ...
+func (f ObserverFunc) OnEvent(event Event) {
+	f(event)
+}
+
+// CommandInfo describes the solution and project a command belongs to.
+type CommandInfo struct {
+	Solution      string
+	Project       string // Empty for the commands building the whole solution
+	SDK           constants.SDK
+	TestFramework constants.TestFramework
+}
+
+// ProjectSkipped is reported for the projects the builder does not build or test.
+type ProjectSkipped struct {
+	Solution string
+	Project  string
+	Reason   string
+}
+
+// CommandPrepared is reported after creating a command, the observer can modify the command before it runs.
+type CommandPrepared struct {
+	CommandInfo
+	Command *tools.Editable
+}
+
+// CommandStarted is reported before running a command, or instead of running it if it was already performed.
+type CommandStarted struct {
+	CommandInfo
+	Command          string
+	AlreadyPerformed bool
+}
+
+// CommandResult ...
+type CommandResult string
+
+const (
+	// CommandSucceeded ...
+	CommandSucceeded CommandResult = "succeeded"
+	// CommandFailed ...
+	CommandFailed CommandResult = "failed"
+	// CommandCancelled is the result of the commands stopped by the context or the command timeout.
+	CommandCancelled CommandResult = "cancelled"
+)
+
+// CommandFinished is reported after running a command.
+type CommandFinished struct {
+	CommandInfo
+	Command  string
+	Duration time.Duration
+	ExitCode int // -1 if the command did not exit normally
+	Result   CommandResult
+	Err      error
+}
+
+// ArtifactCollected is reported for the outputs found by CollectProjectOutputs and CollectXamarinUITestProjectOutputs.
+type ArtifactCollected struct {
+	Solution string
+	Project  string
+	SDK      constants.SDK
+	Output   OutputModel
+}
+
+// CleanRemoved is reported before CleanAll removes a project's output directory.
+type CleanRemoved struct {
+	Solution string
+	Project  project.Model
+	Dir      string
+}
+
+// Name ...
+func (ProjectSkipped) Name() string { return "ProjectSkipped" }
+
+// Name ...
+func (CommandPrepared) Name() string { return "CommandPrepared" }
+
+// Name ...
+func (CommandStarted) Name() string { return "CommandStarted" }
+
+// Name ...
+func (CommandFinished) Name() string { return "CommandFinished" }
+
+// Name ...
+func (ArtifactCollected) Name() string { return "ArtifactCollected" }
+
+// Name ...
+func (CleanRemoved) Name() string { return "CleanRemoved" }
+
+// AddObserver adds an observer of the builder's events.
+func (builder *Model) AddObserver(observer Observer) {
+	builder.observers = append(builder.observers, observer)
+}
+
+// withCallbacks returns a copy of the builder which reports its events to the given callbacks too.
+func (builder Model) withCallbacks(prepareCallback PrepareCommandCallback, callback BuildCommandCallback) Model {
+	if prepareCallback == nil && callback == nil {
+		return builder
+	}
+	return builder.withObserver(callbackObserver{prepareCallback: prepareCallback, callback: callback})
+}
+
+func (builder Model) withObserver(observer Observer) Model {
+	observers := make([]Observer, 0, len(builder.observers)+1)
+	observers = append(observers, builder.observers...)
+	builder.observers = append(observers, observer)
+	return builder
+}
+
+// withLockedObservers returns a copy of the builder which reports its events holding the given lock,
+// so the observers are not called concurrently.
+func (builder Model) withLockedObservers(mu *sync.Mutex) Model {
+	observers := make([]Observer, 0, len(builder.observers))
+	for _, observer := range builder.observers {
+		observers = append(observers, lockedObserver{mu: mu, observer: observer})
+	}
+	builder.observers = observers
+	return builder
+}
+
+func (builder Model) notify(event Event) {
+	for _, observer := range builder.observers {
+		observer.OnEvent(event)
+	}
+}
+
+func (builder Model) commandInfo(proj project.Model) CommandInfo {
+	return CommandInfo{Solution: builder.solution.Name, Project: proj.Name, SDK: proj.SDK, TestFramework: proj.TestFramework}
+}
+
+// solutionCommandInfo describes the commands building the whole solution.
+func (builder Model) solutionCommandInfo() CommandInfo {
+	return CommandInfo{Solution: builder.solution.Name, SDK: constants.SDKUnknown, TestFramework: constants.TestFrameworkUnknown}
+}
+
+func (builder Model) notifySkipped(skipped []SkippedProject) {
+	for _, skippedProject := range skipped {
+		builder.notify(ProjectSkipped{Solution: builder.solution.Name, Project: skippedProject.Project, Reason: skippedProject.Reason})
+	}
+}
+
+// prepareCommand reports the created command to the observers, which can modify it.
+func (builder Model) prepareCommand(info CommandInfo, command tools.Runnable) {
+	editableCommand := tools.Editable(command)
+	builder.notify(CommandPrepared{CommandInfo: info, Command: &editableCommand})
+}
+
+// runObservedCommand reports the command to the observers and runs it, unless it was already performed.
+func (builder Model) runObservedCommand(ctx context.Context, info CommandInfo, command tools.Runnable, alreadyPerformed bool) error {
+	commandStr := command.String()
+	builder.notify(CommandStarted{CommandInfo: info, Command: commandStr, AlreadyPerformed: alreadyPerformed})
+	if alreadyPerformed {
+		return nil
+	}
+
+	startTime := time.Now()
+	err := builder.runCommand(ctx, command)
+
+	result := CommandSucceeded
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		result = CommandCancelled
+	} else if err != nil {
+		result = CommandFailed
+	}
+	builder.notify(CommandFinished{
+		CommandInfo: info,
+		Command:     commandStr,
+		Duration:    time.Since(startTime),
+		ExitCode:    tools.ExitCode(err),
+		Result:      result,
+		Err:         err,
+	})
+
+	return err
+}
+
+// callbackObserver adapts the PrepareCommandCallback and BuildCommandCallback callbacks to an Observer.
+type callbackObserver struct {
+	prepareCallback PrepareCommandCallback
+	callback        BuildCommandCallback
+}
+
+// OnEvent ...
+func (observer callbackObserver) OnEvent(event Event) {
+	switch e := event.(type) {
+	case CommandPrepared:
+		if observer.prepareCallback != nil {
+			observer.prepareCallback(e.Solution, e.Project, e.SDK, e.TestFramework, e.Command)
+		}
+	case CommandStarted:
+		if observer.callback != nil {
+			observer.callback(e.Solution, e.Project, e.SDK, e.TestFramework, e.Command, e.AlreadyPerformed)
+		}
+	}
+}
+
+// clearCallbackObserver adapts the ClearCommandCallback callback to an Observer.
+type clearCallbackObserver ClearCommandCallback
+
+// OnEvent ...
+func (observer clearCallbackObserver) OnEvent(event Event) {
+	if e, ok := event.(CleanRemoved); ok {
+		observer(e.Project, e.Dir)
+	}
+}
+
+// lockedObserver reports the events to the observer holding the lock.
+type lockedObserver struct {
+	mu       *sync.Mutex
+	observer Observer
+}
+
+// OnEvent ...
+func (observer lockedObserver) OnEvent(event Event) {
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+
+	observer.observer.OnEvent(event)
+}
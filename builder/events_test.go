@@ -0,0 +1,174 @@
+package builder
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/analyzers/solution"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserver(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	defer fakeToolchain(t, tmpDir, toolchain.Msbuild)()
+
+	outputDir := filepath.Join(tmpDir, "App.Droid", "bin", "Release")
+	app := project.Model{
+		ID:                 "APP",
+		Name:               "App.Droid",
+		Pth:                filepath.Join(tmpDir, "App.Droid", "App.Droid.csproj"),
+		SDK:                constants.SDKAndroid,
+		AndroidApplication: true,
+		ApplicationID:      "com.bitrise.app",
+		ConfigMap:          map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+		Configs: map[string]project.ConfigurationPlatformModel{
+			"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", OutputDir: outputDir, SignAndroid: true},
+		},
+	}
+	lib := project.Model{
+		ID:        "LIB",
+		Name:      "Lib.Droid",
+		Pth:       filepath.Join(tmpDir, "Lib.Droid", "Lib.Droid.csproj"),
+		SDK:       constants.SDKAndroid,
+		ConfigMap: map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+		Configs: map[string]project.ConfigurationPlatformModel{
+			"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", OutputDir: filepath.Join(tmpDir, "Lib.Droid", "bin", "Release")},
+		},
+	}
+	newBuilder := func() Model {
+		return Model{
+			solution: solution.Model{
+				Name:       "App",
+				Pth:        filepath.Join(tmpDir, "App.sln"),
+				ConfigMap:  map[string]string{"Release|Any CPU": "Release|Any CPU"},
+				ProjectMap: map[string]project.Model{app.ID: app, lib.ID: lib},
+			},
+			buildTool: buildtools.Msbuild,
+			outWriter: io.Discard,
+			errWriter: io.Discard,
+		}
+	}
+
+	t.Log("it reports the typed events of the build")
+	{
+		builder := newBuilder()
+		apkPth := filepath.Join(outputDir, "com.bitrise.app-Signed.apk")
+		builder.SetExecutor(tools.NewFakeExecutor().OnArg("/target:SignAndroidPackage", tools.FakeResult{
+			Artifacts: map[string]string{apkPth: "apk"},
+		}))
+
+		var events []Event
+		builder.AddObserver(ObserverFunc(func(event Event) {
+			events = append(events, event)
+		}))
+
+		startTime := time.Now().Add(-time.Second)
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, nil, nil)
+		require.NoError(t, err)
+
+		_, err = builder.CollectProjectOutputs("Release", "Any CPU", startTime, time.Now().Add(time.Second))
+		require.NoError(t, err)
+
+		names := []string{}
+		for _, event := range events {
+			names = append(names, event.Name())
+		}
+		require.Equal(t, []string{"CommandPrepared", "ProjectSkipped", "CommandStarted", "CommandFinished", "ArtifactCollected"}, names)
+
+		info := CommandInfo{Solution: "App", Project: "App.Droid", SDK: constants.SDKAndroid}
+		require.Equal(t, info, events[0].(CommandPrepared).CommandInfo)
+		require.Equal(t, ProjectSkipped{Solution: "App", Project: "Lib.Droid", Reason: "is not an android application project"}, events[1])
+		require.Equal(t, info, events[2].(CommandStarted).CommandInfo)
+
+		finished := events[3].(CommandFinished)
+		require.Equal(t, info, finished.CommandInfo)
+		require.Equal(t, events[2].(CommandStarted).Command, finished.Command)
+		require.Equal(t, CommandSucceeded, finished.Result)
+		require.Equal(t, 0, finished.ExitCode)
+		require.NoError(t, finished.Err)
+		require.True(t, finished.Duration >= 0)
+
+		require.Equal(t, ArtifactCollected{
+			Solution: "App",
+			Project:  "App.Droid",
+			SDK:      constants.SDKAndroid,
+			Output:   OutputModel{Pth: apkPth, OutputType: constants.OutputTypeAPK},
+		}, events[4])
+	}
+
+	t.Log("it reports the exit code and the result of the failed commands")
+	{
+		builder := newBuilder()
+		builder.SetExecutor(tools.NewFakeExecutor().OnArg("/target:SignAndroidPackage", tools.FakeResult{ExitCode: 2}))
+
+		var finished []CommandFinished
+		builder.AddObserver(ObserverFunc(func(event Event) {
+			if e, ok := event.(CommandFinished); ok {
+				finished = append(finished, e)
+			}
+		}))
+
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, nil, nil)
+		require.Error(t, err)
+		require.Equal(t, 1, len(finished))
+		require.Equal(t, CommandFailed, finished[0].Result)
+		require.Equal(t, 2, finished[0].ExitCode)
+		require.Error(t, finished[0].Err)
+	}
+
+	t.Log("the callbacks are adapters of the observer")
+	{
+		builder := newBuilder()
+		executor := tools.NewFakeExecutor()
+		builder.SetExecutor(executor)
+
+		prepareCallback := func(solutionName string, projectName string, sdk constants.SDK, testFramwork constants.TestFramework, command *tools.Editable) {
+			(*command).SetCustomOptions("/p:Custom=true")
+		}
+		var started []string
+		callback := func(solutionName string, projectName string, sdk constants.SDK, testFramwork constants.TestFramework, commandStr string, alreadyPerformed bool) {
+			started = append(started, projectName)
+		}
+
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, prepareCallback, callback)
+		require.NoError(t, err)
+		require.Equal(t, []string{"App.Droid"}, started)
+		require.Contains(t, executor.Commands()[0], "/p:Custom=true")
+	}
+
+	t.Log("it reports the removed directories of the clean")
+	{
+		builder := newBuilder()
+		require.NoError(t, os.MkdirAll(outputDir, 0755))
+
+		var removed []CleanRemoved
+		builder.AddObserver(ObserverFunc(func(event Event) {
+			if e, ok := event.(CleanRemoved); ok {
+				removed = append(removed, e)
+			}
+		}))
+
+		cleared := []string{}
+		require.NoError(t, builder.CleanAll(func(project project.Model, dir string) {
+			cleared = append(cleared, dir)
+		}))
+
+		binPth := filepath.Join(tmpDir, "App.Droid", "bin")
+		require.Equal(t, []CleanRemoved{{Solution: "App", Project: app, Dir: binPth}}, removed)
+		require.Equal(t, []string{binPth}, cleared)
+	}
+}
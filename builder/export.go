@@ -162,12 +162,19 @@ func exportXCArchive(outputDir, assemblyName string, startTime, endTime time.Tim
 	)
 }
 
-func exportLatestXCArchiveFromXcodeArchives(assemblyName string, startTime, endTime time.Time) (string, error) {
+// defaultXcodeArchivesDir returns the dir Xcode archives to by default, or an empty string if the user home dir is not set.
+func defaultXcodeArchivesDir() string {
 	userHomeDir, ok := os.LookupEnv("HOME")
 	if !ok {
-		return "", fmt.Errorf("failed to get user home dir")
+		return ""
+	}
+	return filepath.Join(userHomeDir, "Library/Developer/Xcode/Archives")
+}
+
+func exportLatestXCArchiveFromXcodeArchives(xcodeArchivesDir, assemblyName string, startTime, endTime time.Time) (string, error) {
+	if xcodeArchivesDir == "" {
+		return "", fmt.Errorf("failed to get the Xcode archives dir")
 	}
-	xcodeArchivesDir := filepath.Join(userHomeDir, "Library/Developer/Xcode/Archives")
 	if exist, err := pathutil.IsDirExists(xcodeArchivesDir); err != nil {
 		return "", err
 	} else if !exist {
@@ -0,0 +1,132 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// SolutionConfig is a Configuration|Platform pair of the solution.
+type SolutionConfig struct {
+	Configuration string
+	Platform      string
+}
+
+// ParseSolutionConfig parses a Configuration|Platform solution config.
+func ParseSolutionConfig(config string) (SolutionConfig, error) {
+	split := strings.Split(config, "|")
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return SolutionConfig{}, fmt.Errorf("invalid solution config (%s), expected format: Configuration|Platform", config)
+	}
+	return SolutionConfig{Configuration: split[0], Platform: split[1]}, nil
+}
+
+// String returns the solution config in Configuration|Platform format.
+func (config SolutionConfig) String() string {
+	return utility.ToConfig(config.Configuration, config.Platform)
+}
+
+// MatrixOutputMap is the outputs of a matrix build, keyed by the solution config in Configuration|Platform format.
+type MatrixOutputMap map[string]ProjectOutputMap
+
+// BuildMatrix builds the buildable projects for each of the given solution configs in order, see BuildAllProjects,
+// and collects the outputs generated while each solution config was built.
+// Every solution config is validated before building, the solution analysis is shared by the builds.
+// The commands already performed for an earlier solution config are reported to the observers but not run again,
+// their outputs are collected from the time of the earlier solution config's build.
+func (builder Model) BuildMatrix(ctx context.Context, configs []SolutionConfig, buildIpa bool, prepareCallback PrepareCommandCallback, callback BuildCommandCallback) (MatrixOutputMap, []string, error) {
+	outputMap := MatrixOutputMap{}
+	warnings := []string{}
+
+	if len(configs) == 0 {
+		return outputMap, warnings, fmt.Errorf("no solution config specified")
+	}
+	for _, config := range configs {
+		if err := validateSolutionConfig(builder.solution, config.Configuration, config.Platform); err != nil {
+			return outputMap, warnings, err
+		}
+	}
+
+	builder = builder.withCallbacks(prepareCallback, callback)
+
+	plans := []Plan{}
+	performedBy := map[string]int{} // Command - the index of the solution config performing it
+	for i, config := range configs {
+		plan, err := builder.planBuildAllProjects(config.Configuration, config.Platform, buildIpa)
+		builder.notifySkipped(plan.Skipped)
+		warnings = append(warnings, skippedProjectWarnings(plan.Skipped)...)
+		warnings = append(warnings, plan.Warnings...)
+		if err != nil {
+			return outputMap, warnings, fmt.Errorf("solution config (%s): %w", config, err)
+		}
+
+		for j, command := range plan.Commands {
+			if _, ok := performedBy[command.Command]; ok {
+				plan.Commands[j].AlreadyPerformed = true
+			} else {
+				performedBy[command.Command] = i
+			}
+		}
+		plans = append(plans, plan)
+	}
+
+	windows := make([]timeWindow, len(plans))
+	for i, plan := range plans {
+		config := configs[i]
+
+		// the start time is truncated as the file systems store the modification times with lower precision
+		windows[i].start = time.Now().Truncate(time.Second)
+
+		warns, err := builder.runProjectsPlan(ctx, plan)
+		warnings = append(warnings, warns...)
+		if err != nil {
+			return outputMap, warnings, fmt.Errorf("solution config (%s): %w", config, err)
+		}
+
+		windows[i].end = time.Now()
+
+		// the outputs of the commands performed for an earlier solution config are generated in that solution config's window
+		projectWindows := map[string]timeWindow{}
+		for _, command := range plan.Commands {
+			window := windows[performedBy[command.Command]]
+			if projectWindow, ok := projectWindows[command.Project]; ok {
+				window = projectWindow.union(window)
+			}
+			projectWindows[command.Project] = window
+		}
+
+		projectOutputMap, err := builder.collectProjectOutputs(config.Configuration, config.Platform, func(proj project.Model) (time.Time, time.Time) {
+			window, ok := projectWindows[proj.Name]
+			if !ok {
+				window = windows[i]
+			}
+			return window.start, window.end
+		})
+		if err != nil {
+			return outputMap, warnings, fmt.Errorf("solution config (%s): failed to collect outputs, error: %s", config, err)
+		}
+		outputMap[config.String()] = projectOutputMap
+	}
+
+	return outputMap, warnings, nil
+}
+
+// timeWindow is the time a solution config's build ran.
+type timeWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+func (window timeWindow) union(other timeWindow) timeWindow {
+	if other.start.Before(window.start) {
+		window.start = other.start
+	}
+	if other.end.After(window.end) {
+		window.end = other.end
+	}
+	return window
+}
@@ -0,0 +1,179 @@
+package builder
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/analyzers/solution"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSolutionConfig(t *testing.T) {
+	config, err := ParseSolutionConfig("Release|Any CPU")
+	require.NoError(t, err)
+	require.Equal(t, SolutionConfig{Configuration: "Release", Platform: "Any CPU"}, config)
+	require.Equal(t, "Release|Any CPU", config.String())
+
+	for _, invalid := range []string{"", "Release", "Release|", "|Any CPU", "Release|Any CPU|x86"} {
+		_, err := ParseSolutionConfig(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestBuildMatrix(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	defer fakeToolchain(t, tmpDir, toolchain.Msbuild)()
+
+	releaseDir := filepath.Join(tmpDir, "App.Droid", "bin", "Release")
+	debugDir := filepath.Join(tmpDir, "App.Droid", "bin", "Debug")
+	app := project.Model{
+		ID:                 "APP",
+		Name:               "App.Droid",
+		Pth:                filepath.Join(tmpDir, "App.Droid", "App.Droid.csproj"),
+		SDK:                constants.SDKAndroid,
+		AndroidApplication: true,
+		ApplicationID:      "com.bitrise.app",
+		ConfigMap: map[string]string{
+			"Release|Any CPU": "Release|AnyCPU",
+			"Release|x86":     "Release|AnyCPU",
+			"Debug|Any CPU":   "Debug|AnyCPU",
+		},
+		Configs: map[string]project.ConfigurationPlatformModel{
+			"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", OutputDir: releaseDir, SignAndroid: true},
+			"Debug|AnyCPU":   {Configuration: "Debug", Platform: "AnyCPU", OutputDir: debugDir, SignAndroid: true},
+		},
+	}
+	newBuilder := func(executor tools.Executor) Model {
+		builder := Model{
+			solution: solution.Model{
+				Name: "App",
+				Pth:  filepath.Join(tmpDir, "App.sln"),
+				ConfigMap: map[string]string{
+					"Release|Any CPU": "Release|Any CPU",
+					"Release|x86":     "Release|x86",
+					"Debug|Any CPU":   "Debug|Any CPU",
+				},
+				ProjectMap: map[string]project.Model{app.ID: app},
+			},
+			buildTool: buildtools.Msbuild,
+			outWriter: io.Discard,
+			errWriter: io.Discard,
+		}
+		builder.SetExecutor(executor)
+		return builder
+	}
+
+	t.Log("it builds every solution config and collects the outputs by solution config")
+	{
+		releaseApk := filepath.Join(releaseDir, "com.bitrise.app-Signed.apk")
+		debugApk := filepath.Join(debugDir, "com.bitrise.app-Signed.apk")
+		executor := tools.NewFakeExecutor().
+			OnArg("Configuration=Release", tools.FakeResult{Artifacts: map[string]string{releaseApk: "release"}}).
+			OnArg("Configuration=Debug", tools.FakeResult{Artifacts: map[string]string{debugApk: "debug"}})
+		builder := newBuilder(executor)
+
+		var started []CommandStarted
+		builder.AddObserver(ObserverFunc(func(event Event) {
+			if e, ok := event.(CommandStarted); ok {
+				started = append(started, e)
+			}
+		}))
+
+		configs := []SolutionConfig{
+			{Configuration: "Release", Platform: "Any CPU"},
+			{Configuration: "Release", Platform: "x86"},
+			{Configuration: "Debug", Platform: "Any CPU"},
+		}
+		outputs, warnings, err := builder.BuildMatrix(context.Background(), configs, true, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{}, warnings)
+
+		// the commands already performed for an earlier solution config are not run again
+		require.Equal(t, 2, len(executor.Commands()))
+		require.Equal(t, 3, len(started))
+		require.False(t, started[0].AlreadyPerformed)
+		require.True(t, started[1].AlreadyPerformed)
+		require.False(t, started[2].AlreadyPerformed)
+
+		require.Equal(t, MatrixOutputMap{
+			"Release|Any CPU": {"App.Droid": {ProjectType: constants.SDKAndroid, Outputs: []OutputModel{{Pth: releaseApk, OutputType: constants.OutputTypeAPK}}}},
+			"Release|x86":     {"App.Droid": {ProjectType: constants.SDKAndroid, Outputs: []OutputModel{{Pth: releaseApk, OutputType: constants.OutputTypeAPK}}}},
+			"Debug|Any CPU":   {"App.Droid": {ProjectType: constants.SDKAndroid, Outputs: []OutputModel{{Pth: debugApk, OutputType: constants.OutputTypeAPK}}}},
+		}, outputs)
+	}
+
+	t.Log("it collects the outputs of each solution config generated while the solution config was built")
+	{
+		releaseApk := filepath.Join(releaseDir, "com.bitrise.app-Signed.apk")
+		fakeExecutor := tools.NewFakeExecutor().
+			OnArg("/p:Platform=x86", tools.FakeResult{}).
+			OnArg("/p:Configuration=Release", tools.FakeResult{Artifacts: map[string]string{releaseApk: "release"}})
+		// the x86 build starts in a later second than the apk of the Release|Any CPU build was generated
+		executor := executorFunc(func(ctx context.Context, cmdSlice []string, outWriter, errWriter io.Writer) error {
+			err := fakeExecutor.Execute(ctx, cmdSlice, outWriter, errWriter)
+			time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(1100 * time.Millisecond)))
+			return err
+		})
+
+		x86App := app
+		x86App.ConfigMap = map[string]string{"Release|Any CPU": "Release|AnyCPU", "Release|x86": "Release|x86"}
+		x86App.Configs = map[string]project.ConfigurationPlatformModel{
+			"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", OutputDir: releaseDir, SignAndroid: true},
+			"Release|x86":    {Configuration: "Release", Platform: "x86", OutputDir: releaseDir, SignAndroid: true},
+		}
+		builder := newBuilder(executor)
+		builder.solution.ProjectMap = map[string]project.Model{x86App.ID: x86App}
+
+		configs := []SolutionConfig{
+			{Configuration: "Release", Platform: "Any CPU"},
+			{Configuration: "Release", Platform: "x86"},
+		}
+		outputs, _, err := builder.BuildMatrix(context.Background(), configs, true, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(fakeExecutor.Commands()))
+
+		require.Equal(t, MatrixOutputMap{
+			"Release|Any CPU": {"App.Droid": {ProjectType: constants.SDKAndroid, Outputs: []OutputModel{{Pth: releaseApk, OutputType: constants.OutputTypeAPK}}}},
+			"Release|x86":     {},
+		}, outputs)
+	}
+
+	t.Log("it validates every solution config before building")
+	{
+		executor := tools.NewFakeExecutor()
+		builder := newBuilder(executor)
+
+		configs := []SolutionConfig{
+			{Configuration: "Release", Platform: "Any CPU"},
+			{Configuration: "Release", Platform: "iPhone"},
+		}
+		_, _, err := builder.BuildMatrix(context.Background(), configs, true, nil, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid solution config: Release|iPhone")
+		require.Equal(t, 0, len(executor.Commands()))
+
+		_, _, err = builder.BuildMatrix(context.Background(), nil, true, nil, nil)
+		require.EqualError(t, err, "no solution config specified")
+	}
+}
+
+// executorFunc runs the commands with the given function.
+type executorFunc func(ctx context.Context, cmdSlice []string, outWriter, errWriter io.Writer) error
+
+func (executor executorFunc) Execute(ctx context.Context, cmdSlice []string, outWriter, errWriter io.Writer) error {
+	return executor(ctx, cmdSlice, outWriter, errWriter)
+}
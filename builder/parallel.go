@@ -0,0 +1,316 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ProjectOutputsFactory returns the writers of the given project's command output, see SetProjectOutputs.
+type ProjectOutputsFactory func(projectName string) (outWriter, errWriter io.Writer)
+
+// BuildError is returned by the keep going builds, it holds the errors of the failed projects in build order.
+type BuildError struct {
+	Errors []ProjectError
+}
+
+// ProjectError is the error of a failed project.
+type ProjectError struct {
+	Project string
+	Err     error
+}
+
+// Error ...
+func (err *BuildError) Error() string {
+	msgs := []string{}
+	for _, projectErr := range err.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", projectErr.Project, projectErr.Err))
+	}
+	return fmt.Sprintf("%d project(s) failed: %s", len(err.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the first failed project's error.
+func (err *BuildError) Unwrap() error {
+	if len(err.Errors) == 0 {
+		return nil
+	}
+	return err.Errors[0].Err
+}
+
+type projectRunState int
+
+const (
+	projectPending projectRunState = iota
+	projectRunning
+	projectSucceeded
+	projectFailed
+	projectSkipped
+)
+
+// projectRun is a project's planned commands, run after the commands of the projects it depends on.
+type projectRun struct {
+	projectID    string
+	project      string
+	commands     []PlannedCommand
+	dependencies []int // The indexes of the earlier runs the project depends on
+	// The run builds the whole solution (like the legacy iOS builds), it runs after the earlier runs and before the later ones
+	solutionWide bool
+
+	state projectRunState
+	err   error
+}
+
+// projectRuns groups the planned commands by project in plan order. A project depends on the earlier projects
+// it depends on in the project graph (directly, or through projects not built), on the earlier projects
+// sharing a dependency not built on its own (like a library referenced by several applications, which each of them builds),
+// and on the projects which performed its already performed commands.
+// The commands not belonging to a project and the commands building the whole solution depend on every earlier project,
+// and every later project depends on them.
+func (builder Model) projectRuns(plan Plan) []*projectRun {
+	// the analyzed project's ID might differ from the solution's project ID
+	projectKeys := map[string]string{}
+	for key, proj := range builder.solution.ProjectMap {
+		projectKeys[strings.ToUpper(proj.ID)] = key
+		projectKeys[key] = key
+	}
+
+	runs := []*projectRun{}
+	runIndex := map[string]int{}    // Project key - run index
+	performedBy := map[string]int{} // Command - the run index performing the command
+	for _, command := range plan.Commands {
+		key, ok := projectKeys[strings.ToUpper(command.projectID)]
+		if !ok {
+			key = command.projectID
+		}
+
+		i, ok := runIndex[key]
+		if !ok || command.projectID == "" {
+			i = len(runs)
+			runs = append(runs, &projectRun{projectID: key, project: command.Project})
+			runIndex[key] = i
+		}
+		run := runs[i]
+		run.commands = append(run.commands, command)
+		if command.projectID == "" || command.Pth == plan.Solution {
+			run.solutionWide = true
+		}
+
+		if !command.AlreadyPerformed {
+			performedBy[command.Command] = i
+		} else if j, ok := performedBy[command.Command]; ok && j != i {
+			run.dependencies = appendIndex(run.dependencies, j)
+		}
+	}
+
+	graph := builder.solution.ProjectGraph()
+	builtBy := map[string]int{} // The ID of a project not built on its own - the first run building it as a dependency
+	for i, run := range runs {
+		if run.solutionWide {
+			for j := 0; j < i; j++ {
+				run.dependencies = appendIndex(run.dependencies, j)
+			}
+			continue
+		}
+
+		visited := map[string]bool{}
+		var visit func(projectID string)
+		visit = func(projectID string) {
+			for _, dependencyID := range graph.Dependencies(projectID) {
+				if visited[dependencyID] {
+					continue
+				}
+				visited[dependencyID] = true
+
+				j, ok := runIndex[dependencyID]
+				if !ok {
+					// the projects building the same dependency can not run concurrently
+					if k, ok := builtBy[dependencyID]; ok {
+						run.dependencies = appendIndex(run.dependencies, k)
+					} else {
+						builtBy[dependencyID] = i
+					}
+					visit(dependencyID)
+				} else if j < i {
+					// the later runs are ignored, they can only be reached through a dependency cycle
+					run.dependencies = appendIndex(run.dependencies, j)
+				}
+			}
+		}
+		visit(run.projectID)
+
+		for j := 0; j < i; j++ {
+			if runs[j].solutionWide {
+				run.dependencies = appendIndex(run.dependencies, j)
+			}
+		}
+	}
+
+	return runs
+}
+
+func appendIndex(indexes []int, index int) []int {
+	for _, i := range indexes {
+		if i == index {
+			return indexes
+		}
+	}
+	return append(indexes, index)
+}
+
+// runProjectsPlan runs the planned project commands concurrently if the max parallelism or the keep going mode is set,
+// otherwise in order. It returns the warnings of the projects skipped because of a failed dependency.
+func (builder Model) runProjectsPlan(ctx context.Context, plan Plan) ([]string, error) {
+	if builder.maxParallelism > 1 || builder.keepGoing {
+		skipped, err := builder.runPlanParallel(ctx, plan)
+		return skippedProjectWarnings(skipped), err
+	}
+	return []string{}, builder.runPlan(ctx, plan)
+}
+
+// runPlanParallel runs the planned commands of the independent projects concurrently, at most maxParallelism projects at a time,
+// the projects are started in plan order once the projects they depend on succeeded.
+// If a project fails, the other projects are cancelled, or in keep going mode the projects depending on it are skipped
+// and the errors of the failed projects are returned in a *BuildError.
+func (builder Model) runPlanParallel(ctx context.Context, plan Plan) ([]SkippedProject, error) {
+	maxParallelism := builder.maxParallelism
+	if maxParallelism < 1 {
+		maxParallelism = 1
+	}
+
+	builder = builder.withLockedObservers(&sync.Mutex{})
+	outputMu := &sync.Mutex{}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	runs := builder.projectRuns(plan)
+	skipped := []SkippedProject{}
+	var firstErr error
+
+	results := make(chan *projectRun)
+	running := 0
+	for {
+		for _, run := range runs {
+			if running >= maxParallelism || runCtx.Err() != nil {
+				break
+			}
+			if run.state != projectPending {
+				continue
+			}
+
+			ready, failedDependency := true, ""
+			for _, j := range run.dependencies {
+				switch runs[j].state {
+				case projectFailed, projectSkipped:
+					if failedDependency == "" {
+						failedDependency = runs[j].project
+					}
+				case projectPending, projectRunning:
+					ready = false
+				}
+			}
+
+			if failedDependency != "" {
+				run.state = projectSkipped
+				skippedProject := SkippedProject{Project: run.project, Reason: fmt.Sprintf("depends on the failed project (%s)", failedDependency)}
+				skipped = append(skipped, skippedProject)
+				builder.notifySkipped([]SkippedProject{skippedProject})
+				continue
+			}
+			if !ready {
+				continue
+			}
+
+			run.state = projectRunning
+			running++
+			go func(run *projectRun) {
+				run.err = builder.runProject(runCtx, run, outputMu)
+				results <- run
+			}(run)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		run := <-results
+		running--
+		if run.err == nil {
+			run.state = projectSucceeded
+			continue
+		}
+
+		run.state = projectFailed
+		if firstErr == nil {
+			firstErr = run.err
+		}
+		if !builder.keepGoing {
+			cancel()
+		}
+	}
+
+	if firstErr != nil && !builder.keepGoing {
+		return skipped, firstErr
+	}
+
+	if firstErr != nil {
+		buildErr := &BuildError{}
+		for _, run := range runs {
+			if run.state == projectFailed {
+				buildErr.Errors = append(buildErr.Errors, ProjectError{Project: run.project, Err: run.err})
+			}
+		}
+		return skipped, buildErr
+	}
+
+	return skipped, ctx.Err()
+}
+
+// runProject runs the project's commands in order with the project's output writers.
+func (builder Model) runProject(ctx context.Context, run *projectRun, outputMu *sync.Mutex) (err error) {
+	outWriter, errWriter, flush := builder.projectWriters(run.project, outputMu)
+	defer func() {
+		if flushErr := flush(); flushErr != nil && err == nil {
+			err = fmt.Errorf("failed to write the output of project (%s), error: %s", run.project, flushErr)
+		}
+	}()
+	builder.outWriter, builder.errWriter = outWriter, errWriter
+
+	for _, command := range run.commands {
+		info := CommandInfo{Solution: builder.solution.Name, Project: command.Project, SDK: command.SDK, TestFramework: command.TestFramework}
+		if err := builder.runObservedCommand(ctx, info, command.runnable, command.AlreadyPerformed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectWriters returns the writers of the given project's output created by the project outputs factory,
+// the builder's outputs if the projects run one at a time, or buffers written to the builder's outputs
+// by the returned flush function, so the outputs of the concurrent projects do not interleave.
+func (builder Model) projectWriters(projectName string, outputMu *sync.Mutex) (io.Writer, io.Writer, func() error) {
+	if builder.projectOutputs != nil {
+		outWriter, errWriter := builder.projectOutputs(projectName)
+		return outWriter, errWriter, func() error { return nil }
+	}
+	if builder.maxParallelism <= 1 {
+		outWriter, errWriter := builder.outputs()
+		return outWriter, errWriter, func() error { return nil }
+	}
+
+	var outBuffer, errBuffer bytes.Buffer
+	return &outBuffer, &errBuffer, func() error {
+		outputMu.Lock()
+		defer outputMu.Unlock()
+
+		outWriter, errWriter := builder.outputs()
+		if _, err := outWriter.Write(outBuffer.Bytes()); err != nil {
+			return err
+		}
+		_, err := errWriter.Write(errBuffer.Bytes())
+		return err
+	}
+}
@@ -0,0 +1,388 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/analyzers/solution"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
+	"github.com/stretchr/testify/require"
+)
+
+// slowExecutor runs the commands of the fake executor slowly, recording the concurrently running commands.
+type slowExecutor struct {
+	*tools.FakeExecutor
+
+	mu           sync.Mutex
+	running      int
+	maxRunning   int
+	startedAfter map[string][]string // Project path - the project paths finished before the project started
+	finished     []string
+}
+
+// commandProject returns the path of the project built by the command.
+func commandProject(cmdSlice []string) string {
+	for _, arg := range cmdSlice[1:] {
+		if filepath.Ext(arg) == constants.CSProjExt {
+			return arg
+		}
+	}
+	return cmdSlice[1]
+}
+
+func newSlowExecutor() *slowExecutor {
+	return &slowExecutor{FakeExecutor: tools.NewFakeExecutor(), startedAfter: map[string][]string{}}
+}
+
+func (executor *slowExecutor) Execute(ctx context.Context, cmdSlice []string, outWriter, errWriter io.Writer) error {
+	executor.mu.Lock()
+	executor.running++
+	if executor.running > executor.maxRunning {
+		executor.maxRunning = executor.running
+	}
+	executor.startedAfter[commandProject(cmdSlice)] = append([]string{}, executor.finished...)
+	executor.mu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+	err := executor.FakeExecutor.Execute(ctx, cmdSlice, outWriter, errWriter)
+
+	executor.mu.Lock()
+	executor.running--
+	executor.finished = append(executor.finished, commandProject(cmdSlice))
+	executor.mu.Unlock()
+
+	return err
+}
+
+func TestBuildAllProjectsParallel(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	defer fakeToolchain(t, tmpDir, toolchain.Msbuild)()
+
+	androidProject := func(id, name string, dependencyIDs ...string) project.Model {
+		return project.Model{
+			ID:                   id,
+			Name:                 name,
+			Pth:                  filepath.Join(tmpDir, name, name+".csproj"),
+			SDK:                  constants.SDKAndroid,
+			AndroidApplication:   true,
+			ApplicationID:        "com.bitrise." + id,
+			DependencyProjectIDs: dependencyIDs,
+			ConfigMap:            map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+			Configs: map[string]project.ConfigurationPlatformModel{
+				"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", OutputDir: filepath.Join(tmpDir, name, "bin", "Release")},
+			},
+		}
+	}
+	a := androidProject("A", "A.Droid")
+	b := androidProject("B", "B.Droid")
+	c := androidProject("C", "C.Droid", "A")
+	d := androidProject("D", "D.Droid")
+
+	newBuilder := func(executor tools.Executor) Model {
+		builder := Model{
+			solution: solution.Model{
+				Name:       "App",
+				Pth:        filepath.Join(tmpDir, "App.sln"),
+				ConfigMap:  map[string]string{"Release|Any CPU": "Release|Any CPU"},
+				ProjectMap: map[string]project.Model{a.ID: a, b.ID: b, c.ID: c, d.ID: d},
+			},
+			buildTool: buildtools.Msbuild,
+			outWriter: io.Discard,
+			errWriter: io.Discard,
+		}
+		builder.SetExecutor(executor)
+		builder.SetMaxParallelism(2)
+		return builder
+	}
+
+	t.Log("it builds the independent projects concurrently after their dependencies")
+	{
+		executor := newSlowExecutor()
+		builder := newBuilder(executor)
+
+		var started []string
+		builder.AddObserver(ObserverFunc(func(event Event) {
+			if e, ok := event.(CommandStarted); ok {
+				started = append(started, e.Project)
+			}
+		}))
+
+		warnings, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{}, warnings)
+
+		require.Equal(t, 2, executor.maxRunning)
+		require.Equal(t, 4, len(executor.Commands()))
+		require.Contains(t, executor.startedAfter[c.Pth], a.Pth)
+		sort.Strings(started)
+		require.Equal(t, []string{"A.Droid", "B.Droid", "C.Droid", "D.Droid"}, started)
+	}
+
+	t.Log("it writes the output of the projects to their own writers")
+	{
+		executor := tools.NewFakeExecutor()
+		for _, proj := range []project.Model{a, b, c, d} {
+			executor.OnArg(proj.Pth, tools.FakeResult{Stdout: "building " + proj.Name + "\n"})
+		}
+		builder := newBuilder(executor)
+
+		var mu sync.Mutex
+		outputs := map[string]*bytes.Buffer{}
+		builder.SetProjectOutputs(func(projectName string) (io.Writer, io.Writer) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			outputs[projectName] = &bytes.Buffer{}
+			return outputs[projectName], io.Discard
+		})
+
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, 4, len(outputs))
+		for name, output := range outputs {
+			require.Equal(t, "building "+name+"\n", output.String())
+		}
+	}
+
+	t.Log("by default the output of the projects is written to the builder's outputs one project at a time")
+	{
+		executor := tools.NewFakeExecutor()
+		for _, proj := range []project.Model{a, b, c, d} {
+			executor.OnArg(proj.Pth, tools.FakeResult{Stdout: proj.Name + " 1\n" + proj.Name + " 2\n"})
+		}
+		builder := newBuilder(executor)
+		var out bytes.Buffer
+		builder.SetOutputs(&out, io.Discard)
+
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, nil, nil)
+		require.NoError(t, err)
+		for _, proj := range []project.Model{a, b, c, d} {
+			require.Contains(t, out.String(), proj.Name+" 1\n"+proj.Name+" 2\n")
+		}
+	}
+
+	t.Log("it stops at the first failure")
+	{
+		executor := tools.NewFakeExecutor().OnArg(a.Pth, tools.FakeResult{ExitCode: 1})
+		builder := newBuilder(executor)
+
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, nil, nil)
+		var commandErr *CommandError
+		require.True(t, errors.As(err, &commandErr))
+		for _, command := range executor.Commands() {
+			require.NotEqual(t, c.Pth, command[1])
+		}
+	}
+
+	t.Log("it keeps going, skips the dependents of the failed projects and aggregates the errors")
+	{
+		executor := tools.NewFakeExecutor().
+			OnArg(a.Pth, tools.FakeResult{ExitCode: 1}).
+			OnArg(d.Pth, tools.FakeResult{ExitCode: 2})
+		builder := newBuilder(executor)
+		builder.SetKeepGoing(true)
+
+		var skipped []ProjectSkipped
+		builder.AddObserver(ObserverFunc(func(event Event) {
+			if e, ok := event.(ProjectSkipped); ok {
+				skipped = append(skipped, e)
+			}
+		}))
+
+		warnings, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, nil, nil)
+		require.Equal(t, []string{"Project (C.Droid) depends on the failed project (A.Droid), skipping..."}, warnings)
+		require.Equal(t, []ProjectSkipped{{Solution: "App", Project: "C.Droid", Reason: "depends on the failed project (A.Droid)"}}, skipped)
+
+		var buildErr *BuildError
+		require.True(t, errors.As(err, &buildErr))
+		require.Equal(t, 2, len(buildErr.Errors))
+		require.Equal(t, "A.Droid", buildErr.Errors[0].Project)
+		require.Equal(t, "D.Droid", buildErr.Errors[1].Project)
+		require.Equal(t, 2, tools.ExitCode(buildErr.Errors[1].Err))
+
+		var commandErr *CommandError
+		require.True(t, errors.As(err, &commandErr))
+		require.Equal(t, 3, len(executor.Commands()))
+	}
+}
+
+func TestBuildAllProjectsParallelSharedDependency(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	defer fakeToolchain(t, tmpDir, toolchain.Msbuild)()
+
+	sdkStyleProject := func(id, name string, application bool, referencePths ...string) project.Model {
+		return project.Model{
+			ID:                   id,
+			Name:                 name,
+			Pth:                  filepath.Join(tmpDir, name, name+".csproj"),
+			Sdk:                  "Microsoft.NET.Sdk",
+			SDK:                  constants.SDKAndroid,
+			AndroidApplication:   application,
+			ApplicationID:        "com.bitrise." + id,
+			ReferredProjectPaths: referencePths,
+			ConfigMap:            map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+			Configs: map[string]project.ConfigurationPlatformModel{
+				"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", OutputDir: filepath.Join(tmpDir, name, "bin", "Release")},
+			},
+		}
+	}
+	// the SDK-style project references do not contain the referenced project's GUID
+	lib := sdkStyleProject("LIB", "Lib.Droid", false)
+	a := sdkStyleProject("A", "A.Droid", true, lib.Pth)
+	b := sdkStyleProject("B", "B.Droid", true, lib.Pth)
+	c := sdkStyleProject("C", "C.Droid", true)
+
+	t.Log("the projects building the same library are not built concurrently")
+	{
+		executor := newSlowExecutor()
+		builder := Model{
+			solution: solution.Model{
+				Name:       "App",
+				Pth:        filepath.Join(tmpDir, "App.sln"),
+				ConfigMap:  map[string]string{"Release|Any CPU": "Release|Any CPU"},
+				ProjectMap: map[string]project.Model{lib.ID: lib, a.ID: a, b.ID: b, c.ID: c},
+			},
+			buildTool: buildtools.Msbuild,
+			outWriter: io.Discard,
+			errWriter: io.Discard,
+		}
+		builder.SetExecutor(executor)
+		builder.SetMaxParallelism(3)
+
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", true, nil, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, 3, len(executor.Commands()))
+		require.Equal(t, 2, executor.maxRunning)
+		require.Contains(t, executor.startedAfter[b.Pth], a.Pth)
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (writer failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestBuildAllProjectsParallelSolutionBuilds(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	defer fakeToolchain(t, tmpDir, toolchain.Msbuild)()
+
+	androidProject := func(id, name string) project.Model {
+		return project.Model{
+			ID:                 id,
+			Name:               name,
+			Pth:                filepath.Join(tmpDir, name, name+".csproj"),
+			SDK:                constants.SDKAndroid,
+			AndroidApplication: true,
+			ApplicationID:      "com.bitrise." + id,
+			ConfigMap:          map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+			Configs: map[string]project.ConfigurationPlatformModel{
+				"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", OutputDir: filepath.Join(tmpDir, name, "bin", "Release")},
+			},
+		}
+	}
+	a := androidProject("A", "A.Droid")
+	b := androidProject("B", "B.Droid")
+	// the legacy iOS projects are built by building the solution
+	ios := project.Model{
+		ID:         "IOS",
+		Name:       "App.iOS",
+		Pth:        filepath.Join(tmpDir, "App.iOS", "App.iOS.csproj"),
+		SDK:        constants.SDKIOS,
+		OutputType: "exe",
+		ConfigMap:  map[string]string{"Release|Any CPU": "Release|iPhone"},
+		Configs: map[string]project.ConfigurationPlatformModel{
+			"Release|iPhone": {Configuration: "Release", Platform: "iPhone", OutputDir: filepath.Join(tmpDir, "App.iOS", "bin", "iPhone", "Release")},
+		},
+	}
+	solutionPth := filepath.Join(tmpDir, "App.sln")
+
+	newBuilder := func(executor tools.Executor) Model {
+		builder := Model{
+			solution: solution.Model{
+				Name:       "App",
+				Pth:        solutionPth,
+				ConfigMap:  map[string]string{"Release|Any CPU": "Release|Any CPU"},
+				ProjectMap: map[string]project.Model{a.ID: a, b.ID: b, ios.ID: ios},
+			},
+			buildTool: buildtools.Msbuild,
+			outWriter: io.Discard,
+			errWriter: io.Discard,
+		}
+		builder.SetExecutor(executor)
+		return builder
+	}
+
+	t.Log("the commands building the whole solution do not run concurrently with the other projects")
+	{
+		executor := newSlowExecutor()
+		builder := newBuilder(executor)
+		builder.SetMaxParallelism(3)
+
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", false, nil, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, 3, len(executor.Commands()))
+		require.Equal(t, 1, executor.maxRunning)
+		require.Equal(t, []string{a.Pth}, executor.startedAfter[solutionPth])
+		require.Equal(t, []string{a.Pth, solutionPth}, executor.startedAfter[b.Pth])
+	}
+
+	t.Log("in serial keep going mode the output is written while the project builds")
+	{
+		executor := tools.NewFakeExecutor().OnArg(a.Pth, tools.FakeResult{Stdout: "building A.Droid\n"})
+		builder := newBuilder(executor)
+		builder.SetKeepGoing(true)
+		var out bytes.Buffer
+		builder.SetOutputs(&out, io.Discard)
+
+		var outputAtFinish []string
+		builder.AddObserver(ObserverFunc(func(event Event) {
+			if e, ok := event.(CommandFinished); ok && e.Project == a.Name {
+				outputAtFinish = append(outputAtFinish, out.String())
+			}
+		}))
+
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", false, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"building A.Droid\n"}, outputAtFinish)
+	}
+
+	t.Log("it returns the errors of writing the buffered project output")
+	{
+		executor := tools.NewFakeExecutor().OnArg(a.Pth, tools.FakeResult{Stdout: "building A.Droid\n"})
+		builder := newBuilder(executor)
+		builder.SetMaxParallelism(2)
+		builder.SetOutputs(failingWriter{}, io.Discard)
+
+		_, err := builder.BuildAllProjects(context.Background(), "Release", "Any CPU", false, nil, nil)
+		require.EqualError(t, err, "failed to write the output of project (A.Droid), error: disk full")
+	}
+}
@@ -0,0 +1,314 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/utility"
+)
+
+// Plan is the dry run of a build: the commands the builder runs in order, the projects it skips
+// and the outputs it expects, computed without running anything.
+type Plan struct {
+	Solution      string           `json:"solution"`
+	Configuration string           `json:"configuration"`
+	Platform      string           `json:"platform"`
+	Commands      []PlannedCommand `json:"commands"`
+	Skipped       []SkippedProject `json:"skipped"`
+	Outputs       []PlannedOutput  `json:"outputs"`
+	Warnings      []string         `json:"warnings"`
+}
+
+// PlannedCommand is a command of the build plan.
+type PlannedCommand struct {
+	Project       string                  `json:"project,omitempty"` // Empty for the commands building the whole solution
+	SDK           constants.SDK           `json:"sdk"`
+	TestFramework constants.TestFramework `json:"test_framework,omitempty"`
+	Pth           string                  `json:"path"`             // The solution or project file the command builds
+	Target        string                  `json:"target,omitempty"` // The MSBuild target or dotnet CLI command
+	Properties    map[string]string       `json:"properties,omitempty"`
+	Command       string                  `json:"command"`
+	// The same command runs earlier in the plan, the builder does not run it again
+	AlreadyPerformed bool `json:"already_performed,omitempty"`
+
+	projectID string
+	runnable  tools.Runnable
+}
+
+// SkippedProject is a project the builder does not build.
+type SkippedProject struct {
+	Project string `json:"project"`
+	Reason  string `json:"reason"`
+}
+
+// PlannedOutput is an output the build is expected to generate,
+// CollectProjectOutputs looks for the latest file matching the pattern in the directory.
+type PlannedOutput struct {
+	Project    string               `json:"project"`
+	OutputType constants.OutputType `json:"output_type"`
+	Dir        string               `json:"dir"`
+	Pattern    string               `json:"pattern"`
+}
+
+func skippedProjectWarnings(skipped []SkippedProject) []string {
+	warnings := []string{}
+	for _, skippedProject := range skipped {
+		warnings = append(warnings, fmt.Sprintf("Project (%s) %s, skipping...", skippedProject.Project, skippedProject.Reason))
+	}
+	return warnings
+}
+
+// PlanBuildAllProjects returns the plan of BuildAllProjects, the prepare callback can modify the planned commands.
+func (builder Model) PlanBuildAllProjects(configuration, platform string, buildIpa bool, prepareCallback PrepareCommandCallback) (Plan, error) {
+	return builder.withCallbacks(prepareCallback, nil).planBuildAllProjects(configuration, platform, buildIpa)
+}
+
+func (builder Model) planBuildAllProjects(configuration, platform string, buildIpa bool) (Plan, error) {
+	plan := builder.newPlan(configuration, platform)
+
+	if err := validateSolutionConfig(builder.solution, configuration, platform); err != nil {
+		return plan, err
+	}
+
+	buildableProjects, skipped := builder.buildableProjectsAndSkipped(configuration, platform)
+	plan.Skipped = skipped
+	if len(buildableProjects) == 0 {
+		return plan, fmt.Errorf("No project to build found")
+	}
+
+	if err := builder.planProjectCommands(&plan, buildableProjects, buildIpa); err != nil {
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+// PlanBuildAllUITestableXamarinProjects returns the plan of BuildAllUITestableXamarinProjects,
+// the prepare callback can modify the planned project commands.
+func (builder Model) PlanBuildAllUITestableXamarinProjects(configuration, platform string, prepareCallback PrepareCommandCallback) (Plan, error) {
+	return builder.withCallbacks(prepareCallback, nil).planBuildAllUITestableXamarinProjects(configuration, platform)
+}
+
+func (builder Model) planBuildAllUITestableXamarinProjects(configuration, platform string) (Plan, error) {
+	plan := builder.newPlan(configuration, platform)
+
+	if err := validateSolutionConfig(builder.solution, configuration, platform); err != nil {
+		return plan, err
+	}
+
+	buildCommand, err := builder.buildSolutionCommand(configuration, platform)
+	if err != nil {
+		return plan, fmt.Errorf("Failed to create build command, error: %s", err)
+	}
+	plan.addCommand(newPlannedCommand(project.Model{SDK: constants.SDKUnknown, TestFramework: constants.TestFrameworkUnknown}, buildCommand))
+
+	_, buildableReferredProjects, skipped, warnings := builder.buildableXamarinUITestProjectsAndReferredProjectsAndSkipped(configuration, platform)
+	plan.Skipped = skipped
+	plan.Warnings = append(plan.Warnings, warnings...)
+	if len(buildableReferredProjects) == 0 {
+		return plan, fmt.Errorf("No project to build found")
+	}
+
+	if err := builder.planProjectCommands(&plan, buildableReferredProjects, true); err != nil {
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+func (builder Model) newPlan(configuration, platform string) Plan {
+	solutionPth, projectPth := builder.solutionTarget()
+	if solutionPth == "" {
+		solutionPth = projectPth
+	}
+
+	return Plan{
+		Solution:      solutionPth,
+		Configuration: configuration,
+		Platform:      platform,
+		Commands:      []PlannedCommand{},
+		Skipped:       []SkippedProject{},
+		Outputs:       []PlannedOutput{},
+		Warnings:      []string{},
+	}
+}
+
+// planProjectCommands adds the build commands and the expected outputs of the given projects to the plan.
+func (builder Model) planProjectCommands(plan *Plan, projects []project.Model, buildIpa bool) error {
+	for _, proj := range projects {
+		buildCommands, warns, err := builder.buildProjectCommand(plan.Configuration, plan.Platform, proj, buildIpa)
+		plan.Warnings = append(plan.Warnings, warns...)
+		if err != nil {
+			return fmt.Errorf("Failed to create build command, error: %s", err)
+		}
+
+		for _, buildCommand := range buildCommands {
+			// Let the observers modify the command
+			builder.prepareCommand(builder.commandInfo(proj), buildCommand)
+
+			plan.addCommand(newPlannedCommand(proj, buildCommand))
+		}
+
+		outputs, err := builder.plannedOutputs(proj, utility.ToConfig(plan.Configuration, plan.Platform))
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("Failed to plan the outputs of project (%s), error: %s", proj.Name, err))
+		}
+		plan.Outputs = append(plan.Outputs, outputs...)
+	}
+	return nil
+}
+
+func newPlannedCommand(proj project.Model, command tools.Runnable) PlannedCommand {
+	plannedCommand := PlannedCommand{
+		Project:       proj.Name,
+		SDK:           proj.SDK,
+		TestFramework: proj.TestFramework,
+		Command:       command.String(),
+		projectID:     proj.ID,
+		runnable:      command,
+	}
+
+	if buildCommand, ok := command.(buildtools.Command); ok {
+		plannedCommand.Pth = buildCommand.BuildPth()
+		plannedCommand.Target = buildCommand.Target()
+		plannedCommand.Properties = buildCommand.Properties()
+	}
+
+	return plannedCommand
+}
+
+// addCommand adds the command to the plan, the commands already in the plan are not run again.
+func (plan *Plan) addCommand(command PlannedCommand) {
+	for _, plannedCommand := range plan.Commands {
+		if plannedCommand.Command == command.Command {
+			command.AlreadyPerformed = true
+			break
+		}
+	}
+	plan.Commands = append(plan.Commands, command)
+}
+
+// plannedOutputs returns the outputs CollectProjectOutputs looks for after building the given project.
+func (builder Model) plannedOutputs(proj project.Model, solutionConfig string) ([]PlannedOutput, error) {
+	projectConfig, ok := proj.Configs[proj.ConfigMap[solutionConfig]]
+	if !ok {
+		return nil, nil
+	}
+
+	output := func(outputType constants.OutputType, dir, name, ext string) PlannedOutput {
+		return PlannedOutput{Project: proj.Name, OutputType: outputType, Dir: dir, Pattern: "*" + name + "*" + ext}
+	}
+
+	outputs := []PlannedOutput{}
+	switch proj.SDK {
+	case constants.SDKIOS, constants.SDKTvOS:
+		if IsDeviceArch(projectConfig.MtouchArchs...) {
+			if builder.xcodeArchivesDir != "" {
+				outputs = append(outputs, output(constants.OutputTypeXCArchive, builder.xcodeArchivesDir, proj.AssemblyName, ".xcarchive"))
+			}
+			outputs = append(outputs,
+				output(constants.OutputTypeIPA, projectConfig.OutputDir, proj.AssemblyName, ".ipa"),
+				output(constants.OutputTypeDSYM, projectConfig.OutputDir, proj.AssemblyName, ".app.dSYM"),
+			)
+		}
+		outputs = append(outputs, output(constants.OutputTypeAPP, projectConfig.OutputDir, proj.AssemblyName, ".app"))
+	case constants.SDKMacOS:
+		outputs = append(outputs,
+			output(constants.OutputTypeAPP, projectConfig.OutputDir, proj.AssemblyName, ".app"),
+			output(constants.OutputTypePKG, projectConfig.OutputDir, proj.AssemblyName, ".pkg"),
+		)
+	case constants.SDKAndroid:
+		// SDK-style projects define the package name by the ApplicationId property
+		packageName := proj.ApplicationID
+		if packageName == "" {
+			var err error
+			packageName, err = androidPackageName(proj.ManifestPth, builder.solution.FileSystem())
+			if err != nil {
+				return nil, fmt.Errorf("could get package name from manifest file at %v. Error: %v", proj.ManifestPth, err)
+			}
+		}
+
+		outputs = append(outputs,
+			output(constants.OutputTypeAPK, projectConfig.OutputDir, packageName, ".apk"),
+			output(constants.OutputTypeAAB, projectConfig.OutputDir, packageName, ".aab"),
+		)
+	}
+	return outputs, nil
+}
+
+// runPlan runs the planned commands in order, the commands already performed are reported to the observers but not run again.
+func (builder Model) runPlan(ctx context.Context, plan Plan) error {
+	for _, command := range plan.Commands {
+		info := CommandInfo{Solution: builder.solution.Name, Project: command.Project, SDK: command.SDK, TestFramework: command.TestFramework}
+		if err := builder.runObservedCommand(ctx, info, command.runnable, command.AlreadyPerformed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the plan in a human readable format.
+func (plan Plan) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Plan: %s (%s)\n", plan.Solution, utility.ToConfig(plan.Configuration, plan.Platform))
+
+	b.WriteString("\nCommands:\n")
+	for i, command := range plan.Commands {
+		name := command.Project
+		if name == "" {
+			name = "solution"
+		}
+		fmt.Fprintf(&b, "%d. %s (%s)\n", i+1, name, command.SDK)
+		fmt.Fprintf(&b, "   $ %s\n", command.Command)
+		if command.Target != "" {
+			fmt.Fprintf(&b, "   target: %s\n", command.Target)
+		}
+		if len(command.Properties) > 0 {
+			names := make([]string, 0, len(command.Properties))
+			for name := range command.Properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			properties := make([]string, 0, len(names))
+			for _, name := range names {
+				properties = append(properties, name+"="+command.Properties[name])
+			}
+			fmt.Fprintf(&b, "   properties: %s\n", strings.Join(properties, ", "))
+		}
+		if command.AlreadyPerformed {
+			b.WriteString("   already performed, skipping\n")
+		}
+	}
+
+	if len(plan.Skipped) > 0 {
+		b.WriteString("\nSkipped projects:\n")
+		for _, skipped := range plan.Skipped {
+			fmt.Fprintf(&b, "- %s: %s\n", skipped.Project, skipped.Reason)
+		}
+	}
+
+	if len(plan.Outputs) > 0 {
+		b.WriteString("\nExpected outputs:\n")
+		for _, output := range plan.Outputs {
+			fmt.Fprintf(&b, "- %s %s: %s\n", output.Project, output.OutputType, filepath.Join(output.Dir, output.Pattern))
+		}
+	}
+
+	if len(plan.Warnings) > 0 {
+		b.WriteString("\nWarnings:\n")
+		for _, warning := range plan.Warnings {
+			fmt.Fprintf(&b, "- %s\n", warning)
+		}
+	}
+
+	return b.String()
+}
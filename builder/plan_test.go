@@ -0,0 +1,222 @@
+package builder
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/analyzers/solution"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
+	"github.com/stretchr/testify/require"
+)
+
+func planTestBuilder() Model {
+	android := sdkStyleProject(constants.SDKAndroid, "net8.0-android")
+	android.ID = "ANDROID"
+	android.Name = "App.Android"
+	android.Pth = "/solution/App.Android/App.Android.csproj"
+	android.ApplicationID = "com.bitrise.app"
+	android.AndroidApplication = true
+	android.Configs["Release|AnyCPU"] = project.ConfigurationPlatformModel{Configuration: "Release", Platform: "AnyCPU", AndroidPackageFormat: "aab", OutputDir: "/solution/App.Android/bin/Release"}
+
+	library := sdkStyleProject(constants.SDKUnknown, "net8.0")
+	library.ID = "LIBRARY"
+	library.Name = "App.Core"
+
+	sample := sdkStyleProject(constants.SDKAndroid, "net8.0-android")
+	sample.ID = "SAMPLE"
+	sample.Name = "Sample.Android"
+	sample.AndroidApplication = true
+	sample.ConfigMap = map[string]string{}
+
+	return Model{
+		solution: solution.Model{
+			Name:       "App",
+			Pth:        "/solution/App.sln",
+			ConfigMap:  map[string]string{"Release|Any CPU": "Release|Any CPU"},
+			ProjectMap: map[string]project.Model{android.ID: android, library.ID: library, sample.ID: sample},
+		},
+		buildTool: buildtools.DotnetCLI,
+	}
+}
+
+func TestPlanBuildAllProjects(t *testing.T) {
+	builder := planTestBuilder()
+
+	t.Log("it plans the commands, the skipped projects and the outputs")
+	{
+		prepareCallback := func(solutionName string, projectName string, sdk constants.SDK, testFramework constants.TestFramework, command *tools.Editable) {
+			(*command).SetCustomOptions("-p:AndroidKeyStore=true")
+		}
+
+		plan, err := builder.PlanBuildAllProjects("Release", "Any CPU", true, prepareCallback)
+		require.NoError(t, err)
+		require.Equal(t, "/solution/App.sln", plan.Solution)
+
+		require.Equal(t, 1, len(plan.Commands))
+		command := plan.Commands[0]
+		require.Equal(t, "App.Android", command.Project)
+		require.Equal(t, constants.SDKAndroid, command.SDK)
+		require.Equal(t, "/solution/App.Android/App.Android.csproj", command.Pth)
+		require.Equal(t, "publish", command.Target)
+		require.Equal(t, map[string]string{
+			"TargetFramework":      "net8.0-android",
+			"Configuration":        "Release",
+			"SolutionDir":          "/solution/",
+			"AndroidPackageFormat": "aab",
+			"AndroidKeyStore":      "true",
+		}, command.Properties)
+		require.False(t, command.AlreadyPerformed)
+
+		require.Equal(t, []SkippedProject{{Project: "Sample.Android", Reason: "do not have config for solution config (Release|Any CPU)"}}, plan.Skipped)
+
+		require.Equal(t, []PlannedOutput{
+			{Project: "App.Android", OutputType: constants.OutputTypeAPK, Dir: "/solution/App.Android/bin/Release", Pattern: "*com.bitrise.app*.apk"},
+			{Project: "App.Android", OutputType: constants.OutputTypeAAB, Dir: "/solution/App.Android/bin/Release", Pattern: "*com.bitrise.app*.aab"},
+		}, plan.Outputs)
+
+		require.Contains(t, plan.String(), "properties: AndroidKeyStore=true, AndroidPackageFormat=aab, Configuration=Release, SolutionDir=/solution/, TargetFramework=net8.0-android")
+		require.Contains(t, plan.String(), "- App.Android apk: "+filepath.Join("/solution/App.Android/bin/Release", "*com.bitrise.app*.apk"))
+	}
+
+	t.Log("it fails for invalid solution configs")
+	{
+		_, err := builder.PlanBuildAllProjects("Debug", "Any CPU", true, nil)
+		require.Error(t, err)
+	}
+
+	t.Log("it runs the planned commands")
+	{
+		plan := Plan{Commands: []PlannedCommand{
+			{Project: "App", Command: "wait", runnable: waitingCommand{}},
+			{Project: "App", Command: "wait", AlreadyPerformed: true, runnable: waitingCommand{}},
+		}}
+
+		ran := []bool{}
+		callback := func(solutionName string, projectName string, sdk constants.SDK, testFramwork constants.TestFramework, commandStr string, alreadyPerformed bool) {
+			ran = append(ran, alreadyPerformed)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := builder.withCallbacks(nil, callback).runPlan(ctx, plan)
+		require.Error(t, err)
+		require.Equal(t, []bool{false}, ran)
+	}
+}
+
+func TestPlanWithoutBuildTool(t *testing.T) {
+	toolchain.SetOverride(toolchain.Msbuild, "/not/existing/msbuild")
+	defer toolchain.SetOverride(toolchain.Msbuild, "")
+
+	ios := project.Model{
+		ID:           "IOS",
+		Name:         "App.iOS",
+		Pth:          "/solution/App.iOS/App.iOS.csproj",
+		SDK:          constants.SDKIOS,
+		OutputType:   "exe",
+		AssemblyName: "App.iOS",
+		ConfigMap:    map[string]string{"Release|iPhone": "Release|iPhone"},
+		Configs: map[string]project.ConfigurationPlatformModel{
+			"Release|iPhone": {Configuration: "Release", Platform: "iPhone", MtouchArchs: []string{"ARM64"}, OutputDir: "/solution/App.iOS/bin/iPhone/Release"},
+		},
+	}
+	builder := Model{
+		solution: solution.Model{
+			Name:       "App",
+			Pth:        "/solution/App.sln",
+			ConfigMap:  map[string]string{"Release|iPhone": "Release|iPhone"},
+			ProjectMap: map[string]project.Model{ios.ID: ios},
+		},
+		buildTool: buildtools.Msbuild,
+	}
+	builder.SetXcodeArchivesDir("/archives")
+
+	t.Log("it plans the commands without locating the build tool")
+	{
+		plan, err := builder.PlanBuildAllProjects("Release", "iPhone", true, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(plan.Commands))
+		require.Equal(t, `"msbuild" "/solution/App.sln" "/target:Build" "/p:SolutionDir=/solution/" "/p:Configuration=Release" "/p:Platform=iPhone" "/p:ArchiveOnBuild=true" "/p:BuildIpa=true"`, plan.Commands[0].Command)
+		require.Contains(t, plan.Outputs, PlannedOutput{Project: "App.iOS", OutputType: constants.OutputTypeXCArchive, Dir: "/archives", Pattern: "*App.iOS*.xcarchive"})
+	}
+
+	t.Log("the planned commands fail to run without the build tool")
+	{
+		plan, err := builder.PlanBuildAllProjects("Release", "iPhone", true, nil)
+		require.NoError(t, err)
+
+		err = builder.runPlan(context.Background(), plan)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "msbuild override (/not/existing/msbuild) is invalid")
+	}
+}
+
+func TestPlanMultiPlatformProject(t *testing.T) {
+	toolchain.SetOverride(toolchain.Dotnet, "/not/existing/dotnet")
+	defer toolchain.SetOverride(toolchain.Dotnet, "")
+
+	iosConfigs := map[string]project.ConfigurationPlatformModel{
+		"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", OutputDir: "/solution/App/bin/Release/net8.0-ios"},
+	}
+	androidConfigs := map[string]project.ConfigurationPlatformModel{
+		"Release|AnyCPU": {Configuration: "Release", Platform: "AnyCPU", AndroidPackageFormat: "aab", OutputDir: "/solution/App/bin/Release/net8.0-android"},
+	}
+	app := sdkStyleProject(constants.SDKIOS, "net8.0-ios", "net8.0-android")
+	app.ID = "APP"
+	app.OutputType = "exe"
+	app.AssemblyName = "App"
+	app.ApplicationID = "com.bitrise.app"
+	app.AndroidApplication = true
+	app.Configs = iosConfigs
+	app.TargetFrameworkConfigs = map[string]map[string]project.ConfigurationPlatformModel{
+		"net8.0-ios":     iosConfigs,
+		"net8.0-android": androidConfigs,
+	}
+
+	builder := Model{
+		solution: solution.Model{
+			Name:       "App",
+			Pth:        "/solution/App.sln",
+			ConfigMap:  map[string]string{"Release|Any CPU": "Release|Any CPU"},
+			ProjectMap: map[string]project.Model{app.ID: app},
+		},
+		buildTool: buildtools.DotnetCLI,
+	}
+
+	t.Log("it builds and collects every platform target framework")
+	{
+		plan, err := builder.PlanBuildAllProjects("Release", "Any CPU", true, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, 2, len(plan.Commands))
+		require.Equal(t, constants.SDKIOS, plan.Commands[0].SDK)
+		require.Equal(t, `"dotnet" "build" "/solution/App/App.csproj" "-f" "net8.0-ios" "-c" "Release" "-p:SolutionDir=/solution/"`, plan.Commands[0].Command)
+		require.Equal(t, constants.SDKAndroid, plan.Commands[1].SDK)
+		require.Equal(t, `"dotnet" "publish" "/solution/App/App.csproj" "-f" "net8.0-android" "-c" "Release" "-p:SolutionDir=/solution/" "-p:AndroidPackageFormat=aab"`, plan.Commands[1].Command)
+
+		require.Equal(t, []PlannedOutput{
+			{Project: "App", OutputType: constants.OutputTypeIPA, Dir: "/solution/App/bin/Release/net8.0-ios", Pattern: "*App*.ipa"},
+			{Project: "App", OutputType: constants.OutputTypeDSYM, Dir: "/solution/App/bin/Release/net8.0-ios", Pattern: "*App*.app.dSYM"},
+			{Project: "App", OutputType: constants.OutputTypeAPP, Dir: "/solution/App/bin/Release/net8.0-ios", Pattern: "*App*.app"},
+			{Project: "App", OutputType: constants.OutputTypeAPK, Dir: "/solution/App/bin/Release/net8.0-android", Pattern: "*com.bitrise.app*.apk"},
+			{Project: "App", OutputType: constants.OutputTypeAAB, Dir: "/solution/App/bin/Release/net8.0-android", Pattern: "*com.bitrise.app*.aab"},
+		}, plan.Outputs)
+	}
+
+	t.Log("the project type whitelist selects the platform target frameworks")
+	{
+		builder := builder
+		builder.projectTypeWhitelist = []constants.SDK{constants.SDKAndroid}
+
+		plan, err := builder.PlanBuildAllProjects("Release", "Any CPU", true, nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(plan.Commands))
+		require.Equal(t, constants.SDKAndroid, plan.Commands[0].SDK)
+	}
+}
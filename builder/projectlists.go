@@ -8,16 +8,37 @@ import (
 	"github.com/bitrise-io/go-xamarin/utility"
 )
 
+// orderedProjects returns the solution's projects in build order: every project follows its dependencies,
+// the independent projects are ordered by name.
+// The dependency cycles are reported by New, the order contains every project even if the graph has a cycle.
+func (builder Model) orderedProjects() []project.Model {
+	order, _ := builder.solution.ProjectGraph().Order()
+
+	projects := []project.Model{}
+	for _, projectID := range order {
+		projects = append(projects, builder.solution.ProjectMap[projectID])
+	}
+	return projects
+}
+
+// whitelistedProjects returns the projects allowed by the project type whitelist and the solution folders,
+// the multi-platform projects are returned for each of their platforms, see project.Model.PlatformProjects.
 func (builder Model) whitelistedProjects() []project.Model {
 	projects := []project.Model{}
 
-	for _, proj := range builder.solution.ProjectMap {
-		if !whitelistAllows(proj.SDK, builder.projectTypeWhitelist...) {
-			continue
-		}
+	for _, solutionProj := range builder.orderedProjects() {
+		for _, proj := range solutionProj.PlatformProjects() {
+			if !whitelistAllows(proj.SDK, builder.projectTypeWhitelist...) {
+				continue
+			}
 
-		if proj.SDK != constants.SDKUnknown {
-			projects = append(projects, proj)
+			if !solutionFoldersAllow(proj.SolutionFolder, builder.solutionFolders...) {
+				continue
+			}
+
+			if proj.SDK != constants.SDKUnknown {
+				projects = append(projects, proj)
+			}
 		}
 	}
 
@@ -25,8 +46,13 @@ func (builder Model) whitelistedProjects() []project.Model {
 }
 
 func (builder Model) buildableProjects(configuration, platform string) ([]project.Model, []string) {
+	projects, skipped := builder.buildableProjectsAndSkipped(configuration, platform)
+	return projects, skippedProjectWarnings(skipped)
+}
+
+func (builder Model) buildableProjectsAndSkipped(configuration, platform string) ([]project.Model, []SkippedProject) {
 	projects := []project.Model{}
-	warnings := []string{}
+	skipped := []SkippedProject{}
 
 	solutionConfig := utility.ToConfig(configuration, platform)
 
@@ -37,7 +63,12 @@ func (builder Model) buildableProjects(configuration, platform string) ([]projec
 		// Solution config - project config mapping
 		_, ok := proj.ConfigMap[solutionConfig]
 		if !ok {
-			warnings = append(warnings, fmt.Sprintf("Project (%s) do not have config for solution config (%s), skipping...", proj.Name, solutionConfig))
+			skipped = append(skipped, SkippedProject{Project: proj.Name, Reason: fmt.Sprintf("do not have config for solution config (%s)", solutionConfig)})
+			continue
+		}
+
+		if !proj.BuildEnabled(solutionConfig) {
+			skipped = append(skipped, SkippedProject{Project: proj.Name, Reason: fmt.Sprintf("is not marked for build in solution config (%s)", solutionConfig)})
 			continue
 		}
 
@@ -45,12 +76,12 @@ func (builder Model) buildableProjects(configuration, platform string) ([]projec
 			proj.SDK == constants.SDKMacOS ||
 			proj.SDK == constants.SDKTvOS) &&
 			proj.OutputType != "exe" {
-			warnings = append(warnings, fmt.Sprintf("Project (%s) is not archivable based on output type (%s), skipping...", proj.Name, proj.OutputType))
+			skipped = append(skipped, SkippedProject{Project: proj.Name, Reason: fmt.Sprintf("is not archivable based on output type (%s)", proj.OutputType)})
 			continue
 		}
 		if proj.SDK == constants.SDKAndroid &&
 			!proj.AndroidApplication {
-			warnings = append(warnings, fmt.Sprintf("(%s) is not an android application project, skipping...", proj.Name))
+			skipped = append(skipped, SkippedProject{Project: proj.Name, Reason: "is not an android application project"})
 			continue
 		}
 
@@ -59,18 +90,24 @@ func (builder Model) buildableProjects(configuration, platform string) ([]projec
 		}
 	}
 
-	return projects, warnings
+	return projects, skipped
 }
 
 func (builder Model) buildableXamarinUITestProjectsAndReferredProjects(configuration, platform string) ([]project.Model, []project.Model, []string) {
+	testProjects, referredProjects, skipped, warnings := builder.buildableXamarinUITestProjectsAndReferredProjectsAndSkipped(configuration, platform)
+	return testProjects, referredProjects, append(skippedProjectWarnings(skipped), warnings...)
+}
+
+func (builder Model) buildableXamarinUITestProjectsAndReferredProjectsAndSkipped(configuration, platform string) ([]project.Model, []project.Model, []SkippedProject, []string) {
 	testProjects := []project.Model{}
 	referredProjects := []project.Model{}
 
+	skipped := []SkippedProject{}
 	warnings := []string{}
 
 	solutionConfig := utility.ToConfig(configuration, platform)
 
-	for _, proj := range builder.solution.ProjectMap {
+	for _, proj := range builder.orderedProjects() {
 		// Check if is XamarinUITest project
 		if proj.TestFramework != constants.TestFrameworkXamarinUITest {
 			continue
@@ -79,13 +116,18 @@ func (builder Model) buildableXamarinUITestProjectsAndReferredProjects(configura
 		// Check if contains config mapping
 		_, ok := proj.ConfigMap[solutionConfig]
 		if !ok {
-			warnings = append(warnings, fmt.Sprintf("Project (%s) do not have config for solution config (%s), skipping...", proj.Name, solutionConfig))
+			skipped = append(skipped, SkippedProject{Project: proj.Name, Reason: fmt.Sprintf("do not have config for solution config (%s)", solutionConfig)})
+			continue
+		}
+
+		if !proj.BuildEnabled(solutionConfig) {
+			skipped = append(skipped, SkippedProject{Project: proj.Name, Reason: fmt.Sprintf("is not marked for build in solution config (%s)", solutionConfig)})
 			continue
 		}
 
 		// Collect referred projects
 		if len(proj.ReferredProjectIDs) == 0 {
-			warnings = append(warnings, fmt.Sprintf("No referred projects found for test project: %s, skipping...", proj.Name))
+			skipped = append(skipped, SkippedProject{Project: proj.Name, Reason: "is a test project without referred projects"})
 			continue
 		}
 
@@ -107,38 +149,48 @@ func (builder Model) buildableXamarinUITestProjectsAndReferredProjects(configura
 		}
 
 		if len(referredProjects) == 0 {
-			warnings = append(warnings, fmt.Sprintf("Test project (%s) does not refers to any project, with project type whitelist (%v), skipping...", proj.Name, builder.projectTypeWhitelist))
+			skipped = append(skipped, SkippedProject{Project: proj.Name, Reason: fmt.Sprintf("is a test project which does not refer to any project with project type whitelist (%v)", builder.projectTypeWhitelist)})
 			continue
 		}
 
 		testProjects = append(testProjects, proj)
 	}
 
-	return testProjects, referredProjects, warnings
+	return testProjects, builder.solution.ProjectGraph().Sort(referredProjects), skipped, warnings
+}
+
+func (builder Model) buildableTestProjects(configuration, platform string, testFramework constants.TestFramework) ([]project.Model, []string) {
+	testProjects, skipped := builder.buildableTestProjectsAndSkipped(configuration, platform, testFramework)
+	return testProjects, skippedProjectWarnings(skipped)
 }
 
-func (builder Model) buildableNunitTestProjects(configuration, platform string) ([]project.Model, []string) {
+func (builder Model) buildableTestProjectsAndSkipped(configuration, platform string, testFramework constants.TestFramework) ([]project.Model, []SkippedProject) {
 	testProjects := []project.Model{}
 
-	warnings := []string{}
+	skipped := []SkippedProject{}
 
 	solutionConfig := utility.ToConfig(configuration, platform)
 
-	for _, proj := range builder.solution.ProjectMap {
-		// Check if is nunit test project
-		if proj.TestFramework != constants.TestFrameworkNunitTest {
+	for _, proj := range builder.orderedProjects() {
+		// Check if is a test project of the given test framework
+		if proj.TestFramework != testFramework {
 			continue
 		}
 
 		// Check if contains config mapping
 		_, ok := proj.ConfigMap[solutionConfig]
 		if !ok {
-			warnings = append(warnings, fmt.Sprintf("Project (%s) do not have config for solution config (%s), skipping...", proj.Name, solutionConfig))
+			skipped = append(skipped, SkippedProject{Project: proj.Name, Reason: fmt.Sprintf("do not have config for solution config (%s)", solutionConfig)})
+			continue
+		}
+
+		if !proj.BuildEnabled(solutionConfig) {
+			skipped = append(skipped, SkippedProject{Project: proj.Name, Reason: fmt.Sprintf("is not marked for build in solution config (%s)", solutionConfig)})
 			continue
 		}
 
 		testProjects = append(testProjects, proj)
 	}
 
-	return testProjects, warnings
+	return testProjects, skipped
 }
@@ -0,0 +1,128 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/analyzers/project"
+	"github.com/bitrise-io/go-xamarin/analyzers/solution"
+	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildableProjects(t *testing.T) {
+	t.Log("it skips the projects not marked for build in the solution config")
+	{
+		app := project.Model{
+			ID:                 "APP",
+			Name:               "App.Droid",
+			SDK:                constants.SDKAndroid,
+			AndroidApplication: true,
+			ConfigMap:          map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+			ConfigMappings:     map[string]project.ConfigMapping{"Release|Any CPU": {Config: "Release|AnyCPU", Build: true}},
+		}
+		unchecked := project.Model{
+			ID:                 "UNCHECKED",
+			Name:               "Sample.Droid",
+			SDK:                constants.SDKAndroid,
+			AndroidApplication: true,
+			ConfigMap:          map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+			ConfigMappings:     map[string]project.ConfigMapping{"Release|Any CPU": {Config: "Release|AnyCPU"}},
+		}
+
+		builder := Model{
+			solution: solution.Model{
+				ProjectMap: map[string]project.Model{app.ID: app, unchecked.ID: unchecked},
+			},
+		}
+
+		projects, warnings := builder.buildableProjects("Release", "Any CPU")
+		require.Equal(t, []project.Model{app}, projects)
+		require.Equal(t, []string{"Project (Sample.Droid) is not marked for build in solution config (Release|Any CPU), skipping..."}, warnings)
+	}
+
+	t.Log("it returns the projects in build order")
+	{
+		newProject := func(id, name string, referredProjectIDs ...string) project.Model {
+			return project.Model{
+				ID:                 id,
+				Name:               name,
+				SDK:                constants.SDKAndroid,
+				AndroidApplication: true,
+				ReferredProjectIDs: referredProjectIDs,
+				ConfigMap:          map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+			}
+		}
+		app := newProject("APP", "App", "LIB")
+		lib := newProject("LIB", "Lib")
+		sample := newProject("SAMPLE", "Sample")
+
+		builder := Model{
+			solution: solution.Model{
+				ProjectMap: map[string]project.Model{app.ID: app, lib.ID: lib, sample.ID: sample},
+			},
+		}
+
+		for i := 0; i < 10; i++ {
+			projects, warnings := builder.buildableProjects("Release", "Any CPU")
+			require.Equal(t, []project.Model{lib, app, sample}, projects)
+			require.Equal(t, []string{}, warnings)
+		}
+	}
+}
+
+func TestWriteTrimmedSolution(t *testing.T) {
+	t.Log("it writes the whitelisted projects and their references")
+	{
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("__xamarin-builder-test__")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, os.RemoveAll(tmpDir))
+		}()
+
+		newProject := func(id, name string, sdk constants.SDK, referredProjectIDs ...string) project.Model {
+			return project.Model{
+				ID:                 id,
+				Name:               name,
+				Pth:                filepath.Join(tmpDir, name, name+".csproj"),
+				SDK:                sdk,
+				ReferredProjectIDs: referredProjectIDs,
+				ConfigMap:          map[string]string{"Release|Any CPU": "Release|AnyCPU"},
+			}
+		}
+
+		app := newProject("AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA", "App.Droid", constants.SDKAndroid, "CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC")
+		ios := newProject("BBBBBBBB-BBBB-BBBB-BBBB-BBBBBBBBBBBB", "App.iOS", constants.SDKIOS, "CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC")
+		core := newProject("CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC", "App", constants.SDKUnknown)
+
+		builder := Model{
+			solution: solution.Model{
+				Pth:        filepath.Join(tmpDir, "App.sln"),
+				ConfigMap:  map[string]string{"Release|Any CPU": "Release|Any CPU"},
+				ProjectMap: map[string]project.Model{app.ID: app, ios.ID: ios, core.ID: core},
+			},
+			projectTypeWhitelist: []constants.SDK{constants.SDKAndroid},
+		}
+
+		pth := filepath.Join(tmpDir, "App.Droid.sln")
+		require.NoError(t, builder.WriteTrimmedSolution(pth))
+
+		content, err := fileutil.ReadStringFromFile(pth)
+		require.NoError(t, err)
+		require.Contains(t, content, `"App.Droid", "App.Droid\App.Droid.csproj", "{AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA}"`)
+		require.Contains(t, content, `"App", "App\App.csproj", "{CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC}"`)
+		require.NotContains(t, content, "App.iOS")
+	}
+
+	t.Log("it fails if no project is whitelisted")
+	{
+		builder := Model{
+			solution:             solution.Model{ProjectMap: map[string]project.Model{}},
+			projectTypeWhitelist: []constants.SDK{constants.SDKAndroid},
+		}
+		require.Error(t, builder.WriteTrimmedSolution("App.sln"))
+	}
+}
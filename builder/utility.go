@@ -6,19 +6,19 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/bitrise-io/go-utils/fileutil"
-	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/go-xamarin/analyzers/solution"
 	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools"
 	"github.com/bitrise-io/go-xamarin/utility"
 )
 
-func validateSolutionPth(pth string) error {
-	ext := filepath.Ext(pth)
-	if ext != constants.SolutionExt {
+func validateSolutionPth(pth string, fileSystem utility.FileSystem) error {
+	switch filepath.Ext(pth) {
+	case constants.SolutionExt, constants.SolutionXMLExt, constants.SolutionFilterExt:
+	default:
 		return fmt.Errorf("path is not a solution file path: %s", pth)
 	}
-	if exist, err := pathutil.IsPathExists(pth); err != nil {
+	if exist, err := fileSystem.IsPathExists(pth); err != nil {
 		return err
 	} else if !exist {
 		return fmt.Errorf("solution not exist at: %s", pth)
@@ -26,6 +26,20 @@ func validateSolutionPth(pth string) error {
 	return nil
 }
 
+func validateProjectPth(pth string) error {
+	switch filepath.Ext(pth) {
+	case constants.CSProjExt, constants.FSProjExt:
+	default:
+		return fmt.Errorf("path is not a project file path: %s", pth)
+	}
+	if exist, err := utility.OSFileSystem.IsPathExists(pth); err != nil {
+		return err
+	} else if !exist {
+		return fmt.Errorf("project not exist at: %s", pth)
+	}
+	return nil
+}
+
 func validateSolutionConfig(solution solution.Model, configuration, platform string) error {
 	config := utility.ToConfig(configuration, platform)
 	if _, ok := solution.ConfigMap[config]; !ok {
@@ -63,6 +77,20 @@ func whitelistAllows(projectType constants.SDK, projectTypeWhiteList ...constant
 	return false
 }
 
+func solutionFoldersAllow(projectFolder string, solutionFolders ...string) bool {
+	if len(solutionFolders) == 0 {
+		return true
+	}
+
+	for _, folder := range solutionFolders {
+		if solution.FolderContains(folder, projectFolder) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // IsDeviceArch based on:
 // default architecture: ARMv7
 // iPhone architecture: <MtouchArch>ARMv7,ARMv7s,ARM64</MtouchArch>
@@ -75,8 +103,8 @@ func isPlatformAnyCPU(platform string) bool {
 	return (platform == "Any CPU" || platform == "AnyCPU")
 }
 
-func androidPackageName(manifestPth string) (string, error) {
-	content, err := fileutil.ReadStringFromFile(manifestPth)
+func androidPackageName(manifestPth string, fileSystem utility.FileSystem) (string, error) {
+	content, err := fileSystem.ReadStringFromFile(manifestPth)
 	if err != nil {
 		return "", err
 	}
@@ -103,3 +131,22 @@ func androidPackageNameFromManifestContent(manifestContent string) (string, erro
 
 	return result.Manifest.Package, nil
 }
+
+// isDeviceRuntimeIdentifier returns true for the Apple device runtime identifiers, like: ios-arm64
+func isDeviceRuntimeIdentifier(runtimeIdentifier string) bool {
+	switch strings.ToLower(runtimeIdentifier) {
+	case "ios-arm64", "ios-arm", "tvos-arm64":
+		return true
+	default:
+		return false
+	}
+}
+
+func diagnosticsContain(diagnostics []buildtools.Diagnostic, diagnostic buildtools.Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d == diagnostic {
+			return true
+		}
+	}
+	return false
+}
@@ -20,7 +20,19 @@ func TestValidateSolutionPth(t *testing.T) {
 
 		solutionPth := filepath.Join(tmpDir, "solution.sln")
 		require.NoError(t, fileutil.WriteStringToFile(solutionPth, "solution"))
-		require.NoError(t, validateSolutionPth(solutionPth))
+		require.NoError(t, validateSolutionPth(solutionPth, utility.OSFileSystem))
+	}
+
+	t.Log("it validates xml solution and solution filter paths")
+	{
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("utility_test")
+		require.NoError(t, err)
+
+		for _, fileName := range []string{"solution.slnx", "solution.slnf"} {
+			solutionPth := filepath.Join(tmpDir, fileName)
+			require.NoError(t, fileutil.WriteStringToFile(solutionPth, "solution"))
+			require.NoError(t, validateSolutionPth(solutionPth, utility.OSFileSystem))
+		}
 	}
 
 	t.Log("it fails if file not exist")
@@ -29,7 +41,7 @@ func TestValidateSolutionPth(t *testing.T) {
 		require.NoError(t, err)
 
 		solutionPth := filepath.Join(tmpDir, "solution.sln")
-		require.Error(t, validateSolutionPth(solutionPth))
+		require.Error(t, validateSolutionPth(solutionPth, utility.OSFileSystem))
 	}
 
 	t.Log("it fails if path is not solution path")
@@ -38,7 +50,7 @@ func TestValidateSolutionPth(t *testing.T) {
 		require.NoError(t, err)
 
 		projectPth := filepath.Join(tmpDir, "project.csproj")
-		require.Error(t, validateSolutionPth(projectPth))
+		require.Error(t, validateSolutionPth(projectPth, utility.OSFileSystem))
 	}
 }
 
@@ -106,6 +118,28 @@ func TestWhitelistAllows(t *testing.T) {
 	}
 }
 
+func TestSolutionFoldersAllow(t *testing.T) {
+	t.Log("empty solution folder list means allow any project")
+	{
+		require.Equal(t, true, solutionFoldersAllow("Apps/Android"))
+		require.Equal(t, true, solutionFoldersAllow(""))
+	}
+
+	t.Log("it allows projects in the solution folder and its sub folders")
+	{
+		require.Equal(t, true, solutionFoldersAllow("Apps/Android", "Apps/Android"))
+		require.Equal(t, true, solutionFoldersAllow("Apps/Android/Wear", "apps/android"))
+		require.Equal(t, true, solutionFoldersAllow("Apps/iOS", "Tests", "Apps"))
+	}
+
+	t.Log("it does not allow projects outside of the solution folders")
+	{
+		require.Equal(t, false, solutionFoldersAllow("Apps/AndroidTV", "Apps/Android"))
+		require.Equal(t, false, solutionFoldersAllow("Apps", "Apps/Android"))
+		require.Equal(t, false, solutionFoldersAllow("", "Apps/Android"))
+	}
+}
+
 func TestIsArchitectureArchiveablet(t *testing.T) {
 	t.Log("default architectures is armv7")
 	{
@@ -1,14 +1,21 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
-
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-xamarin/analyzers/solution"
 	"github.com/bitrise-io/go-xamarin/builder"
 	"github.com/bitrise-io/go-xamarin/constants"
 	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
 	"github.com/urfave/cli"
 )
 
@@ -17,6 +24,10 @@ func buildCmd(c *cli.Context) error {
 	solutionConfiguration := c.String(solutionConfigurationKey)
 	solutionPlatform := c.String(solutionPlatformKey)
 	buildToolName := c.String(buildToolKey)
+	commandTimeout := c.Duration(commandTimeoutKey)
+	maxParallelism := c.Int(maxParallelismKey)
+	keepGoing := c.Bool(keepGoingKey)
+	analysisCacheDir := c.String(analysisCacheDirKey)
 
 	fmt.Println()
 	log.Infof("Config:")
@@ -24,6 +35,10 @@ func buildCmd(c *cli.Context) error {
 	log.Printf("- configuration: %s", solutionConfiguration)
 	log.Printf("- platform: %s", solutionPlatform)
 	log.Printf("- build-tool: %s", buildToolName)
+	log.Printf("- command-timeout: %s", commandTimeout)
+	log.Printf("- max-parallelism: %d", maxParallelism)
+	log.Printf("- keep-going: %v", keepGoing)
+	log.Printf("- cache-dir: %s", analysisCacheDir)
 
 	if solutionPth == "" {
 		return fmt.Errorf("missing required input: %s", solutionFilePathKey)
@@ -35,16 +50,39 @@ func buildCmd(c *cli.Context) error {
 		return fmt.Errorf("missing required input: %s", solutionPlatformKey)
 	}
 
-	buildTool := buildtools.Msbuild
-	if buildToolName == "xbuild" {
-		buildTool = buildtools.Xbuild
+	buildTool := parseBuildTool(buildToolName)
+
+	tool := toolchain.Msbuild
+	switch buildTool {
+	case buildtools.Xbuild:
+		tool = toolchain.Xbuild
+	case buildtools.DotnetCLI:
+		tool = toolchain.Dotnet
 	}
 
-	buildHandler, err := builder.New(solutionPth, nil, buildTool)
+	location, err := toolchain.Locate(tool)
 	if err != nil {
 		return cli.NewExitError(err.Error(), 1)
 	}
 
+	version, err := location.Version()
+	if err != nil {
+		log.Warnf("Failed to get %s version: %s", tool.Name, err)
+	}
+	log.Printf("- %s: %s (found in %s, version: %s)", tool.Name, location.Pth, location.Source, version)
+
+	buildHandler, err := newBuilder(solutionPth, analysisCacheDir, buildTool)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	buildHandler.SetCommandTimeout(commandTimeout)
+	buildHandler.SetMaxParallelism(maxParallelism)
+	buildHandler.SetKeepGoing(keepGoing)
+
+	// interrupting the build kills the running build command with its child processes
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	fmt.Println()
 	log.Infof("Building all projects in solution: %s", solutionPth)
 
@@ -62,11 +100,19 @@ func buildCmd(c *cli.Context) error {
 
 	startTime := time.Now()
 
-	warnings, err := buildHandler.BuildAllProjects(solutionConfiguration, solutionPlatform, true, nil, callback)
+	warnings, err := buildHandler.BuildAllProjects(ctx, solutionConfiguration, solutionPlatform, true, nil, callback)
 	for _, warning := range warnings {
 		log.Warnf(warning)
 	}
 	if err != nil {
+		var buildErr *builder.BuildError
+		if errors.As(err, &buildErr) {
+			for _, projectErr := range buildErr.Errors {
+				printBuildErrors(projectErr.Project, projectErr.Err)
+			}
+		} else {
+			printBuildErrors("", err)
+		}
 		return cli.NewExitError(err.Error(), 1)
 	}
 
@@ -91,3 +137,50 @@ func buildCmd(c *cli.Context) error {
 
 	return nil
 }
+
+// printBuildErrors prints the error diagnostics of the given project's failed build command.
+func printBuildErrors(projectName string, err error) {
+	var commandErr *builder.CommandError
+	if !errors.As(err, &commandErr) || len(commandErr.Errors()) == 0 {
+		return
+	}
+
+	fmt.Println()
+	if projectName != "" {
+		log.Errorf("%s build errors:", projectName)
+	} else {
+		log.Errorf("Build errors:")
+	}
+	for _, diagnostic := range commandErr.Errors() {
+		log.Errorf("- %s", diagnostic)
+	}
+}
+
+// parseBuildTool returns the build tool of the given name, msbuild by default.
+func parseBuildTool(name string) buildtools.BuildTool {
+	switch name {
+	case "xbuild":
+		return buildtools.Xbuild
+	case "dotnet":
+		return buildtools.DotnetCLI
+	}
+	return buildtools.Msbuild
+}
+
+// newBuilder analyzes the solution, using the project analysis cache in the given directory if it is not empty.
+// Project file paths create a builder for the project without solution.
+func newBuilder(solutionPth, analysisCacheDir string, buildTool buildtools.BuildTool) (builder.Model, error) {
+	switch filepath.Ext(solutionPth) {
+	case constants.CSProjExt, constants.FSProjExt:
+		return builder.NewWithProject(solutionPth, nil, buildTool)
+	}
+
+	analyzedSolution, err := solution.NewWithOptions(solutionPth, solution.AnalyzeOptions{LoadProjects: true, CacheDir: analysisCacheDir})
+	if err != nil {
+		return builder.Model{}, err
+	}
+	for _, diagnostic := range analyzedSolution.Warnings() {
+		log.Warnf("%s", diagnostic)
+	}
+	return builder.NewWithSolution(analyzedSolution, nil, buildTool)
+}
@@ -5,17 +5,18 @@ import (
 
 	"github.com/bitrise-io/go-utils/log"
 	"github.com/bitrise-io/go-xamarin/analyzers/project"
-	"github.com/bitrise-io/go-xamarin/builder"
 	"github.com/bitrise-io/go-xamarin/tools/buildtools"
 	"github.com/urfave/cli"
 )
 
 func cleanCmd(c *cli.Context) error {
 	solutionPth := c.String(solutionFilePathKey)
+	analysisCacheDir := c.String(analysisCacheDirKey)
 
 	fmt.Println("")
 	log.Infof("Config:")
 	log.Printf("- solution: %s", solutionPth)
+	log.Printf("- cache-dir: %s", analysisCacheDir)
 	fmt.Println("")
 
 	if solutionPth == "" {
@@ -24,7 +25,7 @@ func cleanCmd(c *cli.Context) error {
 
 	buildTool := buildtools.Xbuild
 
-	builder, err := builder.New(solutionPth, nil, buildTool)
+	builder, err := newBuilder(solutionPth, analysisCacheDir, buildTool)
 	if err != nil {
 		return err
 	}
@@ -7,7 +7,16 @@ const (
 	solutionConfigurationKey string = "configuration"
 	solutionPlatformKey      string = "platform"
 
-	buildToolKey string = "build-tool"
+	buildToolKey      string = "build-tool"
+	commandTimeoutKey string = "command-timeout"
+
+	maxParallelismKey string = "max-parallelism"
+	keepGoingKey      string = "keep-going"
+
+	analysisCacheDirKey string = "cache-dir"
+
+	planFormatKey        string = "format"
+	planXamarinUITestKey string = "xamarin-uitest"
 )
 
 var commands = []cli.Command{
@@ -18,7 +27,7 @@ var commands = []cli.Command{
 		Flags: []cli.Flag{
 			cli.StringFlag{
 				Name:  solutionFilePathKey,
-				Usage: "Solution file path",
+				Usage: "Solution file path, or project file path to build a project without solution",
 			},
 			cli.StringFlag{
 				Name:  solutionConfigurationKey,
@@ -30,7 +39,59 @@ var commands = []cli.Command{
 			},
 			cli.StringFlag{
 				Name:  buildToolKey,
-				Usage: "Build Tool to use, available: msbuild, xbuild",
+				Usage: "Build Tool to use, available: msbuild, xbuild, dotnet",
+			},
+			cli.DurationFlag{
+				Name:  commandTimeoutKey,
+				Usage: "Maximum duration of a single build command (for example 30m), 0 means no timeout",
+			},
+			cli.IntFlag{
+				Name:  maxParallelismKey,
+				Usage: "Maximum number of projects built concurrently, the independent projects are built in parallel",
+			},
+			cli.BoolFlag{
+				Name:  keepGoingKey,
+				Usage: "Keep building the other projects if a project fails",
+			},
+			cli.StringFlag{
+				Name:  analysisCacheDirKey,
+				Usage: "Directory of the project analysis cache, speeds up the repeated analysis of unchanged projects",
+			},
+		},
+	},
+	{
+		Name:   "plan",
+		Usage:  "Print the build plan of xamarin projects without building them",
+		Action: planCmd,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  solutionFilePathKey,
+				Usage: "Solution file path, or project file path to build a project without solution",
+			},
+			cli.StringFlag{
+				Name:  solutionConfigurationKey,
+				Usage: "Solution configuration",
+			},
+			cli.StringFlag{
+				Name:  solutionPlatformKey,
+				Usage: "Solution platform",
+			},
+			cli.StringFlag{
+				Name:  buildToolKey,
+				Usage: "Build Tool to use, available: msbuild, xbuild, dotnet",
+			},
+			cli.StringFlag{
+				Name:  analysisCacheDirKey,
+				Usage: "Directory of the project analysis cache, speeds up the repeated analysis of unchanged projects",
+			},
+			cli.StringFlag{
+				Name:  planFormatKey,
+				Value: "text",
+				Usage: "Output format, available: text, json",
+			},
+			cli.BoolFlag{
+				Name:  planXamarinUITestKey,
+				Usage: "Plan the build of the projects referred by the Xamarin UITest projects",
 			},
 		},
 	},
@@ -41,7 +102,11 @@ var commands = []cli.Command{
 		Flags: []cli.Flag{
 			cli.StringFlag{
 				Name:  solutionFilePathKey,
-				Usage: "Solution file path",
+				Usage: "Solution file path, or project file path to build a project without solution",
+			},
+			cli.StringFlag{
+				Name:  analysisCacheDirKey,
+				Usage: "Directory of the project analysis cache, speeds up the repeated analysis of unchanged projects",
 			},
 		},
 	},
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bitrise-io/go-xamarin/builder"
+	"github.com/urfave/cli"
+)
+
+func planCmd(c *cli.Context) error {
+	solutionPth := c.String(solutionFilePathKey)
+	solutionConfiguration := c.String(solutionConfigurationKey)
+	solutionPlatform := c.String(solutionPlatformKey)
+	buildToolName := c.String(buildToolKey)
+	analysisCacheDir := c.String(analysisCacheDirKey)
+	format := c.String(planFormatKey)
+	xamarinUITest := c.Bool(planXamarinUITestKey)
+
+	if solutionPth == "" {
+		return fmt.Errorf("missing required input: %s", solutionFilePathKey)
+	}
+	if solutionConfiguration == "" {
+		return fmt.Errorf("missing required input: %s", solutionConfigurationKey)
+	}
+	if solutionPlatform == "" {
+		return fmt.Errorf("missing required input: %s", solutionPlatformKey)
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid input: %s, available: text, json", planFormatKey)
+	}
+
+	buildHandler, err := newBuilder(solutionPth, analysisCacheDir, parseBuildTool(buildToolName))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	var plan builder.Plan
+	if xamarinUITest {
+		plan, err = buildHandler.PlanBuildAllUITestableXamarinProjects(solutionConfiguration, solutionPlatform, nil)
+	} else {
+		plan, err = buildHandler.PlanBuildAllProjects(solutionConfiguration, solutionPlatform, true, nil)
+	}
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	if format == "json" {
+		content, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(content))
+		return nil
+	}
+
+	fmt.Print(plan.String())
+	return nil
+}
@@ -1,6 +1,9 @@
 package constants
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	// MsbuildPath ...
@@ -10,11 +13,18 @@ const (
 
 	// MonoPath ...
 	MonoPath = "/Library/Frameworks/Mono.framework/Versions/Current/Commands/mono"
+
+	// DotnetPath ...
+	DotnetPath = "dotnet"
 )
 
 const (
 	// SolutionExt ...
 	SolutionExt = ".sln"
+	// SolutionXMLExt ...
+	SolutionXMLExt = ".slnx"
+	// SolutionFilterExt ...
+	SolutionFilterExt = ".slnf"
 	// CSProjExt ...
 	CSProjExt = ".csproj"
 	// FSProjExt ...
@@ -67,6 +77,10 @@ const (
 	TestFrameworkNunitTest TestFramework = "nunit-test"
 	// TestFrameworkNunitLiteTest ...
 	TestFrameworkNunitLiteTest TestFramework = "nunit-lite-test"
+	// TestFrameworkXunit ...
+	TestFrameworkXunit TestFramework = "xunit-test"
+	// TestFrameworkMSTest ...
+	TestFrameworkMSTest TestFramework = "mstest-test"
 )
 
 // ParseTestFramwork ...
@@ -78,6 +92,10 @@ func ParseTestFramwork(testFramwork string) (TestFramework, error) {
 		return TestFrameworkNunitTest, nil
 	case "nunit-lite-test":
 		return TestFrameworkNunitLiteTest, nil
+	case "xunit-test":
+		return TestFrameworkXunit, nil
+	case "mstest-test":
+		return TestFrameworkMSTest, nil
 	default:
 		return TestFrameworkUnknown, fmt.Errorf("invalid test framwork: %s", testFramwork)
 	}
@@ -108,6 +126,44 @@ func ParseProjectTypeGUID(guid string) (SDK, error) {
 	}
 }
 
+// ParseTargetFramework ...
+func ParseTargetFramework(targetFramework string) (SDK, error) {
+	tfm := strings.ToLower(strings.TrimSpace(targetFramework))
+
+	platform := ""
+	if idx := strings.Index(tfm, "-"); idx != -1 {
+		platform = tfm[idx+1:]
+	}
+
+	// the platform part might contain an OS version: net8.0-ios17.0
+	platform = strings.TrimRight(platform, "0123456789.")
+
+	switch platform {
+	case "android":
+		return SDKAndroid, nil
+	case "ios":
+		return SDKIOS, nil
+	case "tvos":
+		return SDKTvOS, nil
+	case "macos", "maccatalyst":
+		return SDKMacOS, nil
+	}
+
+	// Xamarin target frameworks used by multi-targeting libraries
+	switch {
+	case strings.HasPrefix(tfm, "monoandroid"):
+		return SDKAndroid, nil
+	case strings.HasPrefix(tfm, "xamarinios"), strings.HasPrefix(tfm, "xamarin.ios"):
+		return SDKIOS, nil
+	case strings.HasPrefix(tfm, "xamarintvos"), strings.HasPrefix(tfm, "xamarin.tvos"):
+		return SDKTvOS, nil
+	case strings.HasPrefix(tfm, "xamarinmac"), strings.HasPrefix(tfm, "xamarin.mac"):
+		return SDKMacOS, nil
+	}
+
+	return SDKUnknown, fmt.Errorf("Can not identify target framework: %s", targetFramework)
+}
+
 // OutputType ...
 type OutputType string
 
@@ -65,6 +65,20 @@ func TestParseTestFramwork(t *testing.T) {
 		require.Equal(t, TestFrameworkNunitLiteTest, projectType)
 	}
 
+	t.Log("it parses xunit-test")
+	{
+		projectType, err := ParseTestFramwork("xunit-test")
+		require.NoError(t, err)
+		require.Equal(t, TestFrameworkXunit, projectType)
+	}
+
+	t.Log("it parses mstest-test")
+	{
+		projectType, err := ParseTestFramwork("mstest-test")
+		require.NoError(t, err)
+		require.Equal(t, TestFrameworkMSTest, projectType)
+	}
+
 	t.Log("it failes for unknown type")
 	{
 		projectType, err := ParseTestFramwork("go")
@@ -211,3 +225,36 @@ func TestParseOutputType(t *testing.T) {
 		require.Equal(t, OutputTypeUnknown, outputType)
 	}
 }
+
+func TestParseTargetFramework(t *testing.T) {
+	t.Log("it parses .NET platform target frameworks")
+	{
+		for tfm, sdk := range map[string]SDK{
+			"net8.0-android":     SDKAndroid,
+			"net6.0-android31.0": SDKAndroid,
+			"net8.0-ios":         SDKIOS,
+			"net8.0-ios17.0":     SDKIOS,
+			"net8.0-tvos":        SDKTvOS,
+			"net8.0-macos":       SDKMacOS,
+			"net8.0-maccatalyst": SDKMacOS,
+			" NET7.0-Android ":   SDKAndroid,
+			"MonoAndroid12.0":    SDKAndroid,
+			"Xamarin.iOS10":      SDKIOS,
+			"xamarinmac20":       SDKMacOS,
+			"Xamarin.TVOS10":     SDKTvOS,
+		} {
+			projectType, err := ParseTargetFramework(tfm)
+			require.NoError(t, err, tfm)
+			require.Equal(t, sdk, projectType, tfm)
+		}
+	}
+
+	t.Log("it failes for non mobile target frameworks")
+	{
+		for _, tfm := range []string{"net8.0", "netstandard2.0", "net8.0-windows10.0.19041.0", ""} {
+			projectType, err := ParseTargetFramework(tfm)
+			require.Error(t, err, tfm)
+			require.Equal(t, SDKUnknown, projectType, tfm)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package buildtools
 
+import "strings"
+
 // BuildTool ...
 type BuildTool uint
 
@@ -8,4 +10,32 @@ const (
 	Msbuild BuildTool = iota
 	// Xbuild ...
 	Xbuild
+	// DotnetCLI ...
+	DotnetCLI
 )
+
+// Command is implemented by the build tool commands, it describes the command without running it.
+type Command interface {
+	// BuildPth returns the solution or project file the command builds.
+	BuildPth() string
+	// Target returns the MSBuild target (or dotnet CLI command) the command runs.
+	Target() string
+	// Properties returns the MSBuild properties the command sets, including the ones set by custom options.
+	Properties() map[string]string
+}
+
+// ParsePropertyOption returns the name and value of the MSBuild property set by the given command line option,
+// like /p:Name=Value or -property:Name=Value.
+func ParsePropertyOption(option string) (string, string, bool) {
+	for _, prefix := range []string{"/p:", "-p:", "/property:", "-property:"} {
+		if !strings.HasPrefix(strings.ToLower(option), prefix) {
+			continue
+		}
+
+		property := option[len(prefix):]
+		if idx := strings.Index(property, "="); idx > 0 {
+			return property[:idx], property[idx+1:], true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,280 @@
+package buildtools
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Severity ...
+type Severity string
+
+const (
+	// SeverityError ...
+	SeverityError Severity = "error"
+	// SeverityWarning ...
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is an error or warning reported by MSBuild, xbuild or a tool (compiler, linker, ...) they invoke.
+type Diagnostic struct {
+	Project     string // The project file being built, if known
+	File        string // The file the diagnostic refers to or the reporting tool (like MSBUILD or CSC)
+	Line        int
+	Column      int
+	EndLine     int
+	EndColumn   int
+	Subcategory string
+	Severity    Severity
+	Code        string // Like CS0103, MSB4018, XA5207 or MT1006
+	Message     string
+}
+
+// String returns the diagnostic in MSBuild's canonical format.
+func (diagnostic Diagnostic) String() string {
+	location := diagnostic.File
+	if diagnostic.Line > 0 {
+		position := strconv.Itoa(diagnostic.Line)
+		if diagnostic.Column > 0 {
+			position += "," + strconv.Itoa(diagnostic.Column)
+		}
+		location += "(" + position + ")"
+	}
+
+	s := diagnostic.Message
+	if diagnostic.Code != "" {
+		s = diagnostic.Code + ": " + s
+	} else {
+		s = ": " + s
+	}
+	s = string(diagnostic.Severity) + " " + s
+	if diagnostic.Subcategory != "" {
+		s = diagnostic.Subcategory + " " + s
+	}
+	if location != "" {
+		s = location + ": " + s
+	}
+	return strings.TrimSpace(strings.Replace(s, " : ", ": ", 1))
+}
+
+var (
+	// origin(location): [subcategory] error|warning [code]: message
+	canonicalDiagnosticRegexp = regexp.MustCompile(`^\s*(?:(.*?)\s*(?:\(([0-9,\-\s]+)\))?\s*:\s*)?(?:([^:]*?)\s+)?(error|warning)(?:\s+([A-Za-z]+[0-9]+))?\s*:\s*(.*?)\s*$`)
+	// MSBuild appends the project being built: message [/path/App.csproj] or [/path/App.csproj::TargetFramework=net8.0-ios]
+	projectSuffixRegexp = regexp.MustCompile(`\s*\[([^\[\]]+\.(?:[A-Za-z]*proj|sln))(?:::[^\[\]]*)?\]$`)
+	// Project "/path/App.csproj" (default target(s)):  - xbuild
+	// Project "/path/App.sln" (1) is building "/path/App.csproj" (2) on node 1 (default targets).  - msbuild
+	projectStartRegexp = regexp.MustCompile(`^Project "([^"]+)"(?: \(\d+(?::\d+)?\))?(?: is building "([^"]+)"(?: \(\d+(?::\d+)?\))?)?(?: on node \d+)? \((?:[^()]|\([^()]*\))*\)[:.]?$`)
+	// Done building project "/path/App.csproj".  - xbuild
+	// Done Building Project "/path/App.csproj" (default targets).  - msbuild
+	projectDoneRegexp = regexp.MustCompile(`^Done [Bb]uilding [Pp]roject "([^"]+)"`)
+	// MSBuild prefixes the output of the parallel builds with the node id: 1>
+	nodePrefixRegexp = regexp.MustCompile(`^\s*\d+>`)
+)
+
+// ParseDiagnostic parses a single line of MSBuild or xbuild console output,
+// it returns false if the line is not an error or warning.
+func ParseDiagnostic(line string) (Diagnostic, bool) {
+	line = nodePrefixRegexp.ReplaceAllString(strings.TrimRight(line, "\r\n"), "")
+
+	project := ""
+	if match := projectSuffixRegexp.FindStringSubmatch(line); len(match) == 2 {
+		project = match[1]
+		line = line[:len(line)-len(match[0])]
+	}
+
+	match := canonicalDiagnosticRegexp.FindStringSubmatch(line)
+	if len(match) != 7 {
+		return Diagnostic{}, false
+	}
+
+	origin, location, subcategory, severity, code, message := match[1], match[2], match[3], match[4], match[5], match[6]
+
+	// log lines (like "The build had an error: ...") are not diagnostics
+	if origin == "" && code == "" && subcategory != "" {
+		return Diagnostic{}, false
+	}
+	if strings.ContainsAny(subcategory, "\"'") || strings.Count(subcategory, " ") > 2 {
+		return Diagnostic{}, false
+	}
+
+	diagnostic := Diagnostic{
+		Project:     project,
+		File:        strings.TrimSpace(origin),
+		Subcategory: strings.TrimSpace(subcategory),
+		Severity:    Severity(severity),
+		Code:        code,
+		Message:     message,
+	}
+	diagnostic.Line, diagnostic.Column, diagnostic.EndLine, diagnostic.EndColumn = parseLocation(location)
+
+	return diagnostic, true
+}
+
+// parseLocation parses the location part of a canonical message:
+// (line), (line-endLine), (line,column), (line,column-endColumn) or (line,column,endLine,endColumn).
+func parseLocation(location string) (line, column, endLine, endColumn int) {
+	location = strings.Replace(location, " ", "", -1)
+	if location == "" {
+		return
+	}
+
+	atoi := func(s string) int {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return 0
+		}
+		return i
+	}
+
+	parts := strings.Split(location, ",")
+	switch len(parts) {
+	case 1:
+		bounds := strings.SplitN(parts[0], "-", 2)
+		line = atoi(bounds[0])
+		if len(bounds) == 2 {
+			endLine = atoi(bounds[1])
+		}
+	case 2:
+		line = atoi(parts[0])
+		bounds := strings.SplitN(parts[1], "-", 2)
+		column = atoi(bounds[0])
+		if len(bounds) == 2 {
+			endColumn = atoi(bounds[1])
+		}
+	case 4:
+		line, column, endLine, endColumn = atoi(parts[0]), atoi(parts[1]), atoi(parts[2]), atoi(parts[3])
+	}
+	return
+}
+
+// OutputParser collects the diagnostics of MSBuild or xbuild console output,
+// it is an io.Writer so it can be used along with the command's output writers.
+// The project of the diagnostics without project suffix is the project being built, according to the output.
+// Duplicated diagnostics (MSBuild repeats them in the build summary) are collected once.
+type OutputParser struct {
+	mu          sync.Mutex
+	buffer      bytes.Buffer
+	projects    []string
+	seen        map[Diagnostic]bool
+	diagnostics []Diagnostic
+}
+
+// NewOutputParser ...
+func NewOutputParser() *OutputParser {
+	return &OutputParser{seen: map[Diagnostic]bool{}}
+}
+
+// Write parses the complete lines of the given output, the incomplete last line is buffered.
+func (parser *OutputParser) Write(p []byte) (int, error) {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+
+	parser.buffer.Write(p)
+	for {
+		idx := bytes.IndexByte(parser.buffer.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+		line := string(parser.buffer.Next(idx + 1))
+		parser.parseLine(line)
+	}
+	return len(p), nil
+}
+
+// Flush parses the buffered incomplete line.
+func (parser *OutputParser) Flush() {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+
+	if parser.buffer.Len() > 0 {
+		parser.parseLine(parser.buffer.String())
+		parser.buffer.Reset()
+	}
+}
+
+// Diagnostics returns the diagnostics collected so far, in output order.
+func (parser *OutputParser) Diagnostics() []Diagnostic {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+
+	return append([]Diagnostic{}, parser.diagnostics...)
+}
+
+// Errors returns the error diagnostics collected so far, in output order.
+func (parser *OutputParser) Errors() []Diagnostic {
+	return FilterDiagnostics(parser.Diagnostics(), SeverityError)
+}
+
+func (parser *OutputParser) parseLine(line string) {
+	trimmed := strings.TrimSpace(nodePrefixRegexp.ReplaceAllString(line, ""))
+	if match := projectStartRegexp.FindStringSubmatch(trimmed); len(match) == 3 {
+		project := match[1]
+		if match[2] != "" {
+			project = match[2]
+		}
+		parser.projects = append(parser.projects, project)
+		return
+	}
+	if match := projectDoneRegexp.FindStringSubmatch(trimmed); len(match) == 2 {
+		for i := len(parser.projects) - 1; i >= 0; i-- {
+			if parser.projects[i] == match[1] {
+				parser.projects = append(parser.projects[:i], parser.projects[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+
+	diagnostic, ok := ParseDiagnostic(line)
+	if !ok {
+		return
+	}
+	if diagnostic.Project == "" && len(parser.projects) > 0 {
+		diagnostic.Project = parser.projects[len(parser.projects)-1]
+	}
+
+	// the build summary (printed out of the projects) repeats the diagnostics without project
+	withoutProject := diagnostic
+	withoutProject.Project = ""
+	if parser.seen[diagnostic] || (diagnostic.Project == "" && parser.seen[withoutProject]) {
+		return
+	}
+	parser.seen[diagnostic] = true
+	parser.seen[withoutProject] = true
+	parser.diagnostics = append(parser.diagnostics, diagnostic)
+}
+
+// ParseOutput parses the given MSBuild or xbuild console output line by line.
+func ParseOutput(reader io.Reader) ([]Diagnostic, error) {
+	parser := NewOutputParser()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if _, err := parser.Write(append(scanner.Bytes(), '\n')); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read build output: %s", err)
+	}
+
+	return parser.Diagnostics(), nil
+}
+
+// FilterDiagnostics returns the diagnostics with the given severity.
+func FilterDiagnostics(diagnostics []Diagnostic, severity Severity) []Diagnostic {
+	var filtered []Diagnostic
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == severity {
+			filtered = append(filtered, diagnostic)
+		}
+	}
+	return filtered
+}
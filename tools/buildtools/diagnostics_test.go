@@ -0,0 +1,214 @@
+package buildtools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const xbuildOutput = `XBuild Engine Version 14.0
+Mono, Version 5.4.1.6
+Copyright (C) 2005-2013 Various Mono authors
+
+Build started 1/12/2018 10:12:03 AM.
+__________________________________________________
+Project "/Users/bitrise/XamarinSample/XamarinSample.sln" (default target(s)):
+	Target ValidateSolutionConfiguration:
+		Building solution configuration "Release|iPhone".
+	Target Build:
+		Project "/Users/bitrise/XamarinSample/XamarinSample.iOS/XamarinSample.iOS.csproj" (default target(s)):
+			Target CoreCompile:
+				Tool /Library/Frameworks/Mono.framework/Versions/5.4.1/lib/mono/4.5/csc.exe execution started with arguments: /noconfig
+/Users/bitrise/XamarinSample/XamarinSample.iOS/Main.cs(10,5): error CS0103: The name 'foo' does not exist in the current context
+/Users/bitrise/XamarinSample/XamarinSample.iOS/AppDelegate.cs(22,13): warning CS0168: The variable 'ex' is declared but never used
+			Task "Csc" execution -- FAILED
+		Done building project "/Users/bitrise/XamarinSample/XamarinSample.iOS/XamarinSample.iOS.csproj".-- FAILED
+Done building project "/Users/bitrise/XamarinSample/XamarinSample.sln".-- FAILED
+
+Build FAILED.
+Warnings:
+
+/Users/bitrise/XamarinSample/XamarinSample.iOS/AppDelegate.cs(22,13): warning CS0168: The variable 'ex' is declared but never used
+
+Errors:
+
+/Users/bitrise/XamarinSample/XamarinSample.iOS/Main.cs(10,5): error CS0103: The name 'foo' does not exist in the current context
+
+	 1 Warning(s)
+	 1 Error(s)
+`
+
+const msbuildOutput = `Microsoft (R) Build Engine version 16.6.0 for Mono
+Build started 6/2/2020 9:01:37 AM.
+Project "/Users/bitrise/Sample/Sample.sln" on node 1 (Build target(s)).
+ValidateSolutionConfiguration:
+  Building solution configuration "Release|Any CPU".
+Project "/Users/bitrise/Sample/Sample.sln" (1) is building "/Users/bitrise/Sample/Sample.Droid/Sample.Droid.csproj" (2) on node 1 (default targets).
+_GenerateJavaStubs:
+/Library/Frameworks/Xamarin.Android.framework/Versions/10.3.1.4/lib/xamarin.android/xbuild/Xamarin/Android/Xamarin.Android.Common.targets(2377,2): error XA5207: Could not find android.jar for API level 29. [/Users/bitrise/Sample/Sample.Droid/Sample.Droid.csproj]
+Done Building Project "/Users/bitrise/Sample/Sample.Droid/Sample.Droid.csproj" (default targets) -- FAILED.
+Project "/Users/bitrise/Sample/Sample.sln" (1) is building "/Users/bitrise/Sample/Sample.iOS/Sample.iOS.csproj" (3) on node 1 (default targets).
+_CompileToNative:
+MTOUCH : error MT1006: Could not install the application '/Users/bitrise/Sample/Sample.iOS/bin/iPhone/Release/Sample.iOS.app' on the device. [/Users/bitrise/Sample/Sample.iOS/Sample.iOS.csproj::TargetFramework=net8.0-ios]
+Done Building Project "/Users/bitrise/Sample/Sample.iOS/Sample.iOS.csproj" (default targets) -- FAILED.
+Done Building Project "/Users/bitrise/Sample/Sample.sln" (Build target(s)) -- FAILED.
+
+Build FAILED.
+
+"/Users/bitrise/Sample/Sample.sln" (Build target) (1) ->
+"/Users/bitrise/Sample/Sample.Droid/Sample.Droid.csproj" (default target) (2) ->
+(_GenerateJavaStubs target) ->
+  /Library/Frameworks/Xamarin.Android.framework/Versions/10.3.1.4/lib/xamarin.android/xbuild/Xamarin/Android/Xamarin.Android.Common.targets(2377,2): error XA5207: Could not find android.jar for API level 29. [/Users/bitrise/Sample/Sample.Droid/Sample.Droid.csproj]
+
+    0 Warning(s)
+    2 Error(s)
+`
+
+func TestParseDiagnostic(t *testing.T) {
+	t.Log("compiler error")
+	{
+		diagnostic, ok := ParseDiagnostic("/src/App/Main.cs(10,5): error CS0103: The name 'foo' does not exist in the current context")
+		require.True(t, ok)
+		require.Equal(t, Diagnostic{
+			File:     "/src/App/Main.cs",
+			Line:     10,
+			Column:   5,
+			Severity: SeverityError,
+			Code:     "CS0103",
+			Message:  "The name 'foo' does not exist in the current context",
+		}, diagnostic)
+	}
+
+	t.Log("warning with range and project suffix")
+	{
+		diagnostic, ok := ParseDiagnostic("  1>/src/App/Main.cs(10,5,12,7): warning CS0168: The variable 'ex' is declared but never used [/src/App/App.csproj]")
+		require.True(t, ok)
+		require.Equal(t, Diagnostic{
+			Project:   "/src/App/App.csproj",
+			File:      "/src/App/Main.cs",
+			Line:      10,
+			Column:    5,
+			EndLine:   12,
+			EndColumn: 7,
+			Severity:  SeverityWarning,
+			Code:      "CS0168",
+			Message:   "The variable 'ex' is declared but never used",
+		}, diagnostic)
+	}
+
+	t.Log("tool error without location")
+	{
+		diagnostic, ok := ParseDiagnostic("MSBUILD : error MSB1009: Project file does not exist.")
+		require.True(t, ok)
+		require.Equal(t, "MSBUILD", diagnostic.File)
+		require.Equal(t, 0, diagnostic.Line)
+		require.Equal(t, "MSB1009", diagnostic.Code)
+		require.Equal(t, "Project file does not exist.", diagnostic.Message)
+	}
+
+	t.Log("error without origin and code")
+	{
+		diagnostic, ok := ParseDiagnostic("error : Something went wrong")
+		require.True(t, ok)
+		require.Equal(t, "", diagnostic.File)
+		require.Equal(t, "", diagnostic.Code)
+		require.Equal(t, "Something went wrong", diagnostic.Message)
+	}
+
+	t.Log("subcategory")
+	{
+		diagnostic, ok := ParseDiagnostic("clang : fatal error CLANG1: no such file")
+		require.True(t, ok)
+		require.Equal(t, "clang", diagnostic.File)
+		require.Equal(t, "fatal", diagnostic.Subcategory)
+		require.Equal(t, SeverityError, diagnostic.Severity)
+		require.Equal(t, "CLANG1", diagnostic.Code)
+	}
+
+	t.Log("not diagnostics")
+	{
+		for _, line := range []string{
+			"",
+			"Build FAILED.",
+			"    0 Warning(s)",
+			"    2 Error(s)",
+			"The build had an error: see above",
+			"Compile: error handling is enabled",
+			`Project "/src/App.sln" (default target(s)):`,
+		} {
+			_, ok := ParseDiagnostic(line)
+			require.False(t, ok, line)
+		}
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	t.Log("xbuild output")
+	{
+		diagnostics, err := ParseOutput(strings.NewReader(xbuildOutput))
+		require.NoError(t, err)
+		require.Equal(t, []Diagnostic{
+			{
+				Project:  "/Users/bitrise/XamarinSample/XamarinSample.iOS/XamarinSample.iOS.csproj",
+				File:     "/Users/bitrise/XamarinSample/XamarinSample.iOS/Main.cs",
+				Line:     10,
+				Column:   5,
+				Severity: SeverityError,
+				Code:     "CS0103",
+				Message:  "The name 'foo' does not exist in the current context",
+			},
+			{
+				Project:  "/Users/bitrise/XamarinSample/XamarinSample.iOS/XamarinSample.iOS.csproj",
+				File:     "/Users/bitrise/XamarinSample/XamarinSample.iOS/AppDelegate.cs",
+				Line:     22,
+				Column:   13,
+				Severity: SeverityWarning,
+				Code:     "CS0168",
+				Message:  "The variable 'ex' is declared but never used",
+			},
+		}, diagnostics)
+	}
+
+	t.Log("msbuild output")
+	{
+		diagnostics, err := ParseOutput(strings.NewReader(msbuildOutput))
+		require.NoError(t, err)
+		require.Equal(t, []Diagnostic{
+			{
+				Project:  "/Users/bitrise/Sample/Sample.Droid/Sample.Droid.csproj",
+				File:     "/Library/Frameworks/Xamarin.Android.framework/Versions/10.3.1.4/lib/xamarin.android/xbuild/Xamarin/Android/Xamarin.Android.Common.targets",
+				Line:     2377,
+				Column:   2,
+				Severity: SeverityError,
+				Code:     "XA5207",
+				Message:  "Could not find android.jar for API level 29.",
+			},
+			{
+				Project:  "/Users/bitrise/Sample/Sample.iOS/Sample.iOS.csproj",
+				File:     "MTOUCH",
+				Severity: SeverityError,
+				Code:     "MT1006",
+				Message:  "Could not install the application '/Users/bitrise/Sample/Sample.iOS/bin/iPhone/Release/Sample.iOS.app' on the device.",
+			},
+		}, diagnostics)
+	}
+}
+
+func TestOutputParser(t *testing.T) {
+	t.Log("it parses the lines split across writes")
+	{
+		parser := NewOutputParser()
+		for _, chunk := range []string{"/src/Main.cs(1,2): err", "or CS0103: The name 'foo'", " does not exist\n/src/Main.cs(3,4): warning CS0168: unused"} {
+			n, err := parser.Write([]byte(chunk))
+			require.NoError(t, err)
+			require.Equal(t, len(chunk), n)
+		}
+		require.Equal(t, 1, len(parser.Diagnostics()))
+
+		parser.Flush()
+		require.Equal(t, 2, len(parser.Diagnostics()))
+		require.Equal(t, 1, len(parser.Errors()))
+		require.Equal(t, "/src/Main.cs(1,2): error CS0103: The name 'foo' does not exist", parser.Errors()[0].String())
+	}
+}
@@ -0,0 +1,250 @@
+package dotnet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
+)
+
+// Command ...
+type Command string
+
+const (
+	// CommandBuild ...
+	CommandBuild Command = "build"
+	// CommandPublish ...
+	CommandPublish Command = "publish"
+)
+
+// Model builds SDK-style (.NET for Android/iOS, MAUI) projects with the dotnet CLI.
+type Model struct {
+	BuildTool string // The dotnet CLI's path, if empty the dotnet CLI is located when the command runs
+
+	SolutionPth string
+	ProjectPth  string
+
+	command           Command
+	framework         string
+	configuration     string
+	platform          string
+	runtimeIdentifier string
+
+	properties []string // Name=Value
+
+	customOptions []string
+
+	executor tools.Executor
+}
+
+// New ...
+func New(solutionPth, projectPth string) (*Model, error) {
+	// projects can be built without a solution
+	absSolutionPth := ""
+	if solutionPth != "" {
+		absPth, err := pathutil.AbsPath(solutionPth)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to expand path (%s), error: %s", solutionPth, err)
+		}
+		absSolutionPth = absPth
+	}
+
+	absProjectPth := ""
+	if projectPth != "" {
+		absPth, err := pathutil.AbsPath(projectPth)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to expand path (%s), error: %s", projectPth, err)
+		}
+		absProjectPth = absPth
+	}
+
+	return &Model{SolutionPth: absSolutionPth, ProjectPth: absProjectPth, command: CommandBuild}, nil
+}
+
+// SetCommand ...
+func (dotnet *Model) SetCommand(command Command) *Model {
+	dotnet.command = command
+	return dotnet
+}
+
+// SetFramework ...
+func (dotnet *Model) SetFramework(framework string) *Model {
+	dotnet.framework = framework
+	return dotnet
+}
+
+// SetConfiguration ...
+func (dotnet *Model) SetConfiguration(configuration string) *Model {
+	dotnet.configuration = configuration
+	return dotnet
+}
+
+// SetPlatform ...
+func (dotnet *Model) SetPlatform(platform string) *Model {
+	dotnet.platform = platform
+	return dotnet
+}
+
+// SetRuntimeIdentifier ...
+func (dotnet *Model) SetRuntimeIdentifier(runtimeIdentifier string) *Model {
+	dotnet.runtimeIdentifier = runtimeIdentifier
+	return dotnet
+}
+
+// SetProperty sets an MSBuild property, passed as: -p:Name=Value
+func (dotnet *Model) SetProperty(name, value string) *Model {
+	property := name + "=" + value
+	for i, p := range dotnet.properties {
+		if strings.HasPrefix(p, name+"=") {
+			dotnet.properties[i] = property
+			return dotnet
+		}
+	}
+	dotnet.properties = append(dotnet.properties, property)
+	return dotnet
+}
+
+// SetCustomOptions ...
+func (dotnet *Model) SetCustomOptions(options ...string) {
+	dotnet.customOptions = options
+}
+
+// SetExecutor sets the executor running the command, the command runs as an OS process by default.
+func (dotnet *Model) SetExecutor(executor tools.Executor) {
+	dotnet.executor = executor
+}
+
+// BuildPth returns the project path, or the solution path if the command builds the solution.
+func (dotnet Model) BuildPth() string {
+	if dotnet.ProjectPth != "" {
+		return dotnet.ProjectPth
+	}
+	return dotnet.SolutionPth
+}
+
+// Target returns the dotnet CLI command.
+func (dotnet Model) Target() string {
+	return string(dotnet.command)
+}
+
+// Properties returns the MSBuild properties the command sets, the dotnet CLI options are mapped to the properties they set.
+func (dotnet Model) Properties() map[string]string {
+	properties := map[string]string{}
+	if dotnet.framework != "" {
+		properties["TargetFramework"] = dotnet.framework
+	}
+	if dotnet.configuration != "" {
+		properties["Configuration"] = dotnet.configuration
+	}
+	if dotnet.runtimeIdentifier != "" {
+		properties["RuntimeIdentifier"] = dotnet.runtimeIdentifier
+	}
+	if dotnet.SolutionPth != "" {
+		properties["SolutionDir"] = strings.TrimSuffix(filepath.Dir(dotnet.SolutionPth), string(filepath.Separator)) + string(filepath.Separator)
+	}
+	if dotnet.platform != "" {
+		properties["Platform"] = dotnet.platform
+	}
+	for _, property := range dotnet.properties {
+		if idx := strings.Index(property, "="); idx > 0 {
+			properties[property[:idx]] = property[idx+1:]
+		}
+	}
+	for _, option := range dotnet.customOptions {
+		if name, value, ok := buildtools.ParsePropertyOption(option); ok {
+			properties[name] = value
+		}
+	}
+	return properties
+}
+
+// buildToolPth returns the dotnet CLI's path, the dotnet CLI is located if the path is not set.
+func (dotnet Model) buildToolPth() (string, error) {
+	if dotnet.BuildTool != "" {
+		return dotnet.BuildTool, nil
+	}
+
+	location, err := toolchain.Locate(toolchain.Dotnet)
+	if err != nil {
+		return "", fmt.Errorf("the dotnet CLI is required to build the SDK-style projects, error: %s", err)
+	}
+	return location.Pth, nil
+}
+
+// printableBuildTool returns the dotnet CLI's path, or its name if the dotnet CLI is not found.
+func (dotnet Model) printableBuildTool() string {
+	if pth, err := dotnet.buildToolPth(); err == nil {
+		return pth
+	}
+	return toolchain.Dotnet.Name
+}
+
+func (dotnet Model) buildCommands() []string {
+	return dotnet.buildCommandsWith(dotnet.printableBuildTool())
+}
+
+func (dotnet Model) buildCommandsWith(buildTool string) []string {
+	cmdSlice := []string{buildTool, string(dotnet.command)}
+
+	if dotnet.ProjectPth != "" {
+		cmdSlice = append(cmdSlice, dotnet.ProjectPth)
+	} else {
+		cmdSlice = append(cmdSlice, dotnet.SolutionPth)
+	}
+
+	if dotnet.framework != "" {
+		cmdSlice = append(cmdSlice, "-f", dotnet.framework)
+	}
+
+	if dotnet.configuration != "" {
+		cmdSlice = append(cmdSlice, "-c", dotnet.configuration)
+	}
+
+	if dotnet.runtimeIdentifier != "" {
+		cmdSlice = append(cmdSlice, "-r", dotnet.runtimeIdentifier)
+	}
+
+	if dotnet.SolutionPth != "" {
+		solutionDirPth := strings.TrimSuffix(filepath.Dir(dotnet.SolutionPth), string(filepath.Separator)) + string(filepath.Separator)
+		cmdSlice = append(cmdSlice, "-p:SolutionDir="+solutionDirPth)
+	}
+
+	if dotnet.platform != "" {
+		cmdSlice = append(cmdSlice, "-p:Platform="+dotnet.platform)
+	}
+
+	for _, property := range dotnet.properties {
+		cmdSlice = append(cmdSlice, "-p:"+property)
+	}
+
+	cmdSlice = append(cmdSlice, dotnet.customOptions...)
+
+	return cmdSlice
+}
+
+// String ...
+func (dotnet Model) String() string {
+	cmdSlice := dotnet.buildCommands()
+	return command.PrintableCommandArgs(true, cmdSlice)
+}
+
+// Run ...
+func (dotnet Model) Run(outWriter, errWriter io.Writer) error {
+	return dotnet.RunContext(context.Background(), outWriter, errWriter)
+}
+
+// RunContext runs the command, the command's process tree is killed when the context is done.
+func (dotnet Model) RunContext(ctx context.Context, outWriter, errWriter io.Writer) error {
+	buildTool, err := dotnet.buildToolPth()
+	if err != nil {
+		return err
+	}
+	return tools.Execute(ctx, dotnet.executor, dotnet.buildCommandsWith(buildTool), outWriter, errWriter)
+}
@@ -1,20 +1,24 @@
 package xbuild
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/bitrise-io/go-utils/command"
 	"github.com/bitrise-io/go-utils/pathutil"
-	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/buildtools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
 )
 
 // Model ...
 type Model struct {
-	BuildTool string
+	BuildTool string // The build tool's path, if empty the tool is located when the command runs
+
+	tool toolchain.Tool
 
 	SolutionPth string
 	ProjectPth  string
@@ -27,13 +31,26 @@ type Model struct {
 	archiveOnBuild bool
 
 	customOptions []string
+
+	executor tools.Executor
 }
 
 // New ...
 func New(solutionPth, projectPth string) (*Model, error) {
-	absSolutionPth, err := pathutil.AbsPath(solutionPth)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to expand path (%s), error: %s", solutionPth, err)
+	return NewWithTool(toolchain.Xbuild, solutionPth, projectPth)
+}
+
+// NewWithTool creates a command run by the given build tool (like msbuild), the tool is located when the command runs,
+// so the command can be planned on machines without the tool.
+func NewWithTool(tool toolchain.Tool, solutionPth, projectPth string) (*Model, error) {
+	// projects can be built without a solution
+	absSolutionPth := ""
+	if solutionPth != "" {
+		absPth, err := pathutil.AbsPath(solutionPth)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to expand path (%s), error: %s", solutionPth, err)
+		}
+		absSolutionPth = absPth
 	}
 
 	absProjectPth := ""
@@ -45,7 +62,7 @@ func New(solutionPth, projectPth string) (*Model, error) {
 		absProjectPth = absPth
 	}
 
-	return &Model{SolutionPth: absSolutionPth, ProjectPth: absProjectPth, BuildTool: constants.XbuildPath}, nil
+	return &Model{SolutionPth: absSolutionPth, ProjectPth: absProjectPth, tool: tool}, nil
 }
 
 // SetTarget ...
@@ -83,8 +100,77 @@ func (xbuild *Model) SetCustomOptions(options ...string) {
 	xbuild.customOptions = options
 }
 
+// SetExecutor sets the executor running the command, the command runs as an OS process by default.
+func (xbuild *Model) SetExecutor(executor tools.Executor) {
+	xbuild.executor = executor
+}
+
+// BuildPth returns the project path, or the solution path if the command builds the solution.
+func (xbuild Model) BuildPth() string {
+	if xbuild.ProjectPth != "" {
+		return xbuild.ProjectPth
+	}
+	return xbuild.SolutionPth
+}
+
+// Target ...
+func (xbuild Model) Target() string {
+	return xbuild.target
+}
+
+// Properties returns the MSBuild properties the command sets.
+func (xbuild Model) Properties() map[string]string {
+	properties := map[string]string{}
+	if xbuild.SolutionPth != "" {
+		properties["SolutionDir"] = ensureTrailingPathSeparator(filepath.Dir(xbuild.SolutionPth))
+	}
+	if xbuild.configuration != "" {
+		properties["Configuration"] = xbuild.configuration
+	}
+	if xbuild.platform != "" {
+		properties["Platform"] = xbuild.platform
+	}
+	if xbuild.archiveOnBuild {
+		properties["ArchiveOnBuild"] = "true"
+	}
+	if xbuild.buildIpa {
+		properties["BuildIpa"] = "true"
+	}
+	for _, option := range xbuild.customOptions {
+		if name, value, ok := buildtools.ParsePropertyOption(option); ok {
+			properties[name] = value
+		}
+	}
+	return properties
+}
+
+// buildToolPth returns the build tool's path, the tool is located if the path is not set.
+func (xbuild Model) buildToolPth() (string, error) {
+	if xbuild.BuildTool != "" {
+		return xbuild.BuildTool, nil
+	}
+
+	location, err := toolchain.Locate(xbuild.tool)
+	if err != nil {
+		return "", err
+	}
+	return location.Pth, nil
+}
+
+// printableBuildTool returns the build tool's path, or its name if the tool is not found.
+func (xbuild Model) printableBuildTool() string {
+	if pth, err := xbuild.buildToolPth(); err == nil {
+		return pth
+	}
+	return xbuild.tool.Name
+}
+
 func (xbuild Model) buildCommands() []string {
-	cmdSlice := []string{xbuild.BuildTool}
+	return xbuild.buildCommandsWith(xbuild.printableBuildTool())
+}
+
+func (xbuild Model) buildCommandsWith(buildTool string) []string {
+	cmdSlice := []string{buildTool}
 
 	if xbuild.ProjectPth != "" {
 		cmdSlice = append(cmdSlice, xbuild.ProjectPth)
@@ -98,8 +184,10 @@ func (xbuild Model) buildCommands() []string {
 
 	// According to official docs this value should include the trailing backslash:
 	// https://docs.microsoft.com/en-us/cpp/build/reference/common-macros-for-build-commands-and-properties?view=vs-2019
-	solutionDirPth := ensureTrailingPathSeparator(filepath.Dir(xbuild.SolutionPth))
-	cmdSlice = append(cmdSlice, "/p:SolutionDir="+solutionDirPth)
+	if xbuild.SolutionPth != "" {
+		solutionDirPth := ensureTrailingPathSeparator(filepath.Dir(xbuild.SolutionPth))
+		cmdSlice = append(cmdSlice, "/p:SolutionDir="+solutionDirPth)
+	}
 
 	if xbuild.configuration != "" {
 		cmdSlice = append(cmdSlice, "/p:Configuration="+xbuild.configuration)
@@ -130,24 +218,16 @@ func (xbuild Model) String() string {
 
 // Run ...
 func (xbuild Model) Run(outWriter, errWriter io.Writer) error {
-	if outWriter == nil {
-		outWriter = os.Stdout
-	}
-	if errWriter == nil {
-		errWriter = os.Stderr
-	}
-
-	cmdSlice := xbuild.buildCommands()
+	return xbuild.RunContext(context.Background(), outWriter, errWriter)
+}
 
-	command, err := command.NewFromSlice(cmdSlice)
+// RunContext runs the command, the command's process tree is killed when the context is done.
+func (xbuild Model) RunContext(ctx context.Context, outWriter, errWriter io.Writer) error {
+	buildTool, err := xbuild.buildToolPth()
 	if err != nil {
 		return err
 	}
-
-	command.SetStdout(outWriter)
-	command.SetStderr(errWriter)
-
-	return command.Run()
+	return tools.Execute(ctx, xbuild.executor, xbuild.buildCommandsWith(buildTool), outWriter, errWriter)
 }
 
 func ensureTrailingPathSeparator(path string) string {
@@ -2,15 +2,42 @@ package xbuild
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
-	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
 	"github.com/stretchr/testify/require"
 )
 
+var testXbuildPth string
+
+func TestMain(m *testing.M) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__xbuild-test__")
+	if err != nil {
+		panic(err)
+	}
+
+	testXbuildPth = filepath.Join(tmpDir, "xbuild")
+	if err := fileutil.WriteStringToFile(testXbuildPth, "#!/bin/sh\necho 'XBuild Engine Version 14.0'\n"); err != nil {
+		panic(err)
+	}
+	if err := os.Chmod(testXbuildPth, 0755); err != nil {
+		panic(err)
+	}
+	toolchain.SetOverride(toolchain.Xbuild, testXbuildPth)
+
+	code := m.Run()
+
+	if err := os.RemoveAll(tmpDir); err != nil {
+		panic(err)
+	}
+	os.Exit(code)
+}
+
 func TestNew(t *testing.T) {
 	t.Log("it create new xbuild model")
 	{
@@ -21,7 +48,9 @@ func TestNew(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, xbuild)
 
-		require.Equal(t, constants.XbuildPath, xbuild.BuildTool)
+		buildTool, err := xbuild.buildToolPth()
+		require.NoError(t, err)
+		require.Equal(t, testXbuildPth, buildTool)
 		require.Equal(t, filepath.Join(currentDir, "solution.sln"), xbuild.SolutionPth)
 		require.Equal(t, "", xbuild.configuration)
 		require.Equal(t, "", xbuild.platform)
@@ -42,7 +71,9 @@ func TestNew(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, xbuild)
 
-		require.Equal(t, constants.XbuildPath, xbuild.BuildTool)
+		buildTool, err := xbuild.buildToolPth()
+		require.NoError(t, err)
+		require.Equal(t, testXbuildPth, buildTool)
 		require.Equal(t, filepath.Join(currentDir, "solution.sln"), xbuild.SolutionPth)
 		require.Equal(t, filepath.Join(currentDir, "project.csproj"), xbuild.ProjectPth)
 		require.Equal(t, "", xbuild.configuration)
@@ -54,6 +85,27 @@ func TestNew(t *testing.T) {
 
 		require.Equal(t, 0, len(xbuild.customOptions))
 	}
+
+	t.Log("it creates the command if xbuild not found, the command fails to run")
+	{
+		toolchain.SetOverride(toolchain.Xbuild, "/not/existing/xbuild")
+		defer toolchain.SetOverride(toolchain.Xbuild, testXbuildPth)
+
+		xbuild, err := New("/solution.sln", "")
+		require.NoError(t, err)
+		require.Equal(t, `"xbuild" "/solution.sln" "/p:SolutionDir=/"`, xbuild.String())
+
+		err = xbuild.Run(io.Discard, io.Discard)
+		require.EqualError(t, err, "xbuild override (/not/existing/xbuild) is invalid: not exist: /not/existing/xbuild")
+	}
+
+	t.Log("it uses the given build tool path")
+	{
+		xbuild, err := New("/solution.sln", "")
+		require.NoError(t, err)
+		xbuild.BuildTool = "/custom/xbuild"
+		require.Equal(t, `"/custom/xbuild" "/solution.sln" "/p:SolutionDir=/"`, xbuild.String())
+	}
 }
 
 func TestSetProperties(t *testing.T) {
@@ -134,7 +186,7 @@ func Test_buildCommands(t *testing.T) {
 		xbuild, err := New("./test/solution.sln", "./test/ios/project.csproj")
 		require.NoError(t, err)
 		desired := []string{
-			constants.XbuildPath,
+			testXbuildPth,
 			filepath.Join(currentDir, "test/ios/project.csproj"),
 			fmt.Sprintf("/p:SolutionDir=%s", filepath.Join(currentDir, "test")+string(filepath.Separator)),
 		}
@@ -145,7 +197,15 @@ func Test_buildCommands(t *testing.T) {
 	{
 		xbuild, err := New("/Users/Develop/test/solution.sln", "/Users/Develop/test/test/ios/project.csproj")
 		require.NoError(t, err)
-		desired := []string{constants.XbuildPath, "/Users/Develop/test/test/ios/project.csproj", "/p:SolutionDir=/Users/Develop/test/"}
+		desired := []string{testXbuildPth, "/Users/Develop/test/test/ios/project.csproj", "/p:SolutionDir=/Users/Develop/test/"}
+		require.Equal(t, desired, xbuild.buildCommands())
+	}
+
+	t.Log("it builds the project without solution")
+	{
+		xbuild, err := New("", "/Users/Develop/test/ios/project.csproj")
+		require.NoError(t, err)
+		desired := []string{testXbuildPth, "/Users/Develop/test/ios/project.csproj"}
 		require.Equal(t, desired, xbuild.buildCommands())
 	}
 
@@ -153,31 +213,31 @@ func Test_buildCommands(t *testing.T) {
 	{
 		xbuild, err := New("/solution.sln", "")
 		require.NoError(t, err)
-		desired := []string{constants.XbuildPath, "/solution.sln", "/p:SolutionDir=/"}
+		desired := []string{testXbuildPth, "/solution.sln", "/p:SolutionDir=/"}
 		require.Equal(t, desired, xbuild.buildCommands())
 
 		xbuild.SetTarget("Build")
-		desired = []string{constants.XbuildPath, "/solution.sln", "/target:Build", "/p:SolutionDir=/"}
+		desired = []string{testXbuildPth, "/solution.sln", "/target:Build", "/p:SolutionDir=/"}
 		require.Equal(t, desired, xbuild.buildCommands())
 
 		xbuild.SetConfiguration("Release")
-		desired = []string{constants.XbuildPath, "/solution.sln", "/target:Build", "/p:SolutionDir=/", "/p:Configuration=Release"}
+		desired = []string{testXbuildPth, "/solution.sln", "/target:Build", "/p:SolutionDir=/", "/p:Configuration=Release"}
 		require.Equal(t, desired, xbuild.buildCommands())
 
 		xbuild.SetPlatform("iPhone")
-		desired = []string{constants.XbuildPath, "/solution.sln", "/target:Build", "/p:SolutionDir=/", "/p:Configuration=Release", "/p:Platform=iPhone"}
+		desired = []string{testXbuildPth, "/solution.sln", "/target:Build", "/p:SolutionDir=/", "/p:Configuration=Release", "/p:Platform=iPhone"}
 		require.Equal(t, desired, xbuild.buildCommands())
 
 		xbuild.SetArchiveOnBuild(true)
-		desired = []string{constants.XbuildPath, "/solution.sln", "/target:Build", "/p:SolutionDir=/", "/p:Configuration=Release", "/p:Platform=iPhone", "/p:ArchiveOnBuild=true"}
+		desired = []string{testXbuildPth, "/solution.sln", "/target:Build", "/p:SolutionDir=/", "/p:Configuration=Release", "/p:Platform=iPhone", "/p:ArchiveOnBuild=true"}
 		require.Equal(t, desired, xbuild.buildCommands())
 
 		xbuild.SetBuildIpa(true)
-		desired = []string{constants.XbuildPath, "/solution.sln", "/target:Build", "/p:SolutionDir=/", "/p:Configuration=Release", "/p:Platform=iPhone", "/p:ArchiveOnBuild=true", "/p:BuildIpa=true"}
+		desired = []string{testXbuildPth, "/solution.sln", "/target:Build", "/p:SolutionDir=/", "/p:Configuration=Release", "/p:Platform=iPhone", "/p:ArchiveOnBuild=true", "/p:BuildIpa=true"}
 		require.Equal(t, desired, xbuild.buildCommands())
 
 		xbuild.SetCustomOptions("/nologo")
-		desired = []string{constants.XbuildPath, "/solution.sln", "/target:Build", "/p:SolutionDir=/", "/p:Configuration=Release", "/p:Platform=iPhone", "/p:ArchiveOnBuild=true", "/p:BuildIpa=true", "/nologo"}
+		desired = []string{testXbuildPth, "/solution.sln", "/target:Build", "/p:SolutionDir=/", "/p:Configuration=Release", "/p:Platform=iPhone", "/p:ArchiveOnBuild=true", "/p:BuildIpa=true", "/nologo"}
 		require.Equal(t, desired, xbuild.buildCommands())
 	}
 }
@@ -191,7 +251,7 @@ func TestString(t *testing.T) {
 		xbuild, err := New("./test/solution.sln", "./test/ios/project.csproj")
 		require.NoError(t, err)
 		desired := fmt.Sprintf(`"%s" "%s" "%s"`,
-			constants.XbuildPath,
+			testXbuildPth,
 			filepath.Join(currentDir, "test/ios/project.csproj"),
 			fmt.Sprintf("/p:SolutionDir=%s", filepath.Join(currentDir, "test")+string(os.PathSeparator)),
 		)
@@ -202,7 +262,7 @@ func TestString(t *testing.T) {
 	{
 		xbuild, err := New("/Users/Develop/test/solution.sln", "/Users/Develop/test/test/ios/project.csproj")
 		require.NoError(t, err)
-		desired := fmt.Sprintf(`"%s" "/Users/Develop/test/test/ios/project.csproj" "/p:SolutionDir=/Users/Develop/test/"`, constants.XbuildPath)
+		desired := fmt.Sprintf(`"%s" "/Users/Develop/test/test/ios/project.csproj" "/p:SolutionDir=/Users/Develop/test/"`, testXbuildPth)
 		require.Equal(t, desired, xbuild.String())
 	}
 
@@ -210,35 +270,74 @@ func TestString(t *testing.T) {
 	{
 		xbuild, err := New("/solution.sln", "")
 		require.NoError(t, err)
-		desired := fmt.Sprintf(`"%s" "/solution.sln" "/p:SolutionDir=/"`, constants.XbuildPath)
+		desired := fmt.Sprintf(`"%s" "/solution.sln" "/p:SolutionDir=/"`, testXbuildPth)
 		require.Equal(t, desired, xbuild.String())
 
 		xbuild.SetTarget("Build")
-		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/"`, constants.XbuildPath)
+		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/"`, testXbuildPth)
 		require.Equal(t, desired, xbuild.String())
 
 		xbuild.SetConfiguration("Release")
-		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/" "/p:Configuration=Release"`, constants.XbuildPath)
+		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/" "/p:Configuration=Release"`, testXbuildPth)
 		require.Equal(t, desired, xbuild.String())
 
 		xbuild.SetPlatform("iPhone")
-		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/" "/p:Configuration=Release" "/p:Platform=iPhone"`, constants.XbuildPath)
+		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/" "/p:Configuration=Release" "/p:Platform=iPhone"`, testXbuildPth)
 		require.Equal(t, desired, xbuild.String())
 
 		xbuild.SetArchiveOnBuild(true)
-		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/" "/p:Configuration=Release" "/p:Platform=iPhone" "/p:ArchiveOnBuild=true"`, constants.XbuildPath)
+		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/" "/p:Configuration=Release" "/p:Platform=iPhone" "/p:ArchiveOnBuild=true"`, testXbuildPth)
 		require.Equal(t, desired, xbuild.String())
 
 		xbuild.SetBuildIpa(true)
-		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/" "/p:Configuration=Release" "/p:Platform=iPhone" "/p:ArchiveOnBuild=true" "/p:BuildIpa=true"`, constants.XbuildPath)
+		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/" "/p:Configuration=Release" "/p:Platform=iPhone" "/p:ArchiveOnBuild=true" "/p:BuildIpa=true"`, testXbuildPth)
 		require.Equal(t, desired, xbuild.String())
 
 		xbuild.SetCustomOptions("/nologo")
-		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/" "/p:Configuration=Release" "/p:Platform=iPhone" "/p:ArchiveOnBuild=true" "/p:BuildIpa=true" "/nologo"`, constants.XbuildPath)
+		desired = fmt.Sprintf(`"%s" "/solution.sln" "/target:Build" "/p:SolutionDir=/" "/p:Configuration=Release" "/p:Platform=iPhone" "/p:ArchiveOnBuild=true" "/p:BuildIpa=true" "/nologo"`, testXbuildPth)
 		require.Equal(t, desired, xbuild.String())
 	}
 }
 
+func TestProperties(t *testing.T) {
+	t.Log("it describes the project build")
+	{
+		xbuild, err := New("/solution/App.sln", "/solution/App/App.csproj")
+		require.NoError(t, err)
+
+		xbuild.SetTarget("SignAndroidPackage")
+		xbuild.SetConfiguration("Release")
+		xbuild.SetCustomOptions("/nologo", "/p:AndroidKeyStore=true", "-property:AndroidPackageFormat=aab")
+
+		require.Equal(t, "/solution/App/App.csproj", xbuild.BuildPth())
+		require.Equal(t, "SignAndroidPackage", xbuild.Target())
+		require.Equal(t, map[string]string{
+			"SolutionDir":          "/solution/",
+			"Configuration":        "Release",
+			"AndroidKeyStore":      "true",
+			"AndroidPackageFormat": "aab",
+		}, xbuild.Properties())
+	}
+
+	t.Log("it describes the solution build")
+	{
+		xbuild, err := New("/solution/App.sln", "")
+		require.NoError(t, err)
+
+		xbuild.SetPlatform("iPhone")
+		xbuild.SetArchiveOnBuild(true)
+		xbuild.SetBuildIpa(true)
+
+		require.Equal(t, "/solution/App.sln", xbuild.BuildPth())
+		require.Equal(t, map[string]string{
+			"SolutionDir":    "/solution/",
+			"Platform":       "iPhone",
+			"ArchiveOnBuild": "true",
+			"BuildIpa":       "true",
+		}, xbuild.Properties())
+	}
+}
+
 func Test_ensureTrailingPathSeparator(t *testing.T) {
 	tests := []struct {
 		name string
@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// RunCommandContext runs the given command slice,
+// the command's whole process tree is killed when the context is cancelled or its deadline is exceeded.
+func RunCommandContext(ctx context.Context, cmdSlice []string, outWriter, errWriter io.Writer) error {
+	if len(cmdSlice) == 0 {
+		return fmt.Errorf("no command specified")
+	}
+
+	if outWriter == nil {
+		outWriter = os.Stdout
+	}
+	if errWriter == nil {
+		errWriter = os.Stderr
+	}
+
+	cmd := exec.Command(cmdSlice[0], cmdSlice[1:]...)
+	cmd.Stdout = outWriter
+	cmd.Stderr = errWriter
+
+	return RunCmdContext(ctx, cmd)
+}
+
+// RunCmdContext starts the given command and waits for it to finish,
+// the command's whole process tree is killed when the context is cancelled or its deadline is exceeded.
+// The processes escaping the kill (like a grandchild started in another process group) might keep the command's outputs open,
+// the outputs are closed after the kill, so the command does not wait for them.
+func RunCmdContext(ctx context.Context, cmd *exec.Cmd) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	setProcessGroup(cmd)
+
+	outputs, err := pipeOutputs(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		outputs.close()
+		return err
+	}
+	// the started processes hold the write ends of the pipes
+	outputs.closeWriters()
+
+	done := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		// the processes inheriting the command's outputs might write to them after the command exited
+		if copyErr := outputs.wait(); err == nil {
+			err = copyErr
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if err := killProcessTree(cmd); err != nil {
+			log.Debugf("Failed to kill process tree of (%s): %s", strings.Join(cmd.Args, " "), err)
+		}
+		outputs.close()
+		<-done
+		return fmt.Errorf("command (%s) stopped: %w", cmd.Path, ctx.Err())
+	}
+}
+
+// outputPipes copies the command's outputs, which are not files, through pipes (like exec.Cmd does),
+// the pipes can be closed while the processes inheriting them still keep them open.
+type outputPipes struct {
+	readers []*os.File
+	writers []*os.File
+	copied  chan error
+}
+
+func pipeOutputs(cmd *exec.Cmd) (*outputPipes, error) {
+	outputs := &outputPipes{copied: make(chan error, 2)}
+
+	stdout, err := outputs.pipe(cmd.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	// like exec.Cmd, the same writer gets the same pipe, so the writer is not written concurrently
+	stderr := stdout
+	if !sameWriter(cmd.Stderr, cmd.Stdout) {
+		if stderr, err = outputs.pipe(cmd.Stderr); err != nil {
+			outputs.close()
+			return nil, err
+		}
+	}
+
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	return outputs, nil
+}
+
+// pipe returns the pipe copying to the given writer, or the writer itself if the command can write it directly.
+func (outputs *outputPipes) pipe(writer io.Writer) (io.Writer, error) {
+	if writer == nil {
+		return nil, nil
+	}
+	if _, ok := writer.(*os.File); ok {
+		return writer, nil
+	}
+
+	reader, pipeWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	outputs.readers = append(outputs.readers, reader)
+	outputs.writers = append(outputs.writers, pipeWriter)
+
+	go func() {
+		_, err := io.Copy(writer, reader)
+		outputs.copied <- err
+	}()
+
+	return pipeWriter, nil
+}
+
+func (outputs *outputPipes) closeWriters() {
+	for _, writer := range outputs.writers {
+		if err := writer.Close(); err != nil {
+			log.Debugf("Failed to close output pipe: %s", err)
+		}
+	}
+	outputs.writers = nil
+}
+
+// close closes the pipes, the copying of the output stops.
+func (outputs *outputPipes) close() {
+	outputs.closeWriters()
+	for _, reader := range outputs.readers {
+		if err := reader.Close(); err != nil {
+			log.Debugf("Failed to close output pipe: %s", err)
+		}
+	}
+}
+
+// wait waits for the output to be copied and returns the first copy error.
+func (outputs *outputPipes) wait() error {
+	var copyErr error
+	for range outputs.readers {
+		if err := <-outputs.copied; err != nil && copyErr == nil {
+			copyErr = err
+		}
+	}
+	return copyErr
+}
+
+// sameWriter returns true if the given writers are the same, the writers of uncomparable types are not the same.
+func sameWriter(a, b io.Writer) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a != nil && a == b
+}
@@ -0,0 +1,111 @@
+//go:build !windows
+// +build !windows
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCommandContext(t *testing.T) {
+	t.Log("it runs the command")
+	{
+		var out bytes.Buffer
+		require.NoError(t, RunCommandContext(context.Background(), []string{"echo", "hello"}, &out, nil))
+		require.Equal(t, "hello\n", out.String())
+	}
+
+	t.Log("it fails for empty command")
+	{
+		require.Error(t, RunCommandContext(context.Background(), []string{}, nil, nil))
+	}
+
+	t.Log("it does not start the command if the context is already cancelled")
+	{
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var out bytes.Buffer
+		err := RunCommandContext(ctx, []string{"echo", "hello"}, &out, nil)
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Equal(t, "", out.String())
+	}
+
+	t.Log("it kills the whole process tree when the deadline is exceeded")
+	{
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("command")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, os.RemoveAll(tmpDir))
+		}()
+		pidPth := filepath.Join(tmpDir, "child.pid")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		startTime := time.Now()
+		err = RunCommandContext(ctx, []string{"sh", "-c", "sleep 30 & echo $! > " + pidPth + "; wait"}, nil, nil)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		require.True(t, time.Since(startTime) < 10*time.Second)
+
+		content, err := os.ReadFile(pidPth)
+		require.NoError(t, err)
+		pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		require.NoError(t, err)
+
+		// the killed child is reaped by init, give it some time
+		killed := false
+		for i := 0; i < 50 && !killed; i++ {
+			killed = syscall.Kill(pid, 0) != nil
+			if !killed {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+		require.True(t, killed)
+	}
+	t.Log("it does not wait for the grandchild process holding the output after the kill")
+	{
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("command")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, os.RemoveAll(tmpDir))
+		}()
+		pidPth := filepath.Join(tmpDir, "grandchild.pid")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		// the job control starts the grandchild in its own process group, it is not killed with the command
+		var out bytes.Buffer
+		startTime := time.Now()
+		err = RunCommandContext(ctx, []string{"bash", "-c", "set -m; echo hello; sleep 30 & echo $! > " + pidPth + "; wait"}, &out, &out)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		require.True(t, time.Since(startTime) < 10*time.Second)
+		require.Equal(t, "hello\n", out.String())
+
+		content, err := os.ReadFile(pidPth)
+		require.NoError(t, err)
+		pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		require.NoError(t, err)
+		require.NoError(t, syscall.Kill(pid, syscall.SIGKILL))
+	}
+
+	t.Log("it writes the output of the processes exiting after the command")
+	{
+		var out bytes.Buffer
+		require.NoError(t, RunCommandContext(context.Background(), []string{"sh", "-c", "(sleep 0.2; echo late) &"}, &out, nil))
+		require.Equal(t, "late\n", out.String())
+	}
+}
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+)
+
+// Executor runs the processes of the tools' commands.
+type Executor interface {
+	Execute(ctx context.Context, cmdSlice []string, outWriter, errWriter io.Writer) error
+}
+
+// Executable is implemented by the commands running their process with an Executor.
+type Executable interface {
+	SetExecutor(executor Executor)
+}
+
+// OSExecutor runs the commands as OS processes, see RunCommandContext.
+type OSExecutor struct{}
+
+// Execute ...
+func (OSExecutor) Execute(ctx context.Context, cmdSlice []string, outWriter, errWriter io.Writer) error {
+	return RunCommandContext(ctx, cmdSlice, outWriter, errWriter)
+}
+
+// Execute runs the command slice with the given executor, a nil executor runs it as an OS process.
+func Execute(ctx context.Context, executor Executor, cmdSlice []string, outWriter, errWriter io.Writer) error {
+	if executor == nil {
+		executor = OSExecutor{}
+	}
+	return executor.Execute(ctx, cmdSlice, outWriter, errWriter)
+}
+
+// ExitCode returns the exit code of a command's error: 0 if the command succeeded,
+// -1 if it did not exit normally (like a command killed or failed to start).
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	var fakeExitErr *ExitError
+	if errors.As(err, &fakeExitErr) {
+		return fakeExitErr.Code
+	}
+	return -1
+}
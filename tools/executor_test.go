@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeExecutor(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__fake-executor-test__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	apkPth := filepath.Join(tmpDir, "bin", "Release", "com.bitrise.app-Signed.apk")
+	executor := NewFakeExecutor().
+		OnArg("/target:SignAndroidPackage", FakeResult{Stdout: "Build succeeded.\n", Artifacts: map[string]string{apkPth: "apk"}}).
+		OnArg("/target:Fail", FakeResult{ExitCode: 1, Stderr: "error CS0001: failed\n"}).
+		OnArg("missing", FakeResult{Err: errors.New("executable file not found")})
+
+	t.Log("it simulates the output and the artifacts of the command")
+	{
+		var out, errOut bytes.Buffer
+		require.NoError(t, Execute(context.Background(), executor, []string{"msbuild", "/target:SignAndroidPackage"}, &out, &errOut))
+		require.Equal(t, "Build succeeded.\n", out.String())
+		require.Equal(t, "", errOut.String())
+
+		content, err := os.ReadFile(apkPth)
+		require.NoError(t, err)
+		require.Equal(t, "apk", string(content))
+	}
+
+	t.Log("it simulates the exit code and the errors of the command")
+	{
+		var errOut bytes.Buffer
+		err := executor.Execute(context.Background(), []string{"msbuild", "/target:Fail"}, nil, &errOut)
+		var exitErr *ExitError
+		require.True(t, errors.As(err, &exitErr))
+		require.Equal(t, 1, exitErr.Code)
+		require.Equal(t, "error CS0001: failed\n", errOut.String())
+
+		require.EqualError(t, executor.Execute(context.Background(), []string{"missing"}, nil, nil), "executable file not found")
+	}
+
+	t.Log("the commands without result succeed")
+	{
+		var out bytes.Buffer
+		require.NoError(t, executor.Execute(context.Background(), []string{"nunit3-console"}, &out, nil))
+		require.Equal(t, "", out.String())
+	}
+
+	t.Log("it records the commands")
+	{
+		require.Equal(t, [][]string{
+			{"msbuild", "/target:SignAndroidPackage"},
+			{"msbuild", "/target:Fail"},
+			{"missing"},
+			{"nunit3-console"},
+		}, executor.Commands())
+	}
+
+	t.Log("it does not run the commands if the context is done")
+	{
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.True(t, errors.Is(executor.Execute(ctx, []string{"msbuild"}, nil, nil), context.Canceled))
+		require.Equal(t, 4, len(executor.Commands()))
+	}
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ExitError is the error of a command exiting with a non-zero exit code, simulated by the FakeExecutor.
+type ExitError struct {
+	Code int
+}
+
+// Error ...
+func (err *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", err.Code)
+}
+
+// FakeResult is the simulated result of a command run by the FakeExecutor.
+type FakeResult struct {
+	ExitCode  int
+	Stdout    string
+	Stderr    string
+	Artifacts map[string]string // Path - content of the files the command generates
+	Err       error             // Returned instead of running the command, like a missing executable's error
+}
+
+type fakeRule struct {
+	match  func(cmdSlice []string) bool
+	result FakeResult
+}
+
+// FakeExecutor records the commands instead of running them and simulates their results:
+// the command's output is written to the given writers, its artifacts are written to the disk.
+// The commands without a matching result succeed without output.
+type FakeExecutor struct {
+	mu       sync.Mutex
+	rules    []fakeRule
+	commands [][]string
+}
+
+// NewFakeExecutor ...
+func NewFakeExecutor() *FakeExecutor {
+	return &FakeExecutor{}
+}
+
+// On sets the result of the commands matching the given function, the first matching result is used.
+func (executor *FakeExecutor) On(match func(cmdSlice []string) bool, result FakeResult) *FakeExecutor {
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+
+	executor.rules = append(executor.rules, fakeRule{match: match, result: result})
+	return executor
+}
+
+// OnArg sets the result of the commands having an argument containing the given string.
+func (executor *FakeExecutor) OnArg(arg string, result FakeResult) *FakeExecutor {
+	return executor.On(func(cmdSlice []string) bool {
+		for _, a := range cmdSlice {
+			if strings.Contains(a, arg) {
+				return true
+			}
+		}
+		return false
+	}, result)
+}
+
+// Commands returns the recorded commands in execution order.
+func (executor *FakeExecutor) Commands() [][]string {
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+
+	commands := make([][]string, len(executor.commands))
+	copy(commands, executor.commands)
+	return commands
+}
+
+// Execute ...
+func (executor *FakeExecutor) Execute(ctx context.Context, cmdSlice []string, outWriter, errWriter io.Writer) error {
+	if len(cmdSlice) == 0 {
+		return fmt.Errorf("no command specified")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	executor.mu.Lock()
+	executor.commands = append(executor.commands, append([]string{}, cmdSlice...))
+	result := FakeResult{}
+	for _, rule := range executor.rules {
+		if rule.match(cmdSlice) {
+			result = rule.result
+			break
+		}
+	}
+	executor.mu.Unlock()
+
+	if result.Err != nil {
+		return result.Err
+	}
+
+	if outWriter == nil {
+		outWriter = os.Stdout
+	}
+	if errWriter == nil {
+		errWriter = os.Stderr
+	}
+	if _, err := io.WriteString(outWriter, result.Stdout); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(errWriter, result.Stderr); err != nil {
+		return err
+	}
+
+	for pth, content := range result.Artifacts {
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	if result.ExitCode != 0 {
+		return &ExitError{Code: result.ExitCode}
+	}
+	return nil
+}
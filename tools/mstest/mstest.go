@@ -0,0 +1,98 @@
+package mstest
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
+)
+
+// Model runs MSTest test assemblies with: dotnet vstest,
+// the MSTest adapter is copied next to the test assembly by the MSTest.TestAdapter package.
+type Model struct {
+	dotnetPth string
+
+	dllPth string
+	test   string
+
+	resultLogPth string
+
+	customOptions []string
+
+	executor tools.Executor
+}
+
+// New ...
+func New() (*Model, error) {
+	dotnet, err := toolchain.Locate(toolchain.Dotnet)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Model{dotnetPth: dotnet.Pth}, nil
+}
+
+// SetDLLPth ...
+func (vstest *Model) SetDLLPth(dllPth string) *Model {
+	vstest.dllPth = dllPth
+	return vstest
+}
+
+// SetTestToRun ...
+func (vstest *Model) SetTestToRun(test string) *Model {
+	vstest.test = test
+	return vstest
+}
+
+// SetResultLogPth ...
+func (vstest *Model) SetResultLogPth(resultLogPth string) *Model {
+	vstest.resultLogPth = resultLogPth
+	return vstest
+}
+
+// SetCustomOptions ...
+func (vstest *Model) SetCustomOptions(options ...string) {
+	vstest.customOptions = options
+}
+
+// SetExecutor sets the executor running the command, the command runs as an OS process by default.
+func (vstest *Model) SetExecutor(executor tools.Executor) {
+	vstest.executor = executor
+}
+
+func (vstest Model) commandSlice() []string {
+	cmdSlice := []string{vstest.dotnetPth, "vstest"}
+
+	if vstest.dllPth != "" {
+		cmdSlice = append(cmdSlice, vstest.dllPth)
+	}
+	if vstest.test != "" {
+		cmdSlice = append(cmdSlice, fmt.Sprintf("--Tests:%s", vstest.test))
+	}
+
+	if vstest.resultLogPth != "" {
+		cmdSlice = append(cmdSlice, fmt.Sprintf("--logger:trx;LogFileName=%s", vstest.resultLogPth))
+	}
+
+	cmdSlice = append(cmdSlice, vstest.customOptions...)
+	return cmdSlice
+}
+
+// String ...
+func (vstest Model) String() string {
+	cmdSlice := vstest.commandSlice()
+	return command.PrintableCommandArgs(true, cmdSlice)
+}
+
+// Run ...
+func (vstest Model) Run(outWriter, errWriter io.Writer) error {
+	return vstest.RunContext(context.Background(), outWriter, errWriter)
+}
+
+// RunContext runs the command, the command's process tree is killed when the context is done.
+func (vstest Model) RunContext(ctx context.Context, outWriter, errWriter io.Writer) error {
+	return tools.Execute(ctx, vstest.executor, vstest.commandSlice(), outWriter, errWriter)
+}
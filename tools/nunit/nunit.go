@@ -1,6 +1,7 @@
 package nunit
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -8,7 +9,8 @@ import (
 
 	"github.com/bitrise-io/go-utils/command"
 	"github.com/bitrise-io/go-utils/pathutil"
-	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
 )
 
 const (
@@ -17,6 +19,8 @@ const (
 
 // Model ...
 type Model struct {
+	monoPth string
+
 	nunitConsolePth string
 
 	projectPth string
@@ -28,6 +32,8 @@ type Model struct {
 	resultLogPth string
 
 	customOptions []string
+
+	executor tools.Executor
 }
 
 // SystemNunit3ConsolePath ...
@@ -54,7 +60,12 @@ func New(nunitConsolePth string) (*Model, error) {
 		return nil, fmt.Errorf("Failed to expand path (%s), error: %s", nunitConsolePth, err)
 	}
 
-	return &Model{nunitConsolePth: absNunitConsolePth}, nil
+	mono, err := toolchain.Locate(toolchain.Mono)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Model{monoPth: mono.Pth, nunitConsolePth: absNunitConsolePth}, nil
 }
 
 // SetProjectPth ...
@@ -92,8 +103,13 @@ func (nunitConsole *Model) SetCustomOptions(options ...string) {
 	nunitConsole.customOptions = options
 }
 
+// SetExecutor sets the executor running the command, the command runs as an OS process by default.
+func (nunitConsole *Model) SetExecutor(executor tools.Executor) {
+	nunitConsole.executor = executor
+}
+
 func (nunitConsole Model) commandSlice() []string {
-	cmdSlice := []string{constants.MonoPath}
+	cmdSlice := []string{nunitConsole.monoPth}
 	cmdSlice = append(cmdSlice, nunitConsole.nunitConsolePth)
 
 	if nunitConsole.projectPth != "" {
@@ -126,22 +142,10 @@ func (nunitConsole Model) String() string {
 
 // Run ...
 func (nunitConsole Model) Run(outWriter, errWriter io.Writer) error {
-	if outWriter == nil {
-		outWriter = os.Stdout
-	}
-	if errWriter == nil {
-		errWriter = os.Stderr
-	}
-
-	cmdSlice := nunitConsole.commandSlice()
-
-	command, err := command.NewFromSlice(cmdSlice)
-	if err != nil {
-		return err
-	}
-
-	command.SetStdout(outWriter)
-	command.SetStderr(errWriter)
+	return nunitConsole.RunContext(context.Background(), outWriter, errWriter)
+}
 
-	return command.Run()
+// RunContext runs the command, the command's process tree is killed when the context is done.
+func (nunitConsole Model) RunContext(ctx context.Context, outWriter, errWriter io.Writer) error {
+	return tools.Execute(ctx, nunitConsole.executor, nunitConsole.commandSlice(), outWriter, errWriter)
 }
@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package tools
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group,
+// so that the command and its child processes can be killed together.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func killProcessTree(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	// negative pid kills the whole process group
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
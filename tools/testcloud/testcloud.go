@@ -2,11 +2,14 @@ package testcloud
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"os/exec"
 
 	"github.com/bitrise-io/go-utils/command"
 	"github.com/bitrise-io/go-utils/pathutil"
-	"github.com/bitrise-io/go-xamarin/constants"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
 )
 
 // Parallelization ...
@@ -35,6 +38,8 @@ func ParseParallelization(parallelization string) (Parallelization, error) {
 
 // Model ...
 type Model struct {
+	monoPth string
+
 	testCloudExePth string
 
 	apkPth  string
@@ -61,7 +66,12 @@ func NewModel(testCloudExexPth string) (*Model, error) {
 		return nil, fmt.Errorf("Failed to expand path (%s), error: %s", testCloudExexPth, err)
 	}
 
-	return &Model{testCloudExePth: absTestCloudExexPth}, nil
+	mono, err := toolchain.Locate(toolchain.Mono)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Model{monoPth: mono.Pth, testCloudExePth: absTestCloudExexPth}, nil
 }
 
 // SetAPKPth ...
@@ -143,7 +153,7 @@ func (testCloud *Model) SetCustomOptions(options ...string) *Model {
 }
 
 func (testCloud *Model) submitCommandSlice() []string {
-	cmdSlice := []string{constants.MonoPath}
+	cmdSlice := []string{testCloud.monoPth}
 	cmdSlice = append(cmdSlice, testCloud.testCloudExePth)
 	cmdSlice = append(cmdSlice, "submit")
 
@@ -196,14 +206,14 @@ type CaptureLineCallback func(line string)
 
 // Submit ...
 func (testCloud Model) Submit(callback CaptureLineCallback) error {
-	cmdSlice := testCloud.submitCommandSlice()
+	return testCloud.SubmitContext(context.Background(), callback)
+}
 
-	command, err := command.NewFromSlice(cmdSlice)
-	if err != nil {
-		return err
-	}
+// SubmitContext submits the tests, the submit command's process tree is killed when the context is done.
+func (testCloud Model) SubmitContext(ctx context.Context, callback CaptureLineCallback) error {
+	cmdSlice := testCloud.submitCommandSlice()
 
-	cmd := *command.GetCmd()
+	cmd := exec.Command(cmdSlice[0], cmdSlice[1:]...)
 
 	// Redirect output
 	stdoutReader, err := cmd.StdoutPipe()
@@ -224,9 +234,5 @@ func (testCloud Model) Submit(callback CaptureLineCallback) error {
 		return err
 	}
 
-	if err := cmd.Start(); err != nil {
-		return err
-	}
-
-	return cmd.Wait()
+	return tools.RunCmdContext(ctx, cmd)
 }
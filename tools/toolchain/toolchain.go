@@ -0,0 +1,228 @@
+package toolchain
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-xamarin/constants"
+)
+
+// Tool describes where to look for a build tool.
+type Tool struct {
+	Name           string   // The executable's name, looked up in PATH
+	EnvKey         string   // Environment variable pointing to the executable or to its directory
+	KnownLocations []string // Default install locations
+	VersionArgs    []string
+}
+
+var (
+	// Msbuild ...
+	Msbuild = Tool{
+		Name:   "msbuild",
+		EnvKey: "MSBUILD_PATH",
+		KnownLocations: []string{
+			constants.MsbuildPath,
+			"/usr/local/bin/msbuild",
+			"/opt/homebrew/bin/msbuild",
+			"/usr/bin/msbuild",
+			"/usr/lib/mono/msbuild/Current/bin/msbuild",
+		},
+		VersionArgs: []string{"-version"},
+	}
+	// Xbuild ...
+	Xbuild = Tool{
+		Name:   "xbuild",
+		EnvKey: "XBUILD_PATH",
+		KnownLocations: []string{
+			constants.XbuildPath,
+			"/usr/local/bin/xbuild",
+			"/opt/homebrew/bin/xbuild",
+			"/usr/bin/xbuild",
+		},
+		VersionArgs: []string{"/version"},
+	}
+	// Mono ...
+	// MONO_PATH is not used, as mono reads it as its assembly search path (a list of directories).
+	Mono = Tool{
+		Name:   "mono",
+		EnvKey: "XAMARIN_MONO_PATH",
+		KnownLocations: []string{
+			constants.MonoPath,
+			"/usr/local/bin/mono",
+			"/opt/homebrew/bin/mono",
+			"/usr/bin/mono",
+		},
+		VersionArgs: []string{"--version"},
+	}
+	// Dotnet ...
+	Dotnet = Tool{
+		Name:   "dotnet",
+		EnvKey: "XAMARIN_DOTNET_PATH",
+		KnownLocations: []string{
+			"/usr/local/share/dotnet/dotnet",
+			"/usr/local/bin/dotnet",
+			"/opt/homebrew/bin/dotnet",
+			"/usr/share/dotnet/dotnet",
+			"/usr/bin/dotnet",
+		},
+		VersionArgs: []string{"--version"},
+	}
+)
+
+// Source ...
+type Source string
+
+const (
+	// SourceOverride ...
+	SourceOverride Source = "override"
+	// SourceEnvironment ...
+	SourceEnvironment Source = "environment"
+	// SourcePath ...
+	SourcePath Source = "PATH"
+	// SourceKnownLocation ...
+	SourceKnownLocation Source = "known location"
+)
+
+// Location is a found build tool.
+type Location struct {
+	Tool   Tool
+	Pth    string
+	Source Source
+}
+
+// Version runs the tool with its version arguments and returns the first version number of the output,
+// like 16.6.0 for: Microsoft (R) Build Engine version 16.6.0 for Mono
+func (location Location) Version() (string, error) {
+	cmd := command.New(location.Pth, location.Tool.VersionArgs...)
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s version, output: %s, error: %s", location.Tool.Name, out, err)
+	}
+
+	version := versionPattern.FindString(out)
+	if version == "" {
+		return "", fmt.Errorf("failed to find %s version in output: %s", location.Tool.Name, out)
+	}
+	return version, nil
+}
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// Locator resolves the build tools' paths from (in order): an explicit override, the tool's environment variable,
+// PATH and the known install locations.
+type Locator struct {
+	mu        sync.RWMutex
+	overrides map[string]string
+}
+
+// NewLocator ...
+func NewLocator() *Locator {
+	return &Locator{overrides: map[string]string{}}
+}
+
+// SetOverride sets the path to use for the given tool, an empty path removes the override.
+func (locator *Locator) SetOverride(tool Tool, pth string) *Locator {
+	locator.mu.Lock()
+	defer locator.mu.Unlock()
+
+	if pth == "" {
+		delete(locator.overrides, tool.Name)
+	} else {
+		locator.overrides[tool.Name] = pth
+	}
+	return locator
+}
+
+// Locate ...
+func (locator *Locator) Locate(tool Tool) (Location, error) {
+	locator.mu.RLock()
+	override := locator.overrides[tool.Name]
+	locator.mu.RUnlock()
+
+	if override != "" {
+		pth, err := executableAt(override, tool.Name)
+		if err != nil {
+			return Location{}, fmt.Errorf("%s override (%s) is invalid: %s", tool.Name, override, err)
+		}
+		return Location{Tool: tool, Pth: pth, Source: SourceOverride}, nil
+	}
+
+	if tool.EnvKey != "" {
+		if envPth := os.Getenv(tool.EnvKey); envPth != "" {
+			pth, err := executableAt(envPth, tool.Name)
+			if err != nil {
+				return Location{}, fmt.Errorf("%s environment variable (%s) is invalid: %s", tool.EnvKey, envPth, err)
+			}
+			return Location{Tool: tool, Pth: pth, Source: SourceEnvironment}, nil
+		}
+	}
+
+	if pth, err := exec.LookPath(tool.Name); err == nil {
+		if absPth, err := filepath.Abs(pth); err == nil {
+			pth = absPth
+		}
+		return Location{Tool: tool, Pth: pth, Source: SourcePath}, nil
+	}
+
+	for _, knownLocation := range tool.KnownLocations {
+		if pth, err := executableAt(knownLocation, tool.Name); err == nil {
+			return Location{Tool: tool, Pth: pth, Source: SourceKnownLocation}, nil
+		}
+	}
+
+	searched := []string{}
+	if tool.EnvKey != "" {
+		searched = append(searched, "$"+tool.EnvKey)
+	}
+	searched = append(searched, "PATH")
+	searched = append(searched, tool.KnownLocations...)
+
+	hint := "add it to the PATH"
+	if tool.EnvKey != "" {
+		hint = fmt.Sprintf("set the %s environment variable or %s", tool.EnvKey, hint)
+	}
+
+	return Location{}, fmt.Errorf("%s not found, %s (searched: %s)", tool.Name, hint, strings.Join(searched, ", "))
+}
+
+// executableAt returns the executable at the given path,
+// or the tool's executable in the given directory.
+func executableAt(pth, name string) (string, error) {
+	absPth, err := filepath.Abs(pth)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(absPth)
+	if err != nil {
+		return "", fmt.Errorf("not exist: %s", absPth)
+	}
+
+	if info.IsDir() {
+		return executableAt(filepath.Join(absPth, name), name)
+	}
+
+	if info.Mode()&0111 == 0 {
+		return "", fmt.Errorf("not executable: %s", absPth)
+	}
+
+	return absPth, nil
+}
+
+var defaultLocator = NewLocator()
+
+// SetOverride sets the path to use for the given tool by the default locator.
+func SetOverride(tool Tool, pth string) {
+	defaultLocator.SetOverride(tool, pth)
+}
+
+// Locate finds the given tool with the default locator.
+func Locate(tool Tool) (Location, error) {
+	return defaultLocator.Locate(tool)
+}
@@ -0,0 +1,147 @@
+package toolchain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeExecutable(t *testing.T, pth, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+	require.NoError(t, fileutil.WriteStringToFile(pth, content))
+	require.NoError(t, os.Chmod(pth, 0755))
+}
+
+func TestLocate(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__toolchain-test__")
+	require.NoError(t, err)
+
+	originalPath := os.Getenv("PATH")
+	defer func() {
+		require.NoError(t, os.Setenv("PATH", originalPath))
+		require.NoError(t, os.Unsetenv("TEST_MSBUILD_PATH"))
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	tool := Tool{
+		Name:           "msbuild",
+		EnvKey:         "TEST_MSBUILD_PATH",
+		KnownLocations: []string{filepath.Join(tmpDir, "known", "msbuild")},
+		VersionArgs:    []string{"-version"},
+	}
+	script := "#!/bin/sh\necho 'Microsoft (R) Build Engine version 16.6.0 for Mono'\necho '16.6.0.15201'\n"
+	writeExecutable(t, filepath.Join(tmpDir, "override", "msbuild"), script)
+	writeExecutable(t, filepath.Join(tmpDir, "env", "msbuild"), script)
+	writeExecutable(t, filepath.Join(tmpDir, "path", "msbuild"), script)
+	writeExecutable(t, filepath.Join(tmpDir, "known", "msbuild"), script)
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "not-executable"), 0755))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "not-executable", "msbuild"), script))
+
+	require.NoError(t, os.Setenv("PATH", filepath.Join(tmpDir, "path")))
+	require.NoError(t, os.Setenv("TEST_MSBUILD_PATH", filepath.Join(tmpDir, "env")))
+
+	t.Log("it prefers the override")
+	{
+		locator := NewLocator().SetOverride(tool, filepath.Join(tmpDir, "override", "msbuild"))
+		location, err := locator.Locate(tool)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "override", "msbuild"), location.Pth)
+		require.Equal(t, SourceOverride, location.Source)
+
+		version, err := location.Version()
+		require.NoError(t, err)
+		require.Equal(t, "16.6.0", version)
+	}
+
+	t.Log("it uses the environment variable, which might point to a directory")
+	{
+		location, err := NewLocator().Locate(tool)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "env", "msbuild"), location.Pth)
+		require.Equal(t, SourceEnvironment, location.Source)
+	}
+
+	t.Log("it uses PATH")
+	{
+		require.NoError(t, os.Unsetenv("TEST_MSBUILD_PATH"))
+
+		location, err := NewLocator().Locate(tool)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "path", "msbuild"), location.Pth)
+		require.Equal(t, SourcePath, location.Source)
+	}
+
+	t.Log("it uses the known locations")
+	{
+		require.NoError(t, os.Setenv("PATH", filepath.Join(tmpDir, "empty")))
+
+		location, err := NewLocator().Locate(tool)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "known", "msbuild"), location.Pth)
+		require.Equal(t, SourceKnownLocation, location.Source)
+	}
+
+	t.Log("it fails if the tool is not found")
+	{
+		tool.KnownLocations = []string{filepath.Join(tmpDir, "not-executable", "msbuild")}
+
+		_, err := NewLocator().Locate(tool)
+		require.EqualError(t, err, "msbuild not found, set the TEST_MSBUILD_PATH environment variable or add it to the PATH (searched: $TEST_MSBUILD_PATH, PATH, "+filepath.Join(tmpDir, "not-executable", "msbuild")+")")
+	}
+
+	t.Log("it fails if the override is invalid")
+	{
+		_, err := NewLocator().SetOverride(tool, filepath.Join(tmpDir, "not-executable", "msbuild")).Locate(tool)
+		require.Error(t, err)
+	}
+}
+
+func TestLocateMono(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__toolchain-test__")
+	require.NoError(t, err)
+
+	originalPath := os.Getenv("PATH")
+	originalMonoPath, monoPathSet := os.LookupEnv("MONO_PATH")
+	defer func() {
+		require.NoError(t, os.Setenv("PATH", originalPath))
+		if monoPathSet {
+			require.NoError(t, os.Setenv("MONO_PATH", originalMonoPath))
+		} else {
+			require.NoError(t, os.Unsetenv("MONO_PATH"))
+		}
+		require.NoError(t, os.Unsetenv(Mono.EnvKey))
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	writeExecutable(t, filepath.Join(tmpDir, "path", "mono"), "#!/bin/sh\necho 'Mono JIT compiler version 6.12.0.122'\n")
+	writeExecutable(t, filepath.Join(tmpDir, "env", "mono"), "#!/bin/sh\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "lib", "a"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "lib", "b"), 0755))
+
+	require.NoError(t, os.Setenv("PATH", filepath.Join(tmpDir, "path")))
+	require.NoError(t, os.Unsetenv(Mono.EnvKey))
+
+	t.Log("it ignores the MONO_PATH assembly search path")
+	{
+		require.NoError(t, os.Setenv("MONO_PATH", filepath.Join(tmpDir, "lib", "a")+":"+filepath.Join(tmpDir, "lib", "b")))
+
+		location, err := NewLocator().Locate(Mono)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "path", "mono"), location.Pth)
+		require.Equal(t, SourcePath, location.Source)
+	}
+
+	t.Log("it uses its own environment variable")
+	{
+		require.NoError(t, os.Setenv(Mono.EnvKey, filepath.Join(tmpDir, "env")))
+
+		location, err := NewLocator().Locate(Mono)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "env", "mono"), location.Pth)
+		require.Equal(t, SourceEnvironment, location.Source)
+	}
+}
@@ -1,12 +1,16 @@
 package tools
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
 // Runnable ...
 type Runnable interface {
 	String() string
 	SetCustomOptions(options ...string)
 	Run(outWriter, errWriter io.Writer) error
+	RunContext(ctx context.Context, outWriter, errWriter io.Writer) error
 }
 
 // Printable ...
@@ -0,0 +1,165 @@
+package xunit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xamarin/tools"
+	"github.com/bitrise-io/go-xamarin/tools/toolchain"
+)
+
+const (
+	xunitConsole = "xunit.console.exe"
+)
+
+// Model runs xunit test assemblies with: mono xunit.console.exe,
+// or the .NET (SDK-style) test assemblies with: dotnet vstest, see NewVSTest.
+type Model struct {
+	monoPth string
+
+	xunitConsolePth string
+
+	dotnetPth string
+
+	dllPth string
+	test   string
+
+	resultLogPth string
+
+	customOptions []string
+
+	executor tools.Executor
+}
+
+// SystemXunitConsolePath ...
+func SystemXunitConsolePath() (string, error) {
+	xunitDir := os.Getenv("XUNIT_PATH")
+	if xunitDir == "" {
+		return "", fmt.Errorf("XUNIT_PATH environment is not set, failed to determin xunit console path")
+	}
+
+	xunitConsolePth := filepath.Join(xunitDir, xunitConsole)
+	if exist, err := pathutil.IsPathExists(xunitConsolePth); err != nil {
+		return "", fmt.Errorf("Failed to check if xunit console exist at (%s), error: %s", xunitConsolePth, err)
+	} else if !exist {
+		return "", fmt.Errorf("xunit console not exist at: %s", xunitConsolePth)
+	}
+
+	return xunitConsolePth, nil
+}
+
+// New ...
+func New(xunitConsolePth string) (*Model, error) {
+	absXunitConsolePth, err := pathutil.AbsPath(xunitConsolePth)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to expand path (%s), error: %s", xunitConsolePth, err)
+	}
+
+	mono, err := toolchain.Locate(toolchain.Mono)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Model{monoPth: mono.Pth, xunitConsolePth: absXunitConsolePth}, nil
+}
+
+// NewVSTest creates the runner of .NET (SDK-style) test assemblies, which mono cannot run:
+// dotnet vstest runs them with the xunit adapter copied next to the test assembly by the xunit.runner.visualstudio package.
+func NewVSTest() (*Model, error) {
+	dotnet, err := toolchain.Locate(toolchain.Dotnet)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Model{dotnetPth: dotnet.Pth}, nil
+}
+
+// SetDLLPth ...
+func (xunitConsole *Model) SetDLLPth(dllPth string) *Model {
+	xunitConsole.dllPth = dllPth
+	return xunitConsole
+}
+
+// SetTestToRun ...
+func (xunitConsole *Model) SetTestToRun(test string) *Model {
+	xunitConsole.test = test
+	return xunitConsole
+}
+
+// SetResultLogPth ...
+func (xunitConsole *Model) SetResultLogPth(resultLogPth string) *Model {
+	xunitConsole.resultLogPth = resultLogPth
+	return xunitConsole
+}
+
+// SetCustomOptions ...
+func (xunitConsole *Model) SetCustomOptions(options ...string) {
+	xunitConsole.customOptions = options
+}
+
+// SetExecutor sets the executor running the command, the command runs as an OS process by default.
+func (xunitConsole *Model) SetExecutor(executor tools.Executor) {
+	xunitConsole.executor = executor
+}
+
+func (xunitConsole Model) commandSlice() []string {
+	if xunitConsole.dotnetPth != "" {
+		return xunitConsole.vstestCommandSlice()
+	}
+
+	cmdSlice := []string{xunitConsole.monoPth}
+	cmdSlice = append(cmdSlice, xunitConsole.xunitConsolePth)
+
+	if xunitConsole.dllPth != "" {
+		cmdSlice = append(cmdSlice, xunitConsole.dllPth)
+	}
+	if xunitConsole.test != "" {
+		cmdSlice = append(cmdSlice, "-method", xunitConsole.test)
+	}
+
+	if xunitConsole.resultLogPth != "" {
+		cmdSlice = append(cmdSlice, "-xml", xunitConsole.resultLogPth)
+	}
+
+	cmdSlice = append(cmdSlice, xunitConsole.customOptions...)
+	return cmdSlice
+}
+
+func (xunitConsole Model) vstestCommandSlice() []string {
+	cmdSlice := []string{xunitConsole.dotnetPth, "vstest"}
+
+	if xunitConsole.dllPth != "" {
+		cmdSlice = append(cmdSlice, xunitConsole.dllPth)
+	}
+	if xunitConsole.test != "" {
+		cmdSlice = append(cmdSlice, fmt.Sprintf("--Tests:%s", xunitConsole.test))
+	}
+
+	if xunitConsole.resultLogPth != "" {
+		cmdSlice = append(cmdSlice, fmt.Sprintf("--logger:trx;LogFileName=%s", xunitConsole.resultLogPth))
+	}
+
+	cmdSlice = append(cmdSlice, xunitConsole.customOptions...)
+	return cmdSlice
+}
+
+// String ...
+func (xunitConsole Model) String() string {
+	cmdSlice := xunitConsole.commandSlice()
+	return command.PrintableCommandArgs(true, cmdSlice)
+}
+
+// Run ...
+func (xunitConsole Model) Run(outWriter, errWriter io.Writer) error {
+	return xunitConsole.RunContext(context.Background(), outWriter, errWriter)
+}
+
+// RunContext runs the command, the command's process tree is killed when the context is done.
+func (xunitConsole Model) RunContext(ctx context.Context, outWriter, errWriter io.Writer) error {
+	return tools.Execute(ctx, xunitConsole.executor, xunitConsole.commandSlice(), outWriter, errWriter)
+}
@@ -0,0 +1,227 @@
+package utility
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// FSRoot is the directory the fs.FS file systems are mounted at by default:
+// the analyzers refer to the file a/b of an fs.FS by the path FSRoot/a/b (/a/b on unix).
+var FSRoot = fsRoot()
+
+func fsRoot() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return string(filepath.Separator)
+	}
+	return filepath.VolumeName(wd) + string(filepath.Separator)
+}
+
+// FileSystem is the file system the analyzers read from, either the OS file system or an fs.FS mounted at a root directory.
+// The analyzers work with OS paths in both cases, the paths outside of the root of a mounted fs.FS do not exist.
+// The zero value is the OS file system.
+type FileSystem struct {
+	fsys fs.FS
+	root string
+
+	recorder *PathRecorder // Records the read and probed paths, see WithRecorder
+}
+
+// PathRecorder collects the paths read or probed (like by Exists() conditions) through a FileSystem,
+// including the not existing ones.
+type PathRecorder struct {
+	mutex sync.Mutex
+	paths map[string]bool
+}
+
+// Paths returns the recorded paths in sorted order.
+func (recorder *PathRecorder) Paths() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	pths := []string{}
+	for pth := range recorder.paths {
+		pths = append(pths, pth)
+	}
+	sort.Strings(pths)
+	return pths
+}
+
+func (recorder *PathRecorder) record(pth string) {
+	if recorder == nil {
+		return
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	if recorder.paths == nil {
+		recorder.paths = map[string]bool{}
+	}
+	recorder.paths[filepath.Clean(pth)] = true
+}
+
+// OSFileSystem ...
+var OSFileSystem = FileSystem{}
+
+// NewFileSystem mounts the fs.FS at the given root directory.
+func NewFileSystem(fsys fs.FS, root string) FileSystem {
+	return FileSystem{fsys: fsys, root: filepath.Clean(root)}
+}
+
+// NewFSFileSystem mounts the fs.FS at FSRoot, a nil fs.FS is the OS file system.
+func NewFSFileSystem(fsys fs.FS) FileSystem {
+	if fsys == nil {
+		return OSFileSystem
+	}
+	return NewFileSystem(fsys, FSRoot)
+}
+
+// WithRecorder returns a copy of the file system recording the paths of the files read
+// and the paths probed (even the not existing ones) in the given recorder.
+func (fileSystem FileSystem) WithRecorder(recorder *PathRecorder) FileSystem {
+	fileSystem.recorder = recorder
+	return fileSystem
+}
+
+// IsOS returns true for the OS file system.
+func (fileSystem FileSystem) IsOS() bool {
+	return fileSystem.fsys == nil
+}
+
+// AbsPath returns the absolute path of the given path,
+// the relative paths of a mounted fs.FS are relative to its root.
+func (fileSystem FileSystem) AbsPath(pth string) (string, error) {
+	if fileSystem.IsOS() {
+		return pathutil.AbsPath(pth)
+	}
+	if pth == "" {
+		return "", errors.New("No Path provided")
+	}
+
+	pth = filepath.FromSlash(pth)
+	if !filepath.IsAbs(pth) {
+		pth = filepath.Join(fileSystem.root, pth)
+	}
+	return filepath.Clean(pth), nil
+}
+
+// ReadFile ...
+func (fileSystem FileSystem) ReadFile(pth string) ([]byte, error) {
+	fileSystem.recorder.record(pth)
+	if fileSystem.IsOS() {
+		return os.ReadFile(pth)
+	}
+
+	name, err := fileSystem.name("open", pth)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(fileSystem.fsys, name)
+}
+
+// ReadStringFromFile ...
+func (fileSystem FileSystem) ReadStringFromFile(pth string) (string, error) {
+	content, err := fileSystem.ReadFile(pth)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Stat ...
+func (fileSystem FileSystem) Stat(pth string) (fs.FileInfo, error) {
+	fileSystem.recorder.record(pth)
+	if fileSystem.IsOS() {
+		return os.Stat(pth)
+	}
+
+	name, err := fileSystem.name("stat", pth)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(fileSystem.fsys, name)
+}
+
+// IsPathExists ...
+func (fileSystem FileSystem) IsPathExists(pth string) (bool, error) {
+	fileSystem.recorder.record(pth)
+	if fileSystem.IsOS() {
+		return pathutil.IsPathExists(pth)
+	}
+
+	if _, err := fileSystem.Stat(pth); errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IsDirExists ...
+func (fileSystem FileSystem) IsDirExists(pth string) (bool, error) {
+	fileSystem.recorder.record(pth)
+	if fileSystem.IsOS() {
+		return pathutil.IsDirExists(pth)
+	}
+
+	info, err := fileSystem.Stat(pth)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return info.IsDir(), nil
+}
+
+// ReadDir returns the entries of the given directory sorted by file name.
+func (fileSystem FileSystem) ReadDir(pth string) ([]fs.DirEntry, error) {
+	if fileSystem.IsOS() {
+		return os.ReadDir(pth)
+	}
+
+	name, err := fileSystem.name("readdir", pth)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(fileSystem.fsys, name)
+}
+
+// Glob returns the paths matching the pattern, like filepath.Glob.
+func (fileSystem FileSystem) Glob(pattern string) ([]string, error) {
+	if fileSystem.IsOS() {
+		return filepath.Glob(pattern)
+	}
+
+	name, err := fileSystem.name("glob", pattern)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	matches, err := fs.Glob(fileSystem.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	for i, match := range matches {
+		matches[i] = filepath.Join(fileSystem.root, filepath.FromSlash(match))
+	}
+	return matches, nil
+}
+
+// name returns the fs.FS name of the given OS path.
+func (fileSystem FileSystem) name(op, pth string) (string, error) {
+	rel, err := filepath.Rel(fileSystem.root, filepath.Clean(pth))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &fs.PathError{Op: op, Path: pth, Err: fs.ErrNotExist}
+	}
+	return filepath.ToSlash(rel), nil
+}
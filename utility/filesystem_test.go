@@ -0,0 +1,100 @@
+package utility
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSystem(t *testing.T) {
+	root := filepath.Join(FSRoot, "repo")
+	fileSystem := NewFileSystem(fstest.MapFS{
+		"App.sln":                   {Data: []byte("solution")},
+		"src/App/App.csproj":        {Data: []byte("project")},
+		"src/App/Properties/a.xml":  {Data: []byte("a")},
+		"src/App/Properties/b.xml":  {Data: []byte("b")},
+		"src/Directory.Build.props": {Data: []byte("props")},
+	}, root)
+
+	t.Log("it resolves the paths relative to the mount directory")
+	{
+		require.False(t, fileSystem.IsOS())
+
+		pth, err := fileSystem.AbsPath("src/App/App.csproj")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(root, "src", "App", "App.csproj"), pth)
+
+		content, err := fileSystem.ReadStringFromFile(pth)
+		require.NoError(t, err)
+		require.Equal(t, "project", content)
+	}
+
+	t.Log("it checks the existence of files and directories")
+	{
+		exist, err := fileSystem.IsPathExists(filepath.Join(root, "src", "Directory.Build.props"))
+		require.NoError(t, err)
+		require.True(t, exist)
+
+		exist, err = fileSystem.IsDirExists(filepath.Join(root, "src", "App"))
+		require.NoError(t, err)
+		require.True(t, exist)
+
+		exist, err = fileSystem.IsDirExists(filepath.Join(root, "App.sln"))
+		require.NoError(t, err)
+		require.False(t, exist)
+
+		exist, err = fileSystem.IsPathExists(filepath.Join(root, "Directory.Build.props"))
+		require.NoError(t, err)
+		require.False(t, exist)
+	}
+
+	t.Log("the paths outside of the mount directory do not exist")
+	{
+		exist, err := fileSystem.IsPathExists(filepath.Join(FSRoot, "App.sln"))
+		require.NoError(t, err)
+		require.False(t, exist)
+
+		_, err = fileSystem.ReadFile(filepath.Join(root, "..", "repo2", "App.sln"))
+		require.True(t, os.IsNotExist(err))
+	}
+
+	t.Log("it expands the wildcards")
+	{
+		matches, err := fileSystem.Glob(filepath.Join(root, "src", "App", "Properties", "*.xml"))
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			filepath.Join(root, "src", "App", "Properties", "a.xml"),
+			filepath.Join(root, "src", "App", "Properties", "b.xml"),
+		}, matches)
+	}
+
+	t.Log("it records the read and probed paths")
+	{
+		recorder := &PathRecorder{}
+		recording := fileSystem.WithRecorder(recorder)
+
+		_, err := recording.ReadFile(filepath.Join(root, "src", "App", "Properties", "a.xml"))
+		require.NoError(t, err)
+		exist, err := recording.IsPathExists(filepath.Join(root, "local.props"))
+		require.NoError(t, err)
+		require.False(t, exist)
+
+		require.Equal(t, []string{
+			filepath.Join(root, "local.props"),
+			filepath.Join(root, "src", "App", "Properties", "a.xml"),
+		}, recorder.Paths())
+	}
+
+	t.Log("the zero value is the OS file system")
+	{
+		require.True(t, OSFileSystem.IsOS())
+		require.True(t, NewFSFileSystem(nil).IsOS())
+
+		exist, err := OSFileSystem.IsDirExists(os.TempDir())
+		require.NoError(t, err)
+		require.True(t, exist)
+	}
+}
@@ -0,0 +1,71 @@
+package utility
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ResolvePath returns the path of the existing file or directory the given path refers to.
+// Solutions and projects authored on Windows often refer to files with a different case than the files on disk,
+// so if the path does not exist, its components are looked up case-insensitively, like Windows does.
+// The returned warning describes the case mismatch, it is empty if the path exists as given.
+// If no file matches the path (or the lookup fails), the path is returned unchanged, reading it reports the error.
+func (fileSystem FileSystem) ResolvePath(pth string) (string, string) {
+	pth = filepath.Clean(pth)
+	if exist, err := fileSystem.IsPathExists(pth); err != nil || exist {
+		return pth, ""
+	}
+
+	// find the closest existing parent directory, the rest of the components are looked up case-insensitively
+	dir := pth
+	var components []string
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return pth, ""
+		}
+		components = append([]string{filepath.Base(dir)}, components...)
+		dir = parent
+
+		if exist, err := fileSystem.IsDirExists(dir); err != nil {
+			return pth, ""
+		} else if exist {
+			break
+		}
+	}
+
+	resolved := dir
+	for _, component := range components {
+		name, ok := fileSystem.lookupName(resolved, component)
+		if !ok {
+			return pth, ""
+		}
+		resolved = filepath.Join(resolved, name)
+	}
+
+	return resolved, fmt.Sprintf("path (%s) does not exist, using (%s) which differs only in case", pth, resolved)
+}
+
+// lookupName returns the name of the entry of the given directory matching the given name,
+// preferring the exact match to the case-insensitive ones.
+func (fileSystem FileSystem) lookupName(dir, name string) (string, bool) {
+	if name == "." || name == ".." {
+		return name, true
+	}
+
+	entries, err := fileSystem.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	match := ""
+	for _, entry := range entries {
+		if entry.Name() == name {
+			return name, true
+		}
+		if match == "" && strings.EqualFold(entry.Name(), name) {
+			match = entry.Name()
+		}
+	}
+	return match, match != ""
+}
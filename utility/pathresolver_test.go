@@ -0,0 +1,73 @@
+package utility
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePath(t *testing.T) {
+	root := filepath.Join(FSRoot, "repo")
+	fileSystem := NewFileSystem(fstest.MapFS{
+		"App.sln":                 {Data: []byte("solution")},
+		"Core/Core.csproj":        {Data: []byte("project")},
+		"Droid/Properties/a.xml":  {Data: []byte("a")},
+		"droid/Properties/b.xml":  {Data: []byte("b")},
+		"Build/Directory.targets": {Data: []byte("targets")},
+	}, root)
+
+	t.Log("it returns the existing paths without warning")
+	{
+		pth, warning := fileSystem.ResolvePath(filepath.Join(root, "Core", "Core.csproj"))
+		require.Equal(t, filepath.Join(root, "Core", "Core.csproj"), pth)
+		require.Equal(t, "", warning)
+	}
+
+	t.Log("it looks up the missing paths case-insensitively")
+	{
+		pth, warning := fileSystem.ResolvePath(filepath.Join(root, "core", "core.csproj"))
+		require.Equal(t, filepath.Join(root, "Core", "Core.csproj"), pth)
+		require.Contains(t, warning, "differs only in case")
+
+		pth, _ = fileSystem.ResolvePath(filepath.Join(root, "Core", "..", "BUILD", "directory.targets"))
+		require.Equal(t, filepath.Join(root, "Build", "Directory.targets"), pth)
+	}
+
+	t.Log("it prefers the exact match of the path components")
+	{
+		pth, _ := fileSystem.ResolvePath(filepath.Join(root, "droid", "properties", "b.xml"))
+		require.Equal(t, filepath.Join(root, "droid", "Properties", "b.xml"), pth)
+	}
+
+	t.Log("it returns the paths not matching any file unchanged")
+	{
+		pth, warning := fileSystem.ResolvePath(filepath.Join(root, "core", "missing.csproj"))
+		require.Equal(t, filepath.Join(root, "core", "missing.csproj"), pth)
+		require.Equal(t, "", warning)
+
+		pth, warning = fileSystem.ResolvePath(filepath.Join(FSRoot, "other", "App.sln"))
+		require.Equal(t, filepath.Join(FSRoot, "other", "App.sln"), pth)
+		require.Equal(t, "", warning)
+	}
+
+	t.Log("it resolves the paths of the OS file system")
+	{
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("__path-resolver-test__")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, os.RemoveAll(tmpDir))
+		}()
+
+		projectPth := filepath.Join(tmpDir, "Core", "Core.csproj")
+		require.NoError(t, os.MkdirAll(filepath.Dir(projectPth), 0777))
+		require.NoError(t, fileutil.WriteStringToFile(projectPth, "project"))
+
+		pth, _ := OSFileSystem.ResolvePath(filepath.Join(tmpDir, "CORE", "core.CSPROJ"))
+		require.Equal(t, projectPth, pth)
+	}
+}